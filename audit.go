@@ -0,0 +1,77 @@
+package shipping
+
+import (
+	"context"
+	"time"
+)
+
+// AuditAction is the kind of configuration change an AuditEntry records.
+type AuditAction string
+
+const (
+	AuditUpdatePacks          AuditAction = "update_packs"
+	AuditUpdateWarehousePacks AuditAction = "update_warehouse_packs"
+	AuditUpdateDefaultPacks   AuditAction = "update_default_packs"
+)
+
+// AuditEntry records an attempt to change a pack configuration, whether it succeeded or not.
+type AuditEntry struct {
+	ID        uint64      `json:"id"`
+	Tenant    string      `json:"-"`
+	Action    AuditAction `json:"action"`
+	Principal string      `json:"principal"`
+	// Client is the address and user agent the change was requested from
+	Client    string   `json:"client,omitempty"`
+	ProductID uint64   `json:"product_id,omitempty"`
+	Warehouse string   `json:"warehouse,omitempty"`
+	Before    []uint64 `json:"before"`
+	After     []uint64 `json:"after"`
+	Success   bool     `json:"success"`
+	// Error explains why a failed attempt was rejected
+	Error string    `json:"error,omitempty"`
+	At    time.Time `json:"at"`
+}
+
+// AuditQuery filters the audit entries of a tenant, zero fields match every entry.
+type AuditQuery struct {
+	Tenant    string
+	ProductID uint64
+	Principal string
+	From      time.Time
+	To        time.Time
+	// Limit bounds the number of entries returned, the most recent first
+	Limit int
+}
+
+// Matches tells whether the entry satisfies every filter of the query.
+func (q AuditQuery) Matches(e AuditEntry) bool {
+	return e.Tenant == q.Tenant &&
+		(q.ProductID == 0 || e.ProductID == q.ProductID) &&
+		(q.Principal == "" || e.Principal == q.Principal) &&
+		(q.From.IsZero() || !e.At.Before(q.From)) &&
+		(q.To.IsZero() || e.At.Before(q.To))
+}
+
+// AuditSink receives every audit entry, e.g. to ship it to a log pipeline.
+type AuditSink interface {
+	Record(ctx context.Context, entry AuditEntry) error
+}
+
+// AuditRepository keeps the audit entries, so they can be queried back.
+type AuditRepository interface {
+	Store(ctx context.Context, entry AuditEntry) (uint64, error)
+	Find(ctx context.Context, query AuditQuery) ([]AuditEntry, error)
+}
+
+type clientKey struct{}
+
+// WithClient returns a copy of ctx carrying a description of the client making the request, for auditing.
+func WithClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ClientFromContext returns the description of the client making the request.
+func ClientFromContext(ctx context.Context) (string, bool) {
+	client, ok := ctx.Value(clientKey{}).(string)
+	return client, ok
+}
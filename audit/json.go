@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// NewJSONSink returns a sink writing every entry as a line of JSON, e.g. to os.Stdout for a log collector.
+// Unlike the JSON of the audit endpoint, the lines include the tenant of the entry.
+func NewJSONSink(w io.Writer) shipping.AuditSink {
+	return &jsonSink{
+		enc: json.NewEncoder(w),
+	}
+}
+
+type jsonSink struct {
+	mtx sync.Mutex
+	enc *json.Encoder
+}
+
+type jsonEntry struct {
+	Tenant string `json:"tenant"`
+	shipping.AuditEntry
+}
+
+func (js *jsonSink) Record(_ context.Context, entry shipping.AuditEntry) error {
+	js.mtx.Lock()
+	defer js.mtx.Unlock()
+	return js.enc.Encode(jsonEntry{
+		Tenant:     entry.Tenant,
+		AuditEntry: entry,
+	})
+}
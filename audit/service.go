@@ -0,0 +1,95 @@
+// Package audit records the configuration changes and lets tenants query them back.
+package audit
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/silvan-talos/shipping"
+)
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+var (
+	ErrInvalidLimit = errors.New("invalid request: limit must be between 0 and 1000")
+	ErrInvalidRange = errors.New("invalid request: from must be before to")
+)
+
+type Service interface {
+	// Record stores the entry and forwards it to the sinks, it implements shipping.AuditSink
+	Record(ctx context.Context, entry shipping.AuditEntry) error
+	FindEntries(ctx context.Context, query shipping.AuditQuery) ([]shipping.AuditEntry, error)
+}
+
+type service struct {
+	entries shipping.AuditRepository
+	sinks   []shipping.AuditSink
+}
+
+func NewService(args ServiceArgs) Service {
+	err := shipping.Validate.Struct(args)
+	if err != nil {
+		log.Fatal("failed to create audit service, err:", err)
+	}
+
+	return &service{
+		entries: args.Entries,
+		sinks:   args.Sinks,
+	}
+}
+
+type ServiceArgs struct {
+	// Entries keeps the entries the audit endpoint is queried from
+	Entries shipping.AuditRepository `validate:"required"`
+	// Sinks are optional, every entry is also sent to them
+	Sinks []shipping.AuditSink
+}
+
+func (s *service) Record(ctx context.Context, entry shipping.AuditEntry) error {
+	if entry.At.IsZero() {
+		entry.At = time.Now().UTC()
+	}
+	var err error
+	entry.ID, err = s.entries.Store(ctx, entry)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to store audit entry", "entry", entry, "err", err)
+		return shipping.InternalServerErr
+	}
+	// a failing sink must not lose the entry for the others, the repository already holds it
+	for _, sink := range s.sinks {
+		if err := sink.Record(ctx, entry); err != nil {
+			slog.ErrorContext(ctx, "failed to send audit entry to sink", "entry_id", entry.ID, "err", err)
+		}
+	}
+	return nil
+}
+
+// FindEntries returns the entries of the tenant matching the query, the most recent first.
+func (s *service) FindEntries(ctx context.Context, query shipping.AuditQuery) ([]shipping.AuditEntry, error) {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return nil, shipping.ErrMissingTenant
+	}
+	if query.Limit < 0 || query.Limit > MaxLimit {
+		return nil, ErrInvalidLimit
+	}
+	if query.Limit == 0 {
+		query.Limit = DefaultLimit
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return nil, ErrInvalidRange
+	}
+	query.Tenant = tenant
+	entries, err := s.entries.Find(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find audit entries", "err", err)
+		return nil, shipping.InternalServerErr
+	}
+	return entries, nil
+}
@@ -0,0 +1,147 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/audit"
+	"github.com/silvan-talos/shipping/inmem"
+)
+
+var tenantCtx = shipping.WithTenant(context.Background(), "tenant-1")
+
+type failingRepository struct {
+	shipping.AuditRepository
+}
+
+func (failingRepository) Store(context.Context, shipping.AuditEntry) (uint64, error) {
+	return 0, errors.New("disk full")
+}
+
+func (failingRepository) Find(context.Context, shipping.AuditQuery) ([]shipping.AuditEntry, error) {
+	return nil, errors.New("disk full")
+}
+
+func TestService_Record(t *testing.T) {
+	var out bytes.Buffer
+	s := audit.NewService(audit.ServiceArgs{
+		Entries: inmem.NewAuditRepository(),
+		Sinks:   []shipping.AuditSink{audit.NewJSONSink(&out)},
+	})
+	entry := shipping.AuditEntry{
+		Tenant:    "tenant-1",
+		Action:    shipping.AuditUpdatePacks,
+		Principal: "user-1",
+		ProductID: 1,
+		Before:    []uint64{250},
+		After:     []uint64{250, 500},
+		Success:   true,
+	}
+	require.NoError(t, s.Record(tenantCtx, entry))
+
+	entries, err := s.FindEntries(tenantCtx, shipping.AuditQuery{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, uint64(1), entries[0].ID)
+	require.False(t, entries[0].At.IsZero())
+	require.Equal(t, entry.After, entries[0].After)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	require.Equal(t, "tenant-1", line["tenant"])
+	require.Equal(t, "user-1", line["principal"])
+	require.Equal(t, float64(1), line["id"])
+
+	s = audit.NewService(audit.ServiceArgs{
+		Entries: failingRepository{},
+	})
+	require.Equal(t, shipping.InternalServerErr, s.Record(tenantCtx, entry))
+}
+
+func TestService_FindEntries(t *testing.T) {
+	repo := inmem.NewAuditRepository()
+	s := audit.NewService(audit.ServiceArgs{
+		Entries: repo,
+	})
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	for i, e := range []shipping.AuditEntry{
+		{Tenant: "tenant-1", Principal: "user-1", ProductID: 1},
+		{Tenant: "tenant-2", Principal: "user-1", ProductID: 1},
+		{Tenant: "tenant-1", Principal: "user-2", ProductID: 2},
+		{Tenant: "tenant-1", Principal: "user-1", ProductID: 2},
+	} {
+		e.At = start.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, s.Record(tenantCtx, e))
+	}
+
+	tests := map[string]struct {
+		ctx         context.Context
+		query       shipping.AuditQuery
+		expectedIDs []uint64
+		expectedErr error
+	}{
+		"noFilter_returnEntriesOfTenantMostRecentFirst": {
+			ctx:         tenantCtx,
+			expectedIDs: []uint64{4, 3, 1},
+		},
+		"byProduct_returnEntriesOfProduct": {
+			ctx:         tenantCtx,
+			query:       shipping.AuditQuery{ProductID: 1},
+			expectedIDs: []uint64{1},
+		},
+		"byPrincipalAndRange_returnMatchingEntries": {
+			ctx: tenantCtx,
+			query: shipping.AuditQuery{
+				Principal: "user-1",
+				From:      start.Add(time.Hour),
+				To:        start.Add(4 * time.Hour),
+			},
+			expectedIDs: []uint64{4},
+		},
+		"limit_returnMostRecent": {
+			ctx:         tenantCtx,
+			query:       shipping.AuditQuery{Limit: 2},
+			expectedIDs: []uint64{4, 3},
+		},
+		"tenantOfQueryIgnored_returnEntriesOfContextTenant": {
+			ctx:         tenantCtx,
+			query:       shipping.AuditQuery{Tenant: "tenant-2"},
+			expectedIDs: []uint64{4, 3, 1},
+		},
+		"limitTooHigh_returnErrInvalidLimit": {
+			ctx:         tenantCtx,
+			query:       shipping.AuditQuery{Limit: audit.MaxLimit + 1},
+			expectedErr: audit.ErrInvalidLimit,
+		},
+		"fromAfterTo_returnErrInvalidRange": {
+			ctx:         tenantCtx,
+			query:       shipping.AuditQuery{From: start.Add(time.Hour), To: start},
+			expectedErr: audit.ErrInvalidRange,
+		},
+		"tenantMissing_returnErrMissingTenant": {
+			ctx:         context.Background(),
+			expectedErr: shipping.ErrMissingTenant,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			entries, err := s.FindEntries(tc.ctx, tc.query)
+			require.Equal(t, tc.expectedErr, err)
+			if tc.expectedErr != nil {
+				return
+			}
+			ids := make([]uint64, 0, len(entries))
+			for _, e := range entries {
+				ids = append(ids, e.ID)
+			}
+			require.Equal(t, tc.expectedIDs, ids)
+		})
+	}
+}
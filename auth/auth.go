@@ -0,0 +1,159 @@
+// Package auth authenticates the callers of the API with static API keys or with JWTs signed by
+// one of the keys of a local JWKS file.
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/silvan-talos/shipping"
+)
+
+var (
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrInvalidToken    = fmt.Errorf("%w: invalid token", ErrUnauthenticated)
+	ErrExpiredToken    = fmt.Errorf("%w: token expired", ErrUnauthenticated)
+	ErrUnknownAPIKey   = fmt.Errorf("%w: unknown api key", ErrUnauthenticated)
+	ErrNoKeys          = errors.New("auth: neither api keys nor a jwks file configured")
+)
+
+type AuthenticatorArgs struct {
+	// APIKeysFile is a JSON file listing the API keys, see APIKey
+	APIKeysFile string
+	// JWKSFile is a JSON Web Key Set file holding the keys tokens are verified with
+	JWKSFile string
+	// Issuer and Audience are checked against the iss and aud claims of tokens when set
+	Issuer   string
+	Audience string
+	// TenantClaim is the claim holding the tenant of the caller, defaults to "tenant"
+	TenantClaim string
+}
+
+// APIKey is an entry of the API keys file. Only the SHA-256 hash of the key is stored.
+type APIKey struct {
+	ID        string   `json:"id"`
+	KeySHA256 string   `json:"key_sha256"`
+	Tenant    string   `json:"tenant"`
+	Scopes    []string `json:"scopes"`
+	Roles     []string `json:"roles"`
+}
+
+type Authenticator struct {
+	apiKeys     map[string]shipping.Principal
+	jwks        map[string]publicKey
+	issuer      string
+	audience    string
+	tenantClaim string
+	now         func() time.Time
+}
+
+func NewAuthenticator(args AuthenticatorArgs) (*Authenticator, error) {
+	if args.APIKeysFile == "" && args.JWKSFile == "" {
+		return nil, ErrNoKeys
+	}
+	a := &Authenticator{
+		apiKeys:     make(map[string]shipping.Principal),
+		jwks:        make(map[string]publicKey),
+		issuer:      args.Issuer,
+		audience:    args.Audience,
+		tenantClaim: args.TenantClaim,
+		now:         time.Now,
+	}
+	if a.tenantClaim == "" {
+		a.tenantClaim = "tenant"
+	}
+	if args.APIKeysFile != "" {
+		if err := a.loadAPIKeys(args.APIKeysFile); err != nil {
+			return nil, err
+		}
+	}
+	if args.JWKSFile != "" {
+		keys, err := loadJWKS(args.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.jwks = keys
+	}
+	return a, nil
+}
+
+// AuthenticateAPIKey returns the principal the API key was issued to.
+func (a *Authenticator) AuthenticateAPIKey(key string) (shipping.Principal, error) {
+	sum := sha256.Sum256([]byte(key))
+	p, ok := a.apiKeys[hex.EncodeToString(sum[:])]
+	if !ok {
+		return shipping.Principal{}, ErrUnknownAPIKey
+	}
+	return p, nil
+}
+
+// AuthenticateToken verifies the JWT and returns the principal it was issued to. The principal is
+// the subject of the token, with the scopes of its scope claim, the roles of its roles claim and the
+// tenant of its tenant claim.
+func (a *Authenticator) AuthenticateToken(token string) (shipping.Principal, error) {
+	claims, err := a.verify(token)
+	if err != nil {
+		return shipping.Principal{}, err
+	}
+	p := shipping.Principal{
+		ID:     claims.Subject,
+		Scopes: strings.Fields(claims.Scope),
+		Roles:  claims.Roles,
+	}
+	if tenant, ok := claims.extra[a.tenantClaim].(string); ok {
+		p.Tenant = tenant
+	}
+	if p.ID == "" {
+		return shipping.Principal{}, fmt.Errorf("%w: subject missing", ErrInvalidToken)
+	}
+	return p, nil
+}
+
+func (a *Authenticator) loadAPIKeys(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read api keys: %w", err)
+	}
+	var keys []APIKey
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return fmt.Errorf("decode api keys %s: %w", path, err)
+	}
+	for _, k := range keys {
+		if k.ID == "" || len(k.KeySHA256) != sha256.Size*2 {
+			return fmt.Errorf("invalid api key %q in %s: id and key_sha256 are required", k.ID, path)
+		}
+		a.apiKeys[strings.ToLower(k.KeySHA256)] = shipping.Principal{
+			ID:     k.ID,
+			Tenant: k.Tenant,
+			Scopes: k.Scopes,
+			Roles:  k.Roles,
+		}
+	}
+	return nil
+}
+
+// Scopes granted to principals, every route of the API requires one of them.
+const (
+	ScopePackagingRead  = "packaging:read"
+	ScopePackagingWrite = "packaging:write"
+	ScopeLabelsWrite    = "labels:write"
+	ScopeShipmentsRead  = "shipments:read"
+	ScopeShipmentsWrite = "shipments:write"
+	ScopeInventoryRead  = "inventory:read"
+	ScopeInventoryWrite = "inventory:write"
+	ScopeAuditRead      = "audit:read"
+	// ScopeTenantsAdmin lets principals not bound to a tenant act for any tenant, picked per request
+	ScopeTenantsAdmin = "tenants:admin"
+)
+
+// Anonymous is the principal of every request when authentication is disabled.
+var Anonymous = shipping.Principal{
+	ID:     "anonymous",
+	Scopes: []string{shipping.ScopeAll},
+}
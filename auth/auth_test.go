@@ -0,0 +1,248 @@
+package auth_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/auth"
+)
+
+type keys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newKeys(t *testing.T) keys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return keys{rsa: rsaKey, ec: ecKey}
+}
+
+func writeFile(t *testing.T, name string, v any) string {
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+	return path
+}
+
+func (k keys) jwks(t *testing.T) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return writeFile(t, "jwks.json", map[string]any{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": "rsa-1",
+				"use": "sig",
+				"n":   enc(k.rsa.N.Bytes()),
+				"e":   enc(big.NewInt(int64(k.rsa.E)).Bytes()),
+			},
+			{
+				"kty": "EC",
+				"kid": "ec-1",
+				"crv": "P-256",
+				"x":   enc(k.ec.X.FillBytes(make([]byte, 32))),
+				"y":   enc(k.ec.Y.FillBytes(make([]byte, 32))),
+			},
+		},
+	})
+}
+
+// sign creates a JWT having the claims, signed with the RSA key for RS256 and with the EC key for ES256
+func (k keys) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	h, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	c, err := json.Marshal(claims)
+	require.NoError(t, err)
+	payload := enc(h) + "." + enc(c)
+	digest := sha256.Sum256([]byte(payload))
+	var sig []byte
+	switch alg {
+	case "RS256":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		require.NoError(t, err)
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return payload + "." + enc(sig)
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	_, err := auth.NewAuthenticator(auth.AuthenticatorArgs{})
+	require.ErrorIs(t, err, auth.ErrNoKeys)
+
+	_, err = auth.NewAuthenticator(auth.AuthenticatorArgs{JWKSFile: filepath.Join(t.TempDir(), "missing.json")})
+	require.Error(t, err)
+
+	_, err = auth.NewAuthenticator(auth.AuthenticatorArgs{
+		APIKeysFile: writeFile(t, "keys.json", []auth.APIKey{{ID: "ci", KeySHA256: "abc"}}),
+	})
+	require.Error(t, err)
+}
+
+func TestAuthenticator_AuthenticateAPIKey(t *testing.T) {
+	sum := sha256.Sum256([]byte("secret"))
+	a, err := auth.NewAuthenticator(auth.AuthenticatorArgs{
+		APIKeysFile: writeFile(t, "keys.json", []auth.APIKey{
+			{
+				ID:        "ci",
+				KeySHA256: hex.EncodeToString(sum[:]),
+				Tenant:    "tenant-1",
+				Scopes:    []string{auth.ScopePackagingRead},
+			},
+		}),
+	})
+	require.NoError(t, err)
+
+	p, err := a.AuthenticateAPIKey("secret")
+	require.NoError(t, err)
+	require.Equal(t, shipping.Principal{ID: "ci", Tenant: "tenant-1", Scopes: []string{auth.ScopePackagingRead}}, p)
+	require.True(t, p.HasScope(auth.ScopePackagingRead))
+	require.False(t, p.HasScope(auth.ScopePackagingWrite))
+
+	_, err = a.AuthenticateAPIKey("other")
+	require.ErrorIs(t, err, auth.ErrUnknownAPIKey)
+	require.ErrorIs(t, err, auth.ErrUnauthenticated)
+}
+
+func TestAuthenticator_AuthenticateToken(t *testing.T) {
+	k := newKeys(t)
+	a, err := auth.NewAuthenticator(auth.AuthenticatorArgs{
+		JWKSFile: k.jwks(t),
+		Issuer:   "https://issuer.example.com",
+		Audience: "shipping",
+	})
+	require.NoError(t, err)
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"sub":    "user-1",
+			"iss":    "https://issuer.example.com",
+			"aud":    []string{"shipping", "billing"},
+			"exp":    time.Now().Add(time.Hour).Unix(),
+			"scope":  "packaging:read packaging:write",
+			"tenant": "tenant-1",
+			"roles":  []string{"fragile-goods"},
+		}
+		for name, v := range overrides {
+			if v == nil {
+				delete(c, name)
+				continue
+			}
+			c[name] = v
+		}
+		return c
+	}
+	expected := shipping.Principal{
+		ID:     "user-1",
+		Tenant: "tenant-1",
+		Scopes: []string{auth.ScopePackagingRead, auth.ScopePackagingWrite},
+		Roles:  []string{"fragile-goods"},
+	}
+	otherKeys := newKeys(t)
+
+	tests := []struct {
+		name        string
+		token       string
+		expected    shipping.Principal
+		expectedErr error
+	}{
+		{
+			name:     "rs256_returnPrincipal",
+			token:    k.sign(t, "RS256", "rsa-1", claims(nil)),
+			expected: expected,
+		},
+		{
+			name:     "es256_returnPrincipal",
+			token:    k.sign(t, "ES256", "ec-1", claims(nil)),
+			expected: expected,
+		},
+		{
+			name:     "singleAudience_returnPrincipal",
+			token:    k.sign(t, "RS256", "rsa-1", claims(map[string]any{"aud": "shipping"})),
+			expected: expected,
+		},
+		{
+			name:        "expired_returnErrExpiredToken",
+			token:       k.sign(t, "RS256", "rsa-1", claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})),
+			expectedErr: auth.ErrExpiredToken,
+		},
+		{
+			name:        "noExpiry_returnErrExpiredToken",
+			token:       k.sign(t, "RS256", "rsa-1", claims(map[string]any{"exp": nil})),
+			expectedErr: auth.ErrExpiredToken,
+		},
+		{
+			name:        "notValidYet_returnErrInvalidToken",
+			token:       k.sign(t, "RS256", "rsa-1", claims(map[string]any{"nbf": time.Now().Add(time.Hour).Unix()})),
+			expectedErr: auth.ErrInvalidToken,
+		},
+		{
+			name:        "unknownKey_returnErrInvalidToken",
+			token:       k.sign(t, "RS256", "rsa-2", claims(nil)),
+			expectedErr: auth.ErrInvalidToken,
+		},
+		{
+			name:        "otherSigningKey_returnErrInvalidToken",
+			token:       otherKeys.sign(t, "RS256", "rsa-1", claims(nil)),
+			expectedErr: auth.ErrInvalidToken,
+		},
+		{
+			name:        "algorithmOfOtherKey_returnErrInvalidToken",
+			token:       k.sign(t, "ES256", "rsa-1", claims(nil)),
+			expectedErr: auth.ErrInvalidToken,
+		},
+		{
+			name:        "unexpectedIssuer_returnErrInvalidToken",
+			token:       k.sign(t, "RS256", "rsa-1", claims(map[string]any{"iss": "https://other.example.com"})),
+			expectedErr: auth.ErrInvalidToken,
+		},
+		{
+			name:        "unexpectedAudience_returnErrInvalidToken",
+			token:       k.sign(t, "RS256", "rsa-1", claims(map[string]any{"aud": "billing"})),
+			expectedErr: auth.ErrInvalidToken,
+		},
+		{
+			name:        "missingSubject_returnErrInvalidToken",
+			token:       k.sign(t, "RS256", "rsa-1", claims(map[string]any{"sub": nil})),
+			expectedErr: auth.ErrInvalidToken,
+		},
+		{
+			name:        "malformed_returnErrInvalidToken",
+			token:       "not-a-token",
+			expectedErr: auth.ErrInvalidToken,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := a.AuthenticateToken(tc.token)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+				require.ErrorIs(t, err, auth.ErrUnauthenticated)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, p)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// loadJWKS reads the RSA and P-256 signing keys of a JWKS file, indexed by key ID
+func loadJWKS(path string) (map[string]publicKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read jwks: %w", err)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("decode jwks %s: %w", path, err)
+	}
+	keys := make(map[string]publicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(k)
+		if err != nil {
+			return nil, fmt.Errorf("jwks %s, key %q: %w", path, k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func parseJWK(k jwk) (publicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		if k.Alg != "" && k.Alg != "RS256" {
+			return publicKey{}, fmt.Errorf("unsupported algorithm %q", k.Alg)
+		}
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return publicKey{}, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() {
+			return publicKey{}, fmt.Errorf("invalid exponent")
+		}
+		return publicKey{
+			alg: "RS256",
+			rsa: &rsa.PublicKey{N: n, E: int(e.Int64())},
+		}, nil
+	case "EC":
+		if k.Crv != "P-256" || (k.Alg != "" && k.Alg != "ES256") {
+			return publicKey{}, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return publicKey{}, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return publicKey{}, fmt.Errorf("y: %w", err)
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return publicKey{}, fmt.Errorf("point not on curve")
+		}
+		return publicKey{
+			alg: "ES256",
+			ec:  &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y},
+		}, nil
+	default:
+		return publicKey{}, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
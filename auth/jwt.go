@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// leeway tolerates small clock differences with the issuer of tokens
+const leeway = 30 * time.Second
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+	Scope     string   `json:"scope"`
+	Roles     []string `json:"roles"`
+	// extra holds every claim, for the configurable ones like the tenant
+	extra map[string]any
+}
+
+// audience is either a single string or a list of strings
+type audience []string
+
+func (aud *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*aud = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*aud = list
+	return nil
+}
+
+func (aud audience) contains(s string) bool {
+	for _, a := range aud {
+		if a == s {
+			return true
+		}
+	}
+	return false
+}
+
+// verify checks the signature and the registered claims of the token and returns its claims
+func (a *Authenticator) verify(token string) (claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims{}, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return claims{}, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	key, ok := a.jwks[h.Kid]
+	if !ok {
+		return claims{}, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, h.Kid)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims{}, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	// the algorithm must be the one of the key, so a token cannot pick a weaker verification
+	if h.Alg != key.alg {
+		return claims{}, fmt.Errorf("%w: algorithm %q not allowed for key %q", ErrInvalidToken, h.Alg, h.Kid)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !key.verify(digest[:], sig) {
+		return claims{}, fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return claims{}, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if err := decodeSegment(parts[1], &c.extra); err != nil {
+		return claims{}, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	now := a.now()
+	if c.ExpiresAt == nil || now.After(time.Unix(*c.ExpiresAt, 0).Add(leeway)) {
+		return claims{}, ErrExpiredToken
+	}
+	if c.NotBefore != nil && now.Add(leeway).Before(time.Unix(*c.NotBefore, 0)) {
+		return claims{}, fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+	if a.issuer != "" && c.Issuer != a.issuer {
+		return claims{}, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if a.audience != "" && !c.Audience.contains(a.audience) {
+		return claims{}, fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+	return c, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// publicKey verifies SHA-256 signatures with an RSA (RS256) or a P-256 (ES256) key
+type publicKey struct {
+	alg string
+	rsa *rsa.PublicKey
+	ec  *ecdsa.PublicKey
+}
+
+func (k publicKey) verify(digest, sig []byte) bool {
+	switch k.alg {
+	case "RS256":
+		return rsa.VerifyPKCS1v15(k.rsa, crypto.SHA256, digest, sig) == nil
+	case "ES256":
+		// JWS encodes ECDSA signatures as the concatenation of r and s
+		if len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(k.ec, digest, r, s)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,248 @@
+// Package cache provides caching decorators for the repositories of the shipping package.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/silvan-talos/shipping"
+)
+
+type PackRepositoryArgs struct {
+	Next shipping.PackRepository `validate:"required"`
+	// TTL is how long a configuration is served from the cache
+	TTL time.Duration `validate:"gt=0"`
+	// MaxEntries bounds the cached configurations, the least recently used ones are evicted first
+	MaxEntries int `validate:"gt=0"`
+}
+
+// Stats are the counters of a cache since it was created.
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+}
+
+// PackRepository caches the configurations read from the next repository, including the missing ones,
+// and drops them as soon as they are updated through it. Concurrent misses of the same configuration
+// result in a single read of the next repository.
+type PackRepository struct {
+	next       shipping.PackRepository
+	ttl        time.Duration
+	maxEntries int
+
+	mtx      sync.Mutex
+	entries  map[key]*list.Element
+	lru      *list.List
+	inflight map[key]*call
+	// epoch is incremented on every invalidation, so reads started before it are not cached
+	epoch uint64
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+func NewPackRepository(args PackRepositoryArgs) *PackRepository {
+	err := shipping.Validate.Struct(args)
+	if err != nil {
+		log.Fatal("failed to create pack repository cache, err:", err)
+	}
+
+	return &PackRepository{
+		next:       args.Next,
+		ttl:        args.TTL,
+		maxEntries: args.MaxEntries,
+		entries:    make(map[key]*list.Element),
+		lru:        list.New(),
+		inflight:   make(map[key]*call),
+	}
+}
+
+type kind int
+
+const (
+	kindProduct kind = iota
+	kindWarehouse
+	kindDefault
+)
+
+type key struct {
+	tenant    string
+	kind      kind
+	warehouse string
+	productID uint64
+}
+
+type entry struct {
+	key     key
+	config  []uint64
+	err     error
+	expires time.Time
+}
+
+// errLoadPanicked is returned to the callers sharing a load that panicked
+var errLoadPanicked = errors.New("load of pack config panicked")
+
+type call struct {
+	done   chan struct{}
+	config []uint64
+	err    error
+}
+
+func (pr *PackRepository) GetByProductID(ctx context.Context, productID uint64) ([]uint64, error) {
+	return pr.get(ctx, key{kind: kindProduct, productID: productID}, func(ctx context.Context) ([]uint64, error) {
+		return pr.next.GetByProductID(ctx, productID)
+	})
+}
+
+func (pr *PackRepository) GetByWarehouse(ctx context.Context, warehouse string, productID uint64) ([]uint64, error) {
+	return pr.get(ctx, key{kind: kindWarehouse, warehouse: warehouse, productID: productID}, func(ctx context.Context) ([]uint64, error) {
+		return pr.next.GetByWarehouse(ctx, warehouse, productID)
+	})
+}
+
+func (pr *PackRepository) GetDefault(ctx context.Context) ([]uint64, error) {
+	return pr.get(ctx, key{kind: kindDefault}, func(ctx context.Context) ([]uint64, error) {
+		return pr.next.GetDefault(ctx)
+	})
+}
+
+func (pr *PackRepository) UpdateConfig(ctx context.Context, productID uint64, config []uint64) ([]uint64, error) {
+	previous, err := pr.next.UpdateConfig(ctx, productID, config)
+	pr.invalidate(ctx, key{kind: kindProduct, productID: productID})
+	return previous, err
+}
+
+func (pr *PackRepository) UpdateWarehouseConfig(ctx context.Context, warehouse string, productID uint64, config []uint64) ([]uint64, error) {
+	previous, err := pr.next.UpdateWarehouseConfig(ctx, warehouse, productID, config)
+	pr.invalidate(ctx, key{kind: kindWarehouse, warehouse: warehouse, productID: productID})
+	return previous, err
+}
+
+func (pr *PackRepository) UpdateDefault(ctx context.Context, config []uint64) ([]uint64, error) {
+	previous, err := pr.next.UpdateDefault(ctx, config)
+	pr.invalidate(ctx, key{kind: kindDefault})
+	return previous, err
+}
+
+// Stats returns the counters of the cache.
+func (pr *PackRepository) Stats() Stats {
+	pr.mtx.Lock()
+	entries := pr.lru.Len()
+	pr.mtx.Unlock()
+	return Stats{
+		Hits:      pr.hits.Load(),
+		Misses:    pr.misses.Load(),
+		Evictions: pr.evictions.Load(),
+		Entries:   entries,
+	}
+}
+
+// get returns the cached configuration of k, loading it when it is missing or expired. The load of
+// the first caller is shared with the callers missing the same key meanwhile, so it is not canceled
+// along with the context of the first caller.
+func (pr *PackRepository) get(ctx context.Context, k key, load func(ctx context.Context) ([]uint64, error)) ([]uint64, error) {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return nil, shipping.ErrMissingTenant
+	}
+	k.tenant = tenant
+
+	pr.mtx.Lock()
+	if el, ok := pr.entries[k]; ok {
+		e := el.Value.(*entry)
+		if time.Now().Before(e.expires) {
+			pr.lru.MoveToFront(el)
+			pr.mtx.Unlock()
+			pr.hits.Add(1)
+			return clone(e.config), e.err
+		}
+		pr.remove(el)
+	}
+	pr.misses.Add(1)
+	if c, ok := pr.inflight[k]; ok {
+		pr.mtx.Unlock()
+		select {
+		case <-c.done:
+			return clone(c.config), c.err
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wait for pack config: %w", ctx.Err())
+		}
+	}
+	c := &call{done: make(chan struct{})}
+	pr.inflight[k] = c
+	epoch := pr.epoch
+	pr.mtx.Unlock()
+
+	// the callers waiting for a load that panics get errLoadPanicked, the panic going on to the first caller
+	c.err = errLoadPanicked
+	defer pr.finish(k, c, epoch)
+	c.config, c.err = load(context.WithoutCancel(ctx))
+	return clone(c.config), c.err
+}
+
+// finish caches the outcome of the call and hands it to the callers waiting for it
+func (pr *PackRepository) finish(k key, c *call, epoch uint64) {
+	pr.mtx.Lock()
+	delete(pr.inflight, k)
+	// only the configurations and the missing ones are cached, other errors are retried on the next call
+	if (c.err == nil || errors.Is(c.err, shipping.ErrNotFound)) && epoch == pr.epoch {
+		pr.add(&entry{
+			key:     k,
+			config:  clone(c.config),
+			err:     c.err,
+			expires: time.Now().Add(pr.ttl),
+		})
+	}
+	pr.mtx.Unlock()
+	close(c.done)
+}
+
+// add caches the entry, evicting the least recently used one when the cache is full, callers must hold the lock
+func (pr *PackRepository) add(e *entry) {
+	if el, ok := pr.entries[e.key]; ok {
+		pr.remove(el)
+	}
+	pr.entries[e.key] = pr.lru.PushFront(e)
+	for pr.lru.Len() > pr.maxEntries {
+		pr.remove(pr.lru.Back())
+		pr.evictions.Add(1)
+	}
+}
+
+// remove drops the cached entry, callers must hold the lock
+func (pr *PackRepository) remove(el *list.Element) {
+	pr.lru.Remove(el)
+	delete(pr.entries, el.Value.(*entry).key)
+}
+
+func (pr *PackRepository) invalidate(ctx context.Context, k key) {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return
+	}
+	k.tenant = tenant
+	pr.mtx.Lock()
+	defer pr.mtx.Unlock()
+	pr.epoch++
+	if el, ok := pr.entries[k]; ok {
+		pr.remove(el)
+	}
+}
+
+func clone(config []uint64) []uint64 {
+	if config == nil {
+		return nil
+	}
+	res := make([]uint64, len(config))
+	copy(res, config)
+	return res
+}
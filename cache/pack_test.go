@@ -0,0 +1,240 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/cache"
+	"github.com/silvan-talos/shipping/mock"
+)
+
+var tenantCtx = shipping.WithTenant(context.Background(), "tenant-1")
+
+func newCache(next shipping.PackRepository, ttl time.Duration, maxEntries int) *cache.PackRepository {
+	return cache.NewPackRepository(cache.PackRepositoryArgs{
+		Next:       next,
+		TTL:        ttl,
+		MaxEntries: maxEntries,
+	})
+}
+
+func TestPackRepository_HitsAndMisses(t *testing.T) {
+	var calls atomic.Int32
+	c := newCache(&mock.PackRepository{
+		GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+			calls.Add(1)
+			if productID == 2 {
+				return nil, shipping.ErrNotFound
+			}
+			return []uint64{250, 500}, nil
+		},
+	}, time.Minute, 10)
+
+	for i := 0; i < 3; i++ {
+		config, err := c.GetByProductID(tenantCtx, 1)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{250, 500}, config)
+		_, err = c.GetByProductID(tenantCtx, 2)
+		require.ErrorIs(t, err, shipping.ErrNotFound)
+	}
+	require.Equal(t, int32(2), calls.Load())
+	require.Equal(t, cache.Stats{Hits: 4, Misses: 2, Entries: 2}, c.Stats())
+
+	// other tenants do not share the cached configurations
+	_, err := c.GetByProductID(shipping.WithTenant(context.Background(), "tenant-2"), 1)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), calls.Load())
+
+	_, err = c.GetByProductID(context.Background(), 1)
+	require.ErrorIs(t, err, shipping.ErrMissingTenant)
+}
+
+func TestPackRepository_Expiry(t *testing.T) {
+	var calls atomic.Int32
+	c := newCache(&mock.PackRepository{
+		GetDefaultFn: func(ctx context.Context) ([]uint64, error) {
+			calls.Add(1)
+			return []uint64{250}, nil
+		},
+	}, 20*time.Millisecond, 10)
+
+	_, err := c.GetDefault(tenantCtx)
+	require.NoError(t, err)
+	_, err = c.GetDefault(tenantCtx)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), calls.Load())
+	time.Sleep(30 * time.Millisecond)
+	_, err = c.GetDefault(tenantCtx)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestPackRepository_Eviction(t *testing.T) {
+	var calls atomic.Int32
+	c := newCache(&mock.PackRepository{
+		GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+			calls.Add(1)
+			return []uint64{productID}, nil
+		},
+	}, time.Minute, 2)
+
+	for _, id := range []uint64{1, 2, 1, 3} {
+		_, err := c.GetByProductID(tenantCtx, id)
+		require.NoError(t, err)
+	}
+	require.Equal(t, uint64(1), c.Stats().Evictions)
+	// 2 was the least recently used one
+	_, err := c.GetByProductID(tenantCtx, 1)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), calls.Load())
+	_, err = c.GetByProductID(tenantCtx, 2)
+	require.NoError(t, err)
+	require.Equal(t, int32(4), calls.Load())
+}
+
+func TestPackRepository_InvalidateOnUpdate(t *testing.T) {
+	next := &mock.PackRepository{}
+	config := []uint64{250}
+	next.GetByProductIDFn = func(ctx context.Context, productID uint64) ([]uint64, error) {
+		return config, nil
+	}
+	next.GetByWarehouseFn = func(ctx context.Context, warehouse string, productID uint64) ([]uint64, error) {
+		return config, nil
+	}
+	next.UpdateConfigFn = func(ctx context.Context, productID uint64, c []uint64) ([]uint64, error) {
+		config = c
+		return nil, nil
+	}
+	next.UpdateWarehouseConfigFn = func(ctx context.Context, warehouse string, productID uint64, c []uint64) ([]uint64, error) {
+		config = c
+		return nil, nil
+	}
+	c := newCache(next, time.Minute, 10)
+
+	got, err := c.GetByProductID(tenantCtx, 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{250}, got)
+	_, err = c.UpdateConfig(tenantCtx, 1, []uint64{500})
+	require.NoError(t, err)
+	got, err = c.GetByProductID(tenantCtx, 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{500}, got)
+
+	got, err = c.GetByWarehouse(tenantCtx, "w1", 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{500}, got)
+	_, err = c.UpdateWarehouseConfig(tenantCtx, "w1", 1, []uint64{1000})
+	require.NoError(t, err)
+	got, err = c.GetByWarehouse(tenantCtx, "w1", 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1000}, got)
+}
+
+func TestPackRepository_CoalesceMisses(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	c := newCache(&mock.PackRepository{
+		GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+			calls.Add(1)
+			<-release
+			return []uint64{250}, nil
+		},
+	}, time.Minute, 10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			config, err := c.GetByProductID(tenantCtx, 1)
+			if err != nil || len(config) != 1 {
+				t.Error("unexpected result", config, err)
+			}
+		}()
+	}
+	// let the callers pile up on the first read before releasing it
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestPackRepository_FirstCallerCanceled(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	c := newCache(&mock.PackRepository{
+		GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+			close(started)
+			<-release
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return []uint64{250}, nil
+		},
+	}, time.Minute, 10)
+
+	firstCtx, cancel := context.WithCancel(tenantCtx)
+	first := make(chan error)
+	go func() {
+		_, err := c.GetByProductID(firstCtx, 1)
+		first <- err
+	}()
+	<-started
+	second := make(chan []uint64)
+	go func() {
+		config, err := c.GetByProductID(tenantCtx, 1)
+		if err != nil {
+			t.Error("unexpected error", err)
+		}
+		second <- config
+	}()
+	// the first caller giving up does not cancel the load shared with the second one
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	close(release)
+	require.NoError(t, <-first)
+	require.Equal(t, []uint64{250}, <-second)
+}
+
+func TestPackRepository_LoadPanics(t *testing.T) {
+	var calls atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	c := newCache(&mock.PackRepository{
+		GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+			if calls.Add(1) == 1 {
+				close(started)
+				<-release
+				panic("repository failure")
+			}
+			return []uint64{250}, nil
+		},
+	}, time.Minute, 10)
+
+	go func() {
+		defer func() {
+			_ = recover()
+		}()
+		_, _ = c.GetByProductID(tenantCtx, 1)
+	}()
+	<-started
+	waiter := make(chan error)
+	go func() {
+		_, err := c.GetByProductID(tenantCtx, 1)
+		waiter <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	// the callers sharing the load get an error rather than hanging, the next ones load again
+	require.Error(t, <-waiter)
+	ctx, cancel := context.WithTimeout(tenantCtx, time.Second)
+	defer cancel()
+	config, err := c.GetByProductID(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{250}, config)
+	require.Equal(t, int32(2), calls.Load())
+}
@@ -0,0 +1,51 @@
+package shipping
+
+import (
+	"context"
+)
+
+// RateTable describes how a carrier prices parcels. All amounts are expressed in
+// the minor unit of Currency (e.g. cents), weights in kg and dimensions in cm.
+type RateTable struct {
+	Carrier      string       `json:"carrier"`
+	Currency     string       `json:"currency"`
+	DimDivisor   float64      `json:"dim_divisor"`
+	PerParcelFee int64        `json:"per_parcel_fee"`
+	WeightBands  []WeightBand `json:"weight_bands"`
+	Parcels      []Parcel     `json:"parcels"`
+}
+
+// WeightBand is the price of a parcel weighing up to MaxWeight.
+type WeightBand struct {
+	MaxWeight float64 `json:"max_weight"`
+	Price     int64   `json:"price"`
+}
+
+// Parcel holds the physical properties of a filled pack of a certain size and the cost
+// of the packaging material.
+type Parcel struct {
+	PackSize      uint64  `json:"pack_size"`
+	Weight        float64 `json:"weight"`
+	Length        float64 `json:"length"`
+	Width         float64 `json:"width"`
+	Height        float64 `json:"height"`
+	PackagingCost int64   `json:"packaging_cost"`
+}
+
+type RateRepository interface {
+	GetByCarrier(ctx context.Context, carrier string) (RateTable, error)
+}
+
+type PackCost struct {
+	Count    int64  `json:"number_of_packs"`
+	Size     uint64 `json:"pack_size"`
+	UnitCost int64  `json:"unit_cost"`
+	Cost     int64  `json:"cost"`
+}
+
+type CostEstimate struct {
+	Carrier  string     `json:"carrier"`
+	Currency string     `json:"currency"`
+	Packs    []PackCost `json:"packs"`
+	Total    int64      `json:"total_cost"`
+}
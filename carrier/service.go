@@ -0,0 +1,118 @@
+package carrier
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"math"
+	"sort"
+
+	"github.com/silvan-talos/shipping"
+)
+
+var (
+	ErrNoPacks       = errors.New("invalid request: no packs to price")
+	ErrUnknownParcel = errors.New("carrier has no parcel definition for pack size")
+	ErrOverweight    = errors.New("parcel exceeds the carrier weight limit")
+)
+
+type Service interface {
+	EstimateCost(ctx context.Context, carrier string, packs []shipping.PackConfig) (shipping.CostEstimate, error)
+}
+
+type service struct {
+	rates shipping.RateRepository
+}
+
+func NewService(args ServiceArgs) Service {
+	err := shipping.Validate.Struct(args)
+	if err != nil {
+		log.Fatal("failed to create carrier service, err:", err)
+	}
+
+	return &service{
+		rates: args.Rates,
+	}
+}
+
+type ServiceArgs struct {
+	Rates shipping.RateRepository `validate:"required"`
+}
+
+func (s *service) EstimateCost(ctx context.Context, carrier string, packs []shipping.PackConfig) (shipping.CostEstimate, error) {
+	if len(packs) == 0 {
+		return shipping.CostEstimate{}, ErrNoPacks
+	}
+	table, err := s.rates.GetByCarrier(ctx, carrier)
+	if err != nil {
+		if errors.Is(err, shipping.ErrNotFound) {
+			slog.WarnContext(ctx, "no rate table found for carrier", "carrier", carrier)
+			return shipping.CostEstimate{}, err
+		}
+		slog.ErrorContext(ctx, "failed to get rate table", "carrier", carrier, "err", err)
+		return shipping.CostEstimate{}, shipping.InternalServerErr
+	}
+	return Estimate(table, packs)
+}
+
+// Estimate prices the packs using the rate table.
+func Estimate(table shipping.RateTable, packs []shipping.PackConfig) (shipping.CostEstimate, error) {
+	estimate := shipping.CostEstimate{
+		Carrier:  table.Carrier,
+		Currency: table.Currency,
+		Packs:    make([]shipping.PackCost, 0, len(packs)),
+	}
+	for _, p := range packs {
+		unitCost, err := PackCost(table, p.Size)
+		if err != nil {
+			return shipping.CostEstimate{}, err
+		}
+		if p.Count > 0 && unitCost > 0 && unitCost > (math.MaxInt64-estimate.Total)/p.Count {
+			return shipping.CostEstimate{}, &shipping.RangeError{Field: "shipping cost", Max: math.MaxInt64}
+		}
+		cost := unitCost * p.Count
+		estimate.Packs = append(estimate.Packs, shipping.PackCost{
+			Count:    p.Count,
+			Size:     p.Size,
+			UnitCost: unitCost,
+			Cost:     cost,
+		})
+		estimate.Total += cost
+	}
+	return estimate, nil
+}
+
+// PackCost returns the price of packing and shipping a single pack of the given size with the carrier.
+// The billable weight is the greater of the actual and the dimensional weight.
+func PackCost(table shipping.RateTable, size uint64) (int64, error) {
+	var parcel *shipping.Parcel
+	for i := range table.Parcels {
+		if table.Parcels[i].PackSize == size {
+			parcel = &table.Parcels[i]
+			break
+		}
+	}
+	if parcel == nil {
+		return 0, ErrUnknownParcel
+	}
+	weight := parcel.Weight
+	if table.DimDivisor > 0 {
+		dimWeight := parcel.Length * parcel.Width * parcel.Height / table.DimDivisor
+		if dimWeight > weight {
+			weight = dimWeight
+		}
+	}
+	bands := make([]shipping.WeightBand, len(table.WeightBands))
+	copy(bands, table.WeightBands)
+	// sort bands by weight asc, so the cheapest matching band is picked
+	sort.Slice(bands, func(i, j int) bool {
+		return bands[i].MaxWeight < bands[j].MaxWeight
+	})
+	for _, band := range bands {
+		if weight <= band.MaxWeight {
+			return band.Price + table.PerParcelFee + parcel.PackagingCost, nil
+		}
+	}
+	return 0, ErrOverweight
+}
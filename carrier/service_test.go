@@ -0,0 +1,107 @@
+package carrier_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/carrier"
+	"github.com/silvan-talos/shipping/mock"
+)
+
+func TestService_EstimateCost(t *testing.T) {
+	tests := map[string]struct {
+		packs       []shipping.PackConfig
+		rates       shipping.RateRepository
+		expectedRes shipping.CostEstimate
+		expectedErr error
+	}{
+		"singlePack": {
+			packs: []shipping.PackConfig{{Count: 1, Size: 250}},
+			rates: &mock.RateRepository{},
+			expectedRes: shipping.CostEstimate{
+				Carrier:  "express",
+				Currency: "EUR",
+				Packs: []shipping.PackCost{
+					{Count: 1, Size: 250, UnitCost: 450, Cost: 450},
+				},
+				Total: 450,
+			},
+		},
+		"dimensionalWeightApplied": {
+			packs: []shipping.PackConfig{{Count: 2, Size: 5000}, {Count: 1, Size: 2000}, {Count: 1, Size: 250}},
+			rates: &mock.RateRepository{},
+			expectedRes: shipping.CostEstimate{
+				Carrier:  "express",
+				Currency: "EUR",
+				Packs: []shipping.PackCost{
+					{Count: 2, Size: 5000, UnitCost: 1250, Cost: 2500},
+					{Count: 1, Size: 2000, UnitCost: 1250, Cost: 1250},
+					{Count: 1, Size: 250, UnitCost: 450, Cost: 450},
+				},
+				Total: 4200,
+			},
+		},
+		"noPacks_invalidRequest": {
+			packs:       []shipping.PackConfig{},
+			rates:       &mock.RateRepository{},
+			expectedErr: carrier.ErrNoPacks,
+		},
+		"unknownPackSize_returnErrUnknownParcel": {
+			packs:       []shipping.PackConfig{{Count: 1, Size: 300}},
+			rates:       &mock.RateRepository{},
+			expectedErr: carrier.ErrUnknownParcel,
+		},
+		"parcelTooHeavy_returnErrOverweight": {
+			packs: []shipping.PackConfig{{Count: 1, Size: 250}},
+			rates: &mock.RateRepository{
+				GetByCarrierFn: func(ctx context.Context, carrier string) (shipping.RateTable, error) {
+					return shipping.RateTable{
+						WeightBands: []shipping.WeightBand{{MaxWeight: 0.5, Price: 100}},
+						Parcels:     []shipping.Parcel{{PackSize: 250, Weight: 1}},
+					}, nil
+				},
+			},
+			expectedErr: carrier.ErrOverweight,
+		},
+		"carrierNotFound_returnErrNotFound": {
+			packs: []shipping.PackConfig{{Count: 1, Size: 250}},
+			rates: &mock.RateRepository{
+				GetByCarrierFn: func(ctx context.Context, carrier string) (shipping.RateTable, error) {
+					return shipping.RateTable{}, shipping.ErrNotFound
+				},
+			},
+			expectedErr: shipping.ErrNotFound,
+		},
+		"costOverflows_returnRangeError": {
+			packs:       []shipping.PackConfig{{Count: math.MaxInt64 / 100, Size: 250}},
+			rates:       &mock.RateRepository{},
+			expectedErr: &shipping.RangeError{Field: "shipping cost", Max: math.MaxInt64},
+		},
+		"failedToGetRates_internalError": {
+			packs: []shipping.PackConfig{{Count: 1, Size: 250}},
+			rates: &mock.RateRepository{
+				GetByCarrierFn: func(ctx context.Context, carrier string) (shipping.RateTable, error) {
+					return shipping.RateTable{}, errors.New("failed to get rates")
+				},
+			},
+			expectedErr: shipping.InternalServerErr,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := carrier.NewService(carrier.ServiceArgs{
+				Rates: tc.rates,
+			})
+			res, err := s.EstimateCost(context.Background(), "express", tc.packs)
+			require.Equal(t, tc.expectedErr, err, "errors must match")
+			if err == nil {
+				require.Equal(t, tc.expectedRes, res, "results must match")
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Package client is the Go client of the shipping REST API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/silvan-talos/shipping"
+)
+
+const (
+	defaultTimeout  = 10 * time.Second
+	defaultBackoff  = 100 * time.Millisecond
+	tenantHeader    = "X-Tenant-ID"
+	apiKeyHeader    = "X-API-Key"
+	requestIDHeader = "X-Request-ID"
+
+	idempotencyKeyHeader = "Idempotency-Key"
+)
+
+type Client struct {
+	baseURL    string
+	tenant     string
+	apiKey     string
+	token      string
+	httpClient *http.Client
+	timeout    time.Duration
+	retries    int
+	backoff    time.Duration
+}
+
+type ClientArgs struct {
+	BaseURL string `validate:"required,url"`
+	Tenant  string `validate:"required"`
+	// APIKey or Token authenticate the requests when the server requires it, Token is sent as a bearer token
+	APIKey string
+	Token  string
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+	// Timeout limits every attempt of a request, defaults to 10s
+	Timeout time.Duration `validate:"gte=0"`
+	// Retries is the number of times a request is retried after a network error or a 5xx/429 response
+	Retries int `validate:"gte=0"`
+	// Backoff is the delay before the first retry, doubled on every following one. Defaults to 100ms
+	Backoff time.Duration `validate:"gte=0"`
+}
+
+func NewClient(args ClientArgs) (*Client, error) {
+	err := shipping.Validate.Struct(args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client args: %w", err)
+	}
+	c := &Client{
+		baseURL:    strings.TrimSuffix(args.BaseURL, "/"),
+		tenant:     args.Tenant,
+		apiKey:     args.APIKey,
+		token:      args.Token,
+		httpClient: args.HTTPClient,
+		timeout:    args.Timeout,
+		retries:    args.Retries,
+		backoff:    args.Backoff,
+	}
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
+	}
+	if c.timeout == 0 {
+		c.timeout = defaultTimeout
+	}
+	if c.backoff == 0 {
+		c.backoff = defaultBackoff
+	}
+	return c, nil
+}
+
+// do sends the request, retrying it while the failure is temporary, and decodes the response body into resp
+// when resp is not nil. Every attempt of a PUT or POST request carries the same idempotency key, so the server
+// processes it only once.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, resp any) (http.Header, error) {
+	var idempotencyKey string
+	if method == http.MethodPut || method == http.MethodPost {
+		key := make([]byte, 16)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("generate idempotency key: %w", err)
+		}
+		idempotencyKey = hex.EncodeToString(key)
+	}
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("encode request: %w", err)
+		}
+	}
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		header, err := c.attempt(ctx, method, u, idempotencyKey, payload, resp)
+		if err == nil || attempt >= c.retries || !temporary(err) || ctx.Err() != nil {
+			return header, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method, u, idempotencyKey string, payload []byte, resp any) (http.Header, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set(tenantHeader, c.tenant)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.apiKey != "" {
+		req.Header.Set(apiKeyHeader, c.apiKey)
+	}
+	req.Header.Set("Accept", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set(idempotencyKeyHeader, idempotencyKey)
+	}
+	if id, ok := shipping.RequestIDFromContext(ctx); ok {
+		req.Header.Set(requestIDHeader, id)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	// continue the trace of ctx on the server
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return nil, newError(res)
+	}
+	if resp == nil {
+		return res.Header, nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return res.Header, nil
+}
+
+// temporary tells whether a failed attempt is worth retrying
+func temporary(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	// transport errors, including the timeout of the attempt
+	return true
+}
@@ -0,0 +1,334 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/audit"
+	"github.com/silvan-talos/shipping/carrier"
+	"github.com/silvan-talos/shipping/client"
+	shippinghttp "github.com/silvan-talos/shipping/http"
+	"github.com/silvan-talos/shipping/inmem"
+	"github.com/silvan-talos/shipping/inventory"
+	"github.com/silvan-talos/shipping/label"
+	"github.com/silvan-talos/shipping/mock"
+	"github.com/silvan-talos/shipping/product"
+	"github.com/silvan-talos/shipping/shipment"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(newShippingServer(inmem.NewPackRepository(shipping.DefaultPackSizes), 0))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func newShippingServer(packs shipping.PackRepository, shutdownDelay time.Duration) *shippinghttp.Server {
+	productService := product.NewService(product.ServiceArgs{
+		Packs: packs,
+	})
+	inventoryRepository := inmem.NewInventoryRepository()
+	return shippinghttp.NewServer(shippinghttp.ServerArgs{
+		ProductService: productService,
+		CarrierService: carrier.NewService(carrier.ServiceArgs{
+			Rates: &mock.RateRepository{},
+		}),
+		LabelService: label.NewService(),
+		ShipmentService: shipment.NewService(shipment.ServiceArgs{
+			Shipments: inmem.NewShipmentRepository(),
+			Inventory: inventoryRepository,
+			Products:  productService,
+		}),
+		InventoryService: inventory.NewService(inventory.ServiceArgs{
+			Inventory: inventoryRepository,
+		}),
+		AuditService: audit.NewService(audit.ServiceArgs{
+			Entries: inmem.NewAuditRepository(),
+		}),
+		Idempotency: &shippinghttp.IdempotencyArgs{
+			Store: inmem.NewIdempotencyStore(100),
+		},
+		ShutdownDelay: shutdownDelay,
+	})
+}
+
+func newClient(t *testing.T, baseURL string, retries int) *client.Client {
+	c, err := client.NewClient(client.ClientArgs{
+		BaseURL: baseURL,
+		Tenant:  "tenant-1",
+		Timeout: time.Second,
+		Retries: retries,
+		Backoff: time.Millisecond,
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func TestClient_Packaging(t *testing.T) {
+	ts := newServer(t)
+	c := newClient(t, ts.URL, 0)
+	ctx := context.Background()
+
+	packs, err := c.CalculatePacks(ctx, 1, 12001)
+	require.NoError(t, err)
+	require.Equal(t, []shipping.PackConfig{
+		{Count: 2, Size: 5000},
+		{Count: 1, Size: 2000},
+		{Count: 1, Size: 250},
+	}, packs)
+
+	require.NoError(t, c.UpdatePacks(ctx, 1, []uint64{100, 300}))
+	require.NoError(t, c.UpdateWarehousePacks(ctx, "w1", 1, []uint64{1000}))
+	sizes, source, err := c.GetPacks(ctx, 1, "")
+	require.NoError(t, err)
+	require.Equal(t, shipping.LevelProduct, source)
+	require.Equal(t, []uint64{100, 300}, sizes)
+	packing, err := c.CalculatePacking(ctx, 1, 350, "w1")
+	require.NoError(t, err)
+	require.Equal(t, shipping.LevelWarehouse, packing.Source)
+	require.Equal(t, []shipping.PackConfig{{Count: 1, Size: 1000}}, packing.Packs)
+	require.Equal(t, int64(650), packing.Overhead)
+
+	sim, err := c.SimulatePacks(ctx, 1, "", []uint64{600}, []uint64{300, 600}, "")
+	require.NoError(t, err)
+	require.Equal(t, 2, sim.Orders)
+	require.Equal(t, int64(-1), sim.Delta.Packs)
+
+	require.NoError(t, c.UpdateDefaultPacks(ctx, []uint64{10}))
+	defaults, err := c.GetDefaultPacks(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{10}, defaults)
+
+	err = c.UpdatePacks(ctx, 1, []uint64{})
+	require.ErrorIs(t, err, client.ErrBadRequest)
+	var apiErr *client.Error
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	require.Equal(t, product.ErrInvalidConfig.Error(), apiErr.Message)
+}
+
+func TestClient_Errors(t *testing.T) {
+	tests := map[string]struct {
+		status      int
+		retries     int
+		failures    int32
+		expectedErr error
+		calls       int32
+	}{
+		"notFound_noRetry": {
+			status:      http.StatusNotFound,
+			retries:     3,
+			failures:    10,
+			expectedErr: shipping.ErrNotFound,
+			calls:       1,
+		},
+		"unauthorized_returnMissingTenant": {
+			status:      http.StatusUnauthorized,
+			failures:    10,
+			expectedErr: shipping.ErrMissingTenant,
+			calls:       1,
+		},
+		"forbidden_returnErrForbidden": {
+			status:      http.StatusForbidden,
+			failures:    10,
+			expectedErr: shipping.ErrForbidden,
+			calls:       1,
+		},
+		"serverError_retriesExhausted": {
+			status:      http.StatusInternalServerError,
+			retries:     2,
+			failures:    10,
+			expectedErr: shipping.InternalServerErr,
+			calls:       3,
+		},
+		"serverError_recoversOnRetry": {
+			status:   http.StatusServiceUnavailable,
+			retries:  2,
+			failures: 1,
+			calls:    2,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tc.failures {
+					w.WriteHeader(tc.status)
+					w.Write([]byte(`{"error":"failed"}`))
+					return
+				}
+				w.Write([]byte(`{"source":"default","packs":[{"number_of_packs":1,"pack_size":250}],"overhead":249}`))
+			}))
+			defer ts.Close()
+			c := newClient(t, ts.URL, tc.retries)
+			_, err := c.CalculatePacks(context.Background(), 1, 1)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.calls, calls.Load())
+		})
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(release)
+	c, err := client.NewClient(client.ClientArgs{
+		BaseURL: ts.URL,
+		Tenant:  "tenant-1",
+		Timeout: 20 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	_, err = c.CalculatePacks(context.Background(), 1, 1)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClient_IdempotencyKey(t *testing.T) {
+	var keys []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	c := newClient(t, ts.URL, 1)
+	require.NoError(t, c.UpdatePacks(context.Background(), 1, []uint64{250}))
+	require.NoError(t, c.UpdatePacks(context.Background(), 1, []uint64{250}))
+	require.Len(t, keys, 3)
+	require.NotEmpty(t, keys[0])
+	// retries of a request reuse its key, other requests get a new one
+	require.Equal(t, keys[0], keys[1])
+	require.NotEqual(t, keys[1], keys[2])
+}
+
+func TestServer_RequestID(t *testing.T) {
+	ts := newServer(t)
+	get := func(id string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/ping", nil)
+		require.NoError(t, err)
+		if id != "" {
+			req.Header.Set("X-Request-ID", id)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		return res
+	}
+
+	res := get("order-42")
+	require.Equal(t, "order-42", res.Header.Get("X-Request-ID"))
+
+	res = get("")
+	generated := res.Header.Get("X-Request-ID")
+	require.Len(t, generated, 32)
+	res = get("")
+	require.NotEqual(t, generated, res.Header.Get("X-Request-ID"))
+
+	// IDs that cannot be logged as is are replaced
+	res = get(strings.Repeat("a", 129))
+	require.Len(t, res.Header.Get("X-Request-ID"), 32)
+}
+
+// serve starts server on a local port, returning its URL and the error Serve returns
+func serve(t *testing.T, server *shippinghttp.Server) (string, <-chan error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(lis)
+	}()
+	return "http://" + lis.Addr().String(), served
+}
+
+// blockingPacks blocks the reads of product configurations until release is closed
+func blockingPacks(started chan<- struct{}, release <-chan struct{}) *mock.PackRepository {
+	return &mock.PackRepository{
+		GetByProductIDFn: func(context.Context, uint64) ([]uint64, error) {
+			close(started)
+			<-release
+			return []uint64{250}, nil
+		},
+	}
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	server := newShippingServer(blockingPacks(started, release), 200*time.Millisecond)
+	baseURL, served := serve(t, server)
+	ready := func() int {
+		res, err := http.Get(baseURL + "/ready")
+		require.NoError(t, err)
+		res.Body.Close()
+		return res.StatusCode
+	}
+	require.Equal(t, http.StatusOK, ready())
+
+	c := newClient(t, baseURL, 0)
+	requested := make(chan error, 1)
+	go func() {
+		_, err := c.CalculatePacks(context.Background(), 1, 250)
+		requested <- err
+	}()
+	<-started
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- server.Shutdown(context.Background())
+	}()
+
+	// readiness fails during the shutdown delay, while requests are still accepted
+	require.Eventually(t, func() bool {
+		return ready() == http.StatusServiceUnavailable
+	}, 150*time.Millisecond, 5*time.Millisecond)
+
+	// the request in flight completes before the server stops
+	close(release)
+	require.NoError(t, <-requested)
+	require.NoError(t, <-shutdown)
+	require.NoError(t, <-served)
+	_, err := http.Get(baseURL + "/ping")
+	require.Error(t, err)
+}
+
+func TestServer_ShutdownTimeout(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	server := newShippingServer(blockingPacks(started, release), 0)
+	baseURL, served := serve(t, server)
+
+	c := newClient(t, baseURL, 0)
+	requested := make(chan error, 1)
+	go func() {
+		_, err := c.CalculatePacks(context.Background(), 1, 250)
+		requested <- err
+	}()
+	<-started
+
+	// the requests still in flight when the timeout expires are cut off
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := server.Shutdown(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Error(t, <-requested)
+	require.NoError(t, <-served)
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/silvan-talos/shipping"
+)
+
+var (
+	ErrBadRequest  = errors.New("bad request")
+	ErrConflict    = errors.New("conflict")
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// Error is returned for every response with an error status. It matches the domain errors of the
+// shipping package through errors.Is, e.g. errors.Is(err, shipping.ErrNotFound) for a 404.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func newError(res *http.Response) *Error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if json.Unmarshal(b, &body) != nil || body.Error == "" {
+		body.Error = http.StatusText(res.StatusCode)
+	}
+	return &Error{
+		StatusCode: res.StatusCode,
+		Message:    body.Error,
+	}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("shipping api: %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return shipping.ErrNotFound
+	case e.StatusCode == http.StatusUnauthorized:
+		return shipping.ErrMissingTenant
+	case e.StatusCode == http.StatusForbidden:
+		return shipping.ErrForbidden
+	case e.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode >= http.StatusInternalServerError:
+		return shipping.InternalServerErr
+	default:
+		return ErrBadRequest
+	}
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// CalculatePacks calculates the packs for the ordered quantity of the product.
+func (c *Client) CalculatePacks(ctx context.Context, productID, qty uint64) ([]shipping.PackConfig, error) {
+	packing, err := c.CalculatePacking(ctx, productID, qty, "")
+	if err != nil {
+		return nil, err
+	}
+	return packing.Packs, nil
+}
+
+// CalculatePacking calculates the packs for the ordered quantity of the product, using the sizes configured
+// for the warehouse when one is given. The returned packing has no pack sizes, the API does not report them.
+func (c *Client) CalculatePacking(ctx context.Context, productID, qty uint64, warehouse string) (shipping.Packing, error) {
+	query := url.Values{}
+	query.Set("qty", strconv.FormatUint(qty, 10))
+	if warehouse != "" {
+		query.Set("warehouse", warehouse)
+	}
+	var packing shipping.Packing
+	_, err := c.do(ctx, http.MethodGet, productPath(productID), query, nil, &packing)
+	if err != nil {
+		return shipping.Packing{}, err
+	}
+	return packing, nil
+}
+
+// GetPacks returns the pack sizes used for the product, or for the product in the warehouse when one is given,
+// and the level of the configuration they come from.
+func (c *Client) GetPacks(ctx context.Context, productID uint64, warehouse string) ([]uint64, shipping.ConfigLevel, error) {
+	query := url.Values{}
+	if warehouse != "" {
+		query.Set("warehouse", warehouse)
+	}
+	var resp struct {
+		Source    shipping.ConfigLevel `json:"source"`
+		PackSizes []uint64             `json:"pack_sizes"`
+	}
+	_, err := c.do(ctx, http.MethodGet, productPath(productID)+"/config", query, nil, &resp)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.PackSizes, resp.Source, nil
+}
+
+// SimulatePacks compares the packing of the historical order quantities with the pack sizes currently used for
+// the product and with the candidate ones. The packings are priced when a carrier is given.
+func (c *Client) SimulatePacks(ctx context.Context, productID uint64, warehouse string, candidate, quantities []uint64, carrier string) (shipping.Simulation, error) {
+	req := struct {
+		CandidateSizes []uint64 `json:"candidate_sizes"`
+		Quantities     []uint64 `json:"quantities"`
+		Warehouse      string   `json:"warehouse,omitempty"`
+		Carrier        string   `json:"carrier,omitempty"`
+	}{
+		CandidateSizes: candidate,
+		Quantities:     quantities,
+		Warehouse:      warehouse,
+		Carrier:        carrier,
+	}
+	var resp shipping.Simulation
+	// simulating has no side effects, so it is safe to retry even though it is sent as a POST
+	_, err := c.do(ctx, http.MethodPost, productPath(productID)+"/simulate", nil, req, &resp)
+	if err != nil {
+		return shipping.Simulation{}, err
+	}
+	return resp, nil
+}
+
+// UpdatePacks updates the pack sizes of the product.
+func (c *Client) UpdatePacks(ctx context.Context, productID uint64, packSizes []uint64) error {
+	_, err := c.do(ctx, http.MethodPut, productPath(productID), nil, packSizes, nil)
+	return err
+}
+
+// UpdateWarehousePacks updates the pack sizes of the product in the warehouse.
+func (c *Client) UpdateWarehousePacks(ctx context.Context, warehouse string, productID uint64, packSizes []uint64) error {
+	query := url.Values{}
+	query.Set("warehouse", warehouse)
+	_, err := c.do(ctx, http.MethodPut, productPath(productID), query, packSizes, nil)
+	return err
+}
+
+// GetDefaultPacks returns the pack sizes used for products without configuration.
+func (c *Client) GetDefaultPacks(ctx context.Context) ([]uint64, error) {
+	var packSizes []uint64
+	_, err := c.do(ctx, http.MethodGet, "/v1/packaging/default", nil, nil, &packSizes)
+	if err != nil {
+		return nil, err
+	}
+	return packSizes, nil
+}
+
+// UpdateDefaultPacks updates the pack sizes used for products without configuration.
+func (c *Client) UpdateDefaultPacks(ctx context.Context, packSizes []uint64) error {
+	_, err := c.do(ctx, http.MethodPut, "/v1/packaging/default", nil, packSizes, nil)
+	return err
+}
+
+func productPath(productID uint64) string {
+	return fmt.Sprintf("/v1/products/%d/packaging", productID)
+}
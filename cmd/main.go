@@ -1,40 +1,336 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"net"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/audit"
+	"github.com/silvan-talos/shipping/auth"
+	"github.com/silvan-talos/shipping/cache"
+	"github.com/silvan-talos/shipping/carrier"
+	"github.com/silvan-talos/shipping/config"
+	"github.com/silvan-talos/shipping/file"
+	"github.com/silvan-talos/shipping/grpc"
 	"github.com/silvan-talos/shipping/http"
 	"github.com/silvan-talos/shipping/inmem"
+	"github.com/silvan-talos/shipping/inventory"
+	"github.com/silvan-talos/shipping/label"
+	"github.com/silvan-talos/shipping/logging"
+	"github.com/silvan-talos/shipping/metrics"
 	"github.com/silvan-talos/shipping/product"
+	"github.com/silvan-talos/shipping/shipment"
+	"github.com/silvan-talos/shipping/tracing"
 )
 
 func main() {
-	lis, err := net.Listen("tcp", ":8080")
+	cfg, err := config.Load(os.Args[1:], os.Getenv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger, err := logging.New(os.Stderr, cfg.Log)
+	if err != nil {
+		log.Fatal("failed to configure logging, error:", err)
+	}
+	// the standard logger writes through it as well
+	slog.SetDefault(logger)
+	lis, err := net.Listen("tcp", cfg.HTTP.Addr)
 	if err != nil {
 		log.Fatal("failed to create listener, error:", err)
 	}
-	productService := product.NewService(product.ServiceArgs{
-		Packs: inmem.NewPackRepository(),
+	rates, err := file.NewRateRepository(cfg.RatesDir)
+	if err != nil {
+		log.Fatal("failed to load carrier rates, error:", err)
+	}
+	auditEntries := newAuditRepository(cfg.Audit.File)
+	auditService := newAuditService(auditEntries, cfg.Audit.Stdout)
+	var policy shipping.Policy
+	if cfg.PolicyFile != "" {
+		policy, err = file.NewPolicy(cfg.PolicyFile)
+		if err != nil {
+			log.Fatal("failed to load policy, error:", err)
+		}
+	}
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	tracerProvider := newTracerProvider()
+	var registry *prometheus.Registry
+	if cfg.Features.Metrics {
+		registry = prometheus.NewRegistry()
+		registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
+	packs := instrumentPacks(newPackRepository(cfg.Repository, cfg.Packs.Default), cfg.Repository.Backend, registry)
+	if cfg.Packs.CacheEntries > 0 {
+		packCache := cache.NewPackRepository(cache.PackRepositoryArgs{
+			Next:       packs,
+			TTL:        time.Duration(cfg.Packs.CacheTTL),
+			MaxEntries: cfg.Packs.CacheEntries,
+		})
+		if registry != nil {
+			err = metrics.RegisterCacheStats(registry, "packs", packCache.Stats)
+			if err != nil {
+				log.Fatal("failed to register cache metrics, error:", err)
+			}
+		}
+		packs = instrumentPacks(packCache, "cache", registry)
+	}
+	var productService product.Service = tracing.NewProductService(tracing.ProductServiceArgs{
+		Next: product.NewService(product.ServiceArgs{
+			Packs:      packs,
+			Rates:      rates,
+			TableBound: cfg.Packs.TableBound,
+			Policy:     policy,
+			Audit:      auditService,
+		}),
+	})
+	if registry != nil {
+		productService = metrics.NewProductService(metrics.ProductServiceArgs{
+			Next:       productService,
+			Registerer: registry,
+		})
+	}
+	carrierService := carrier.NewService(carrier.ServiceArgs{
+		Rates: rates,
+	})
+	inventoryRepository := inmem.NewInventoryRepository()
+	shipmentService := shipment.NewService(shipment.ServiceArgs{
+		Shipments: inmem.NewShipmentRepository(),
+		Inventory: inventoryRepository,
+		Products:  productService,
+	})
+	inventoryService := inventory.NewService(inventory.ServiceArgs{
+		Inventory: inventoryRepository,
 	})
+	authenticator := newAuthenticator(cfg.Auth)
+	var idempotency *http.IdempotencyArgs
+	var grpcIdempotency *grpc.IdempotencyArgs
+	if cfg.Features.Idempotency {
+		// the keys of both APIs are scoped apart, they share the bound on the entries
+		store := inmem.NewIdempotencyStore(cfg.IdempotencyEntries)
+		idempotency = &http.IdempotencyArgs{
+			Store: store,
+		}
+		grpcIdempotency = &grpc.IdempotencyArgs{
+			Store: store,
+		}
+	}
 	server := http.NewServer(http.ServerArgs{
-		ProductService: productService,
+		ProductService:   productService,
+		CarrierService:   carrierService,
+		LabelService:     label.NewService(),
+		ShipmentService:  shipmentService,
+		InventoryService: inventoryService,
+		AuditService:     auditService,
+		Authenticator:    authenticator,
+		RateLimits:       loadRateLimits(cfg.RateLimitsFile),
+		Idempotency:      idempotency,
+		Metrics:          registry,
+		Mode:             cfg.HTTP.Mode,
+		ReadTimeout:      time.Duration(cfg.HTTP.ReadTimeout),
+		WriteTimeout:     time.Duration(cfg.HTTP.WriteTimeout),
+		IdleTimeout:      time.Duration(cfg.HTTP.IdleTimeout),
+		TrustedProxies:   cfg.HTTP.TrustedProxies,
+		ShutdownDelay:    time.Duration(cfg.HTTP.ShutdownDelay),
 	})
-	errs := make(chan error, 2)
+	errs := make(chan error, 3)
 	go func() {
 		quit := make(chan os.Signal, 2)
 		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 		errs <- fmt.Errorf("signal: %s", <-quit)
 	}()
 	go func() {
-		err = server.Serve(lis)
-		log.Println("HTTP server stopped, err:", err)
-		errs <- fmt.Errorf("err: %w", err)
+		// Serve returns nil once shut down
+		if err := server.Serve(lis); err != nil {
+			slog.Error("http server stopped", "err", err)
+			errs <- fmt.Errorf("err: %w", err)
+		}
 	}()
+	var grpcServer *grpc.Server
+	if cfg.Features.GRPC {
+		grpcLis, err := net.Listen("tcp", cfg.GRPC.Addr)
+		if err != nil {
+			log.Fatal("failed to create grpc listener, error:", err)
+		}
+		grpcServer = grpc.NewServer(grpc.ServerArgs{
+			ProductService: productService,
+			Authenticator:  authenticator,
+			Idempotency:    grpcIdempotency,
+		})
+		go func() {
+			if err := grpcServer.Serve(grpcLis); err != nil {
+				slog.Error("grpc server stopped", "err", err)
+				errs <- fmt.Errorf("err: %w", err)
+			}
+		}()
+	}
+
+	slog.Info("shutting down", "reason", <-errs)
+	// the delay is spent failing readiness, the timeout bounds the draining that follows
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.HTTP.ShutdownDelay+cfg.HTTP.ShutdownTimeout))
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Error("failed to shut down http server gracefully", "err", err)
+		}
+	}()
+	// gRPC clients are not routed by the health checks, its calls drain right away
+	if grpcServer != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := grpcServer.Shutdown(ctx); err != nil {
+				slog.Error("failed to shut down grpc server gracefully", "err", err)
+			}
+		}()
+	}
+	wg.Wait()
+	// the servers are stopped, nothing writes to the repositories anymore
+	if closer, ok := auditEntries.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			slog.Error("failed to close audit log", "err", err)
+		}
+	}
+	slog.Info("exiting")
+	if tracerProvider != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := tracerProvider.Shutdown(ctx); err != nil {
+			slog.Error("failed to flush spans", "err", err)
+		}
+	}
+}
+
+// newPackRepository creates the repository of the pack configurations on the configured backend
+func newPackRepository(cfg config.Repository, defaultConfig []uint64) shipping.PackRepository {
+	switch cfg.Backend {
+	case config.BackendFile:
+		packs, err := file.NewPackRepository(cfg.DSN, defaultConfig)
+		if err != nil {
+			log.Fatal("failed to open pack configurations, error:", err)
+		}
+		return packs
+	default:
+		return inmem.NewPackRepository(defaultConfig)
+	}
+}
+
+// instrumentPacks records the spans of the calls to the pack repository, and their metrics when registry is not nil
+func instrumentPacks(packs shipping.PackRepository, name string, registry prometheus.Registerer) shipping.PackRepository {
+	packs = tracing.NewPackRepository(tracing.PackRepositoryArgs{
+		Next: packs,
+		Name: name,
+	})
+	if registry == nil {
+		return packs
+	}
+	return metrics.NewPackRepository(metrics.PackRepositoryArgs{
+		Next:       packs,
+		Registerer: registry,
+		Name:       name,
+	})
+}
+
+// newTracerProvider exports the spans with OTLP when OTEL_EXPORTER_OTLP_ENDPOINT or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set, unless OTEL_SDK_DISABLED is true. It returns nil when
+// tracing is disabled, the trace context of the callers is still propagated then.
+func newTracerProvider() *sdktrace.TracerProvider {
+	if os.Getenv("OTEL_SDK_DISABLED") == "true" ||
+		(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "") {
+		slog.Info("tracing disabled, set OTEL_EXPORTER_OTLP_ENDPOINT to enable it")
+		return nil
+	}
+	tp, err := tracing.NewTracerProvider(context.Background(), "shipping")
+	if err != nil {
+		log.Fatal("failed to create tracer provider, error:", err)
+	}
+	otel.SetTracerProvider(tp)
+	return tp
+}
 
-	log.Println("exiting,", <-errs)
+// newAuthenticator creates the authenticator of the API, returning nil when neither API keys nor
+// a JWKS file are configured, which the config only allows with insecure_no_auth
+func newAuthenticator(cfg config.Auth) *auth.Authenticator {
+	if cfg.APIKeysFile == "" && cfg.JWKSFile == "" {
+		slog.Warn("authentication disabled by insecure_no_auth, every request is allowed")
+		return nil
+	}
+	a, err := auth.NewAuthenticator(auth.AuthenticatorArgs{
+		APIKeysFile: cfg.APIKeysFile,
+		JWKSFile:    cfg.JWKSFile,
+		Issuer:      cfg.JWTIssuer,
+		Audience:    cfg.JWTAudience,
+		TenantClaim: cfg.JWTTenantClaim,
+	})
+	if err != nil {
+		log.Fatal("failed to create authenticator, error:", err)
+	}
+	return a
+}
+
+// newAuditRepository keeps the audit entries in the file at path, or in memory when not set
+func newAuditRepository(path string) shipping.AuditRepository {
+	if path == "" {
+		return inmem.NewAuditRepository()
+	}
+	entries, err := file.NewAuditRepository(path)
+	if err != nil {
+		log.Fatal("failed to open audit log, error:", err)
+	}
+	return entries
+}
+
+// newAuditService records the audit entries, also writing them to stdout as JSON when enabled
+func newAuditService(entries shipping.AuditRepository, stdout bool) audit.Service {
+	var sinks []shipping.AuditSink
+	if stdout {
+		sinks = append(sinks, audit.NewJSONSink(os.Stdout))
+	}
+	return audit.NewService(audit.ServiceArgs{
+		Entries: entries,
+		Sinks:   sinks,
+	})
+}
+
+// loadRateLimits reads the limits of the file at path, returning nil when not set:
+//
+//	{"default": {"rate": 50, "burst": 100}, "routes": {"PUT /v1/products/:id/packaging": {"rate": 1, "burst": 5, "daily_quota": 1000}}}
+func loadRateLimits(path string) *http.RateLimitArgs {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal("failed to read rate limits, error:", err)
+	}
+	limits := &http.RateLimitArgs{
+		Store: inmem.NewRateLimitStore(),
+	}
+	err = json.Unmarshal(data, limits)
+	if err != nil {
+		log.Fatal("failed to decode rate limits, error:", err)
+	}
+	return limits
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"strconv"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/product"
+)
+
+type calcResult struct {
+	Quantity uint64                `json:"quantity"`
+	Packs    []shipping.PackConfig `json:"packs"`
+	Overhead int64                 `json:"overhead"`
+}
+
+func runCalc(args []string) error {
+	fs := flag.NewFlagSet("calc", flag.ExitOnError)
+	sizesFlag := fs.String("sizes", "", "comma separated list of pack sizes")
+	qty := fs.Uint64("qty", 0, "ordered quantity")
+	out := formatTable
+	fs.Var(&out, "o", "output format: table, json or csv")
+	fs.Parse(args)
+
+	sizes, err := parseSizes(*sizesFlag)
+	if err != nil {
+		return err
+	}
+	if len(sizes) == 0 {
+		return errors.New("--sizes is required")
+	}
+	if *qty == 0 {
+		return errors.New("--qty must be positive")
+	}
+	packs, overhead, err := product.CalculatePacks(*qty, sizes)
+	if err != nil {
+		return err
+	}
+	res := result{
+		v: calcResult{
+			Quantity: *qty,
+			Packs:    packs,
+			Overhead: overhead,
+		},
+		headers: []string{"count", "size"},
+		footer:  "overhead: " + strconv.FormatInt(overhead, 10),
+	}
+	for _, p := range packs {
+		res.rows = append(res.rows, []string{strconv.FormatInt(p.Count, 10), strconv.FormatUint(p.Size, 10)})
+	}
+	return res.write(os.Stdout, out)
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/client"
+)
+
+// configEntry is the format of exported configurations, which can be imported back
+type configEntry struct {
+	ProductID uint64   `json:"product_id"`
+	Warehouse string   `json:"warehouse,omitempty"`
+	PackSizes []uint64 `json:"pack_sizes"`
+}
+
+var configHeaders = []string{"product_id", "warehouse", "pack_sizes"}
+
+type remoteFlags struct {
+	server  *string
+	tenant  *string
+	apiKey  *string
+	timeout *time.Duration
+}
+
+func addRemoteFlags(fs *flag.FlagSet) remoteFlags {
+	server := os.Getenv("SHIPCTL_SERVER")
+	if server == "" {
+		server = "http://localhost:8080"
+	}
+	return remoteFlags{
+		server:  fs.String("server", server, "address of the shipping server, defaults to $SHIPCTL_SERVER"),
+		tenant:  fs.String("tenant", os.Getenv("SHIPCTL_TENANT"), "tenant to act as, defaults to $SHIPCTL_TENANT"),
+		apiKey:  fs.String("api-key", os.Getenv("SHIPCTL_API_KEY"), "API key to authenticate with, defaults to $SHIPCTL_API_KEY"),
+		timeout: fs.Duration("timeout", 10*time.Second, "timeout of every request"),
+	}
+}
+
+func (rf remoteFlags) client() (*client.Client, error) {
+	if *rf.tenant == "" {
+		return nil, errors.New("--tenant is required")
+	}
+	return client.NewClient(client.ClientArgs{
+		BaseURL: *rf.server,
+		Tenant:  *rf.tenant,
+		APIKey:  *rf.apiKey,
+		Timeout: *rf.timeout,
+		Retries: 2,
+	})
+}
+
+func runGet(args []string) error {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	remote := addRemoteFlags(fs)
+	productID := fs.Uint64("product", 0, "ID of the product")
+	warehouse := fs.String("warehouse", "", "warehouse to get the configuration for")
+	isDefault := fs.Bool("default", false, "get the default configuration instead of a product one")
+	out := formatTable
+	fs.Var(&out, "o", "output format: table, json or csv")
+	fs.Parse(args)
+
+	c, err := remote.client()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if *isDefault {
+		sizes, err := c.GetDefaultPacks(ctx)
+		if err != nil {
+			return err
+		}
+		return result{
+			v:       sizes,
+			headers: []string{"pack_sizes"},
+			rows:    [][]string{{formatSizes(sizes)}},
+		}.write(os.Stdout, out)
+	}
+	if *productID == 0 {
+		return errors.New("--product or --default is required")
+	}
+	sizes, source, err := c.GetPacks(ctx, *productID, *warehouse)
+	if err != nil {
+		return err
+	}
+	return result{
+		v: struct {
+			configEntry
+			Source shipping.ConfigLevel `json:"source"`
+		}{
+			configEntry: configEntry{ProductID: *productID, Warehouse: *warehouse, PackSizes: sizes},
+			Source:      source,
+		},
+		headers: append(configHeaders, "source"),
+		rows:    [][]string{{strconv.FormatUint(*productID, 10), *warehouse, formatSizes(sizes), string(source)}},
+	}.write(os.Stdout, out)
+}
+
+func runSet(args []string) error {
+	fs := flag.NewFlagSet("set", flag.ExitOnError)
+	remote := addRemoteFlags(fs)
+	productID := fs.Uint64("product", 0, "ID of the product")
+	warehouse := fs.String("warehouse", "", "warehouse to update the configuration for")
+	isDefault := fs.Bool("default", false, "update the default configuration instead of a product one")
+	sizesFlag := fs.String("sizes", "", "comma separated list of pack sizes")
+	fs.Parse(args)
+
+	sizes, err := parseSizes(*sizesFlag)
+	if err != nil {
+		return err
+	}
+	if len(sizes) == 0 {
+		return errors.New("--sizes is required")
+	}
+	c, err := remote.client()
+	if err != nil {
+		return err
+	}
+	if *isDefault {
+		return c.UpdateDefaultPacks(context.Background(), sizes)
+	}
+	if *productID == 0 {
+		return errors.New("--product or --default is required")
+	}
+	return setConfig(context.Background(), c, configEntry{ProductID: *productID, Warehouse: *warehouse, PackSizes: sizes})
+}
+
+// runExport exports the configurations of the products, skipping the products that inherit their pack sizes
+// from another level, so importing the export does not pin inherited sizes.
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	remote := addRemoteFlags(fs)
+	products := fs.String("products", "", "comma separated list of product IDs")
+	warehouse := fs.String("warehouse", "", "warehouse to export the configurations of")
+	out := formatJSON
+	fs.Var(&out, "o", "output format: table, json or csv")
+	fs.Parse(args)
+
+	ids, err := parseSizes(*products)
+	if err != nil {
+		return fmt.Errorf("invalid --products: %w", err)
+	}
+	if len(ids) == 0 {
+		return errors.New("--products is required")
+	}
+	c, err := remote.client()
+	if err != nil {
+		return err
+	}
+	level := shipping.LevelProduct
+	if *warehouse != "" {
+		level = shipping.LevelWarehouse
+	}
+	entries := make([]configEntry, 0, len(ids))
+	for _, id := range ids {
+		sizes, source, err := c.GetPacks(context.Background(), id, *warehouse)
+		if errors.Is(err, shipping.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("product %d: %w", id, err)
+		}
+		if source != level {
+			continue
+		}
+		entries = append(entries, configEntry{ProductID: id, Warehouse: *warehouse, PackSizes: sizes})
+	}
+	res := result{
+		v:       entries,
+		headers: configHeaders,
+	}
+	for _, e := range entries {
+		res.rows = append(res.rows, []string{strconv.FormatUint(e.ProductID, 10), e.Warehouse, formatSizes(e.PackSizes)})
+	}
+	return res.write(os.Stdout, out)
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	remote := addRemoteFlags(fs)
+	path := fs.String("file", "", "JSON or CSV file with the configurations, as written by export")
+	fs.Parse(args)
+
+	if *path == "" {
+		return errors.New("--file is required")
+	}
+	f, err := os.Open(*path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var entries []configEntry
+	if strings.EqualFold(filepath.Ext(*path), ".csv") {
+		entries, err = readCSVEntries(f)
+	} else {
+		err = json.NewDecoder(f).Decode(&entries)
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", *path, err)
+	}
+	c, err := remote.client()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := setConfig(context.Background(), c, e); err != nil {
+			return fmt.Errorf("product %d: %w", e.ProductID, err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "imported %d configurations\n", len(entries))
+	return nil
+}
+
+func setConfig(ctx context.Context, c *client.Client, e configEntry) error {
+	if e.Warehouse != "" {
+		return c.UpdateWarehousePacks(ctx, e.Warehouse, e.ProductID, e.PackSizes)
+	}
+	return c.UpdatePacks(ctx, e.ProductID, e.PackSizes)
+}
+
+// readCSVEntries reads the configurations exported as CSV, skipping the header and empty lines
+func readCSVEntries(r io.Reader) ([]configEntry, error) {
+	cr := csv.NewReader(r)
+	// the number of fields is checked below, reporting the line of the entry
+	cr.FieldsPerRecord = -1
+	var entries []configEntry
+	for header := true; ; header = false {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header {
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		if len(record) != len(configHeaders) {
+			return nil, fmt.Errorf("line %d: expected %d fields", line, len(configHeaders))
+		}
+		id, err := strconv.ParseUint(record[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid product id %q", line, record[0])
+		}
+		sizes, err := parseSizes(record[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(sizes) == 0 {
+			return nil, fmt.Errorf("line %d: missing pack sizes", line)
+		}
+		entries = append(entries, configEntry{ProductID: id, Warehouse: record[1], PackSizes: sizes})
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCSVEntries(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected []configEntry
+		err      string
+	}{
+		"empty": {
+			input: "",
+		},
+		"headerOnly": {
+			input: "product_id,warehouse,pack_sizes\n",
+		},
+		"entries": {
+			input: "product_id,warehouse,pack_sizes\n1,,\"250,500\"\n2,w1,1000\n",
+			expected: []configEntry{
+				{ProductID: 1, PackSizes: []uint64{250, 500}},
+				{ProductID: 2, Warehouse: "w1", PackSizes: []uint64{1000}},
+			},
+		},
+		"emptyLines": {
+			input: "\nproduct_id,warehouse,pack_sizes\n\n1,,250\n\n",
+			expected: []configEntry{
+				{ProductID: 1, PackSizes: []uint64{250}},
+			},
+		},
+		"crlf": {
+			input: "product_id,warehouse,pack_sizes\r\n1,,250\r\n",
+			expected: []configEntry{
+				{ProductID: 1, PackSizes: []uint64{250}},
+			},
+		},
+		"missingField": {
+			input: "product_id,warehouse,pack_sizes\n1,250\n",
+			err:   "line 2: expected 3 fields",
+		},
+		"extraField": {
+			input: "product_id,warehouse,pack_sizes\n1,w1,250,500\n",
+			err:   "line 2: expected 3 fields",
+		},
+		"lineAfterEmptyLines": {
+			input: "product_id,warehouse,pack_sizes\n\n\n1,250\n",
+			err:   "line 4: expected 3 fields",
+		},
+		"invalidProductID": {
+			input: "product_id,warehouse,pack_sizes\nfirst,,250\n",
+			err:   `line 2: invalid product id "first"`,
+		},
+		"invalidPackSize": {
+			input: "product_id,warehouse,pack_sizes\n1,,\"250,0\"\n",
+			err:   `line 2: invalid pack size "0"`,
+		},
+		"missingPackSizes": {
+			input: "product_id,warehouse,pack_sizes\n1,w1,\n",
+			err:   "line 2: missing pack sizes",
+		},
+		"unterminatedQuote": {
+			input: "product_id,warehouse,pack_sizes\n1,,\"250,500\n",
+			err:   `extraneous or missing " in quoted-field`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			entries, err := readCSVEntries(strings.NewReader(tc.input))
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, entries)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Command shipctl calculates packings offline and manages the pack configurations of a shipping server.
+//
+// Usage:
+//
+//	shipctl calc --sizes 250,500,1000 --qty 12001
+//	shipctl get --product 1 [--warehouse w1]
+//	shipctl get --default
+//	shipctl set --product 1 --sizes 250,500 [--warehouse w1]
+//	shipctl set --default --sizes 250,500
+//	shipctl export --products 1,2,3 [--warehouse w1] > configs.json
+//	shipctl import --file configs.json
+//	shipctl simulate --current 250,500 --candidate 250,500,750 --orders orders.csv [--carrier express]
+//	shipctl simulate --product 1 --candidate 250,500,750 --orders orders.csv
+//
+// Every command accepts -o table|json|csv. Remote commands read the server address and tenant from
+// --server and --tenant, or from the SHIPCTL_SERVER and SHIPCTL_TENANT environment variables.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `usage: shipctl <command> [flags]
+
+commands:
+  calc     calculate the packs for a quantity, without a server
+  get      show the pack sizes of a product or the default ones
+  set      update the pack sizes of a product or the default ones
+  export   export product configurations
+  import   import product configurations from a JSON or CSV file
+  simulate compare candidate pack sizes with the current ones on historical orders
+
+run "shipctl <command> -h" for the flags of a command
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	commands := map[string]func(args []string) error{
+		"calc":     runCalc,
+		"get":      runGet,
+		"set":      runSet,
+		"export":   runExport,
+		"import":   runImport,
+		"simulate": runSimulate,
+	}
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err := run(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "shipctl:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type format string
+
+const (
+	formatTable format = "table"
+	formatJSON  format = "json"
+	formatCSV   format = "csv"
+)
+
+func (f *format) String() string {
+	return string(*f)
+}
+
+func (f *format) Set(s string) error {
+	switch format(s) {
+	case formatTable, formatJSON, formatCSV:
+		*f = format(s)
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q, must be table, json or csv", s)
+	}
+}
+
+// result is the output of a command: v is encoded as is in JSON, while table and CSV are made of the rows
+type result struct {
+	v       any
+	headers []string
+	rows    [][]string
+	// footer is only printed in table output
+	footer string
+}
+
+func (r result) write(w io.Writer, f format) error {
+	switch f {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r.v)
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(r.headers); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(r.rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(r.headers, "\t")))
+		for _, row := range r.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		if r.footer != "" {
+			_, err := fmt.Fprintln(w, r.footer)
+			return err
+		}
+		return nil
+	}
+}
+
+// parseSizes parses a comma separated list of pack sizes
+func parseSizes(s string) ([]uint64, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	sizes := make([]uint64, 0, len(parts))
+	for _, p := range parts {
+		size, err := strconv.ParseUint(strings.TrimSpace(p), 10, 64)
+		if err != nil || size == 0 {
+			return nil, fmt.Errorf("invalid pack size %q", p)
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
+
+func formatSizes(sizes []uint64) string {
+	parts := make([]string, 0, len(sizes))
+	for _, size := range sizes {
+		parts = append(parts, strconv.FormatUint(size, 10))
+	}
+	return strings.Join(parts, ",")
+}
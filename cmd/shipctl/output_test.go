@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+)
+
+func TestParseSizes(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected []uint64
+		err      string
+	}{
+		"empty": {
+			input: "",
+		},
+		"blank": {
+			input: "  ",
+		},
+		"single": {
+			input:    "250",
+			expected: []uint64{250},
+		},
+		"spaces": {
+			input:    " 250, 500 ,1000",
+			expected: []uint64{250, 500, 1000},
+		},
+		"zero": {
+			input: "250,0",
+			err:   `invalid pack size "0"`,
+		},
+		"negative": {
+			input: "-250",
+			err:   `invalid pack size "-250"`,
+		},
+		"notANumber": {
+			input: "250,large",
+			err:   `invalid pack size "large"`,
+		},
+		"emptyItem": {
+			input: "250,,500",
+			err:   `invalid pack size ""`,
+		},
+		"trailingComma": {
+			input: "250,",
+			err:   `invalid pack size ""`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			sizes, err := parseSizes(tc.input)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, sizes)
+		})
+	}
+}
+
+func TestFormat_Set(t *testing.T) {
+	f := formatTable
+	require.NoError(t, f.Set("csv"))
+	require.Equal(t, formatCSV, f)
+	require.EqualError(t, f.Set("xml"), `unknown output format "xml", must be table, json or csv`)
+	require.Equal(t, formatCSV, f, "an unknown format must leave the format unchanged")
+}
+
+func TestResult_Write(t *testing.T) {
+	res := result{
+		v: calcResult{
+			Quantity: 501,
+			Packs:    []shipping.PackConfig{{Count: 1, Size: 500}, {Count: 1, Size: 250}},
+			Overhead: 249,
+		},
+		headers: []string{"count", "size"},
+		rows:    [][]string{{"1", "500"}, {"1", "250"}},
+		footer:  "overhead: 249",
+	}
+	tests := map[string]struct {
+		format   format
+		expected string
+	}{
+		"table": {
+			format:   formatTable,
+			expected: "COUNT  SIZE\n1      500\n1      250\noverhead: 249\n",
+		},
+		"json": {
+			format: formatJSON,
+			expected: `{
+  "quantity": 501,
+  "packs": [
+    {
+      "number_of_packs": 1,
+      "pack_size": 500
+    },
+    {
+      "number_of_packs": 1,
+      "pack_size": 250
+    }
+  ],
+  "overhead": 249
+}
+`,
+		},
+		"csv": {
+			format:   formatCSV,
+			expected: "count,size\n1,500\n1,250\n",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, res.write(&buf, tc.format))
+			require.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
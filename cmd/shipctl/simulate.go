@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/file"
+	"github.com/silvan-talos/shipping/product"
+)
+
+// runSimulate compares the current and candidate pack sizes on historical orders, either offline with the
+// sizes given in --current, or on the server with the sizes configured for --product.
+func runSimulate(args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	remote := addRemoteFlags(fs)
+	candidateFlag := fs.String("candidate", "", "comma separated list of candidate pack sizes")
+	currentFlag := fs.String("current", "", "comma separated list of current pack sizes, when simulating offline")
+	orders := fs.String("orders", "", "file with one historical order quantity per line, a CSV header is skipped")
+	carrierName := fs.String("carrier", "", "carrier to compare the shipping cost with")
+	rates := fs.String("rates", "rates", "directory of the carrier rate tables, when simulating offline")
+	productID := fs.Uint64("product", 0, "ID of the product to simulate on the server")
+	warehouse := fs.String("warehouse", "", "warehouse of the product, when simulating on the server")
+	out := formatTable
+	fs.Var(&out, "o", "output format: table, json or csv")
+	fs.Parse(args)
+
+	candidate, err := parseSizes(*candidateFlag)
+	if err != nil {
+		return err
+	}
+	if len(candidate) == 0 {
+		return errors.New("--candidate is required")
+	}
+	if *orders == "" {
+		return errors.New("--orders is required")
+	}
+	quantities, err := readQuantities(*orders)
+	if err != nil {
+		return err
+	}
+
+	var sim shipping.Simulation
+	if *productID != 0 {
+		c, err := remote.client()
+		if err != nil {
+			return err
+		}
+		sim, err = c.SimulatePacks(context.Background(), *productID, *warehouse, candidate, quantities, *carrierName)
+		if err != nil {
+			return err
+		}
+	} else {
+		current, err := parseSizes(*currentFlag)
+		if err != nil {
+			return err
+		}
+		if len(current) == 0 {
+			return errors.New("--current or --product is required")
+		}
+		var table *shipping.RateTable
+		if *carrierName != "" {
+			rr, err := file.NewRateRepository(*rates)
+			if err != nil {
+				return err
+			}
+			t, err := rr.GetByCarrier(context.Background(), *carrierName)
+			if err != nil {
+				return fmt.Errorf("carrier %s: %w", *carrierName, err)
+			}
+			table = &t
+		}
+		sim, err = product.Simulate(current, candidate, quantities, table)
+		if err != nil {
+			return err
+		}
+	}
+
+	res := result{
+		v:       sim,
+		headers: []string{"set", "pack_sizes", "overhead", "packs", "cost", "unpriced"},
+		footer:  fmt.Sprintf("orders: %d", sim.Orders),
+	}
+	if sim.Currency != "" {
+		res.footer += ", currency: " + sim.Currency
+	}
+	for _, r := range []struct {
+		name string
+		res  shipping.SimulationResult
+	}{{"current", sim.Current}, {"candidate", sim.Candidate}, {"delta", sim.Delta}} {
+		res.rows = append(res.rows, []string{
+			r.name,
+			formatSizes(r.res.PackSizes),
+			strconv.FormatInt(r.res.Overhead, 10),
+			strconv.FormatInt(r.res.Packs, 10),
+			strconv.FormatInt(r.res.Cost, 10),
+			strconv.Itoa(r.res.Unpriced),
+		})
+	}
+	return res.write(os.Stdout, out)
+}
+
+func readQuantities(path string) ([]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseQuantities(f)
+}
+
+// parseQuantities reads one quantity per line, taking the first column of CSV lines and skipping empty lines
+// and a header line
+func parseQuantities(r io.Reader) ([]uint64, error) {
+	var quantities []uint64
+	scanner := bufio.NewScanner(r)
+	header := true
+	for line := 1; scanner.Scan(); line++ {
+		field, _, _ := strings.Cut(scanner.Text(), ",")
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		qty, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			if header {
+				header = false
+				continue
+			}
+			return nil, fmt.Errorf("line %d: invalid quantity %q", line, field)
+		}
+		header = false
+		quantities = append(quantities, qty)
+	}
+	return quantities, scanner.Err()
+}
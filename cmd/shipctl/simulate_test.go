@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuantities(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected []uint64
+		err      string
+	}{
+		"empty": {
+			input: "",
+		},
+		"quantities": {
+			input:    "1\n251\n12001\n",
+			expected: []uint64{1, 251, 12001},
+		},
+		"noTrailingNewline": {
+			input:    "1\n251",
+			expected: []uint64{1, 251},
+		},
+		"csvFirstColumn": {
+			input:    "quantity,order_id\n501,a-1\n250,a-2\n",
+			expected: []uint64{501, 250},
+		},
+		"emptyLines": {
+			input:    "\n1\n\n  \n251\n\n",
+			expected: []uint64{1, 251},
+		},
+		"headerAfterEmptyLines": {
+			input:    "\n\nquantity\n501\n",
+			expected: []uint64{501},
+		},
+		"crlfAndSpaces": {
+			input:    "quantity\r\n 501 \r\n250\r\n",
+			expected: []uint64{501, 250},
+		},
+		"zero": {
+			input:    "0\n1\n",
+			expected: []uint64{0, 1},
+		},
+		"secondHeader": {
+			input: "quantity\nquantity\n501\n",
+			err:   `line 2: invalid quantity "quantity"`,
+		},
+		"headerAfterQuantities": {
+			input: "501\nquantity\n",
+			err:   `line 2: invalid quantity "quantity"`,
+		},
+		"negative": {
+			input: "quantity\n501\n-1\n",
+			err:   `line 3: invalid quantity "-1"`,
+		},
+		"emptyFirstColumn": {
+			input:    "quantity,order_id\n,a-1\n501,a-2\n",
+			expected: []uint64{501},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			quantities, err := parseQuantities(strings.NewReader(tc.input))
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, quantities)
+		})
+	}
+}
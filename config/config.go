@@ -0,0 +1,258 @@
+// Package config loads the configuration of the shipping server from, in increasing order of precedence,
+// its defaults, an optional JSON file, environment variables and command line flags.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/logging"
+	"github.com/silvan-talos/shipping/product"
+)
+
+// repository backends
+const (
+	BackendInmem = "inmem"
+	BackendFile  = "file"
+)
+
+type Config struct {
+	HTTP       HTTP       `json:"http"`
+	GRPC       GRPC       `json:"grpc"`
+	Repository Repository `json:"repository"`
+	Packs      Packs      `json:"packs"`
+	// RatesDir holds the rate table of every carrier
+	RatesDir       string          `json:"rates_dir"`
+	Log            logging.Options `json:"log"`
+	Auth           Auth            `json:"auth"`
+	PolicyFile     string          `json:"policy_file"`
+	Audit          Audit           `json:"audit"`
+	RateLimitsFile string          `json:"rate_limits_file"`
+	// IdempotencyEntries bounds the idempotency keys kept in memory, the oldest ones are evicted first
+	IdempotencyEntries int      `json:"idempotency_entries"`
+	Features           Features `json:"features"`
+}
+
+type HTTP struct {
+	Addr string `json:"addr"`
+	// Mode is the gin mode: release, debug or test
+	Mode         string   `json:"mode"`
+	ReadTimeout  Duration `json:"read_timeout"`
+	WriteTimeout Duration `json:"write_timeout"`
+	IdleTimeout  Duration `json:"idle_timeout"`
+	// ShutdownDelay is how long readiness fails before the connections are drained on shutdown
+	ShutdownDelay Duration `json:"shutdown_delay"`
+	// ShutdownTimeout bounds the time spent draining the connections on shutdown
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+	// TrustedProxies are the IPs or CIDRs of the proxies whose X-Forwarded-For header gives the client IP
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+type GRPC struct {
+	Addr string `json:"addr"`
+}
+
+type Repository struct {
+	// Backend keeping the pack configurations, inmem or file
+	Backend string `json:"backend"`
+	// DSN locates the data of the backend, the path of the JSON file for the file backend
+	DSN string `json:"dsn"`
+}
+
+type Packs struct {
+	// Default are the pack sizes of tenants that did not configure their own default ones
+	Default []uint64 `json:"default"`
+	// CacheEntries bounds the cached configurations, 0 disables the cache
+	CacheEntries int      `json:"cache_entries"`
+	CacheTTL     Duration `json:"cache_ttl"`
+	// TableBound is the quantity up to which packings are precomputed, 0 disables precomputing
+	TableBound uint64 `json:"table_bound"`
+}
+
+type Auth struct {
+	APIKeysFile    string `json:"api_keys_file"`
+	JWKSFile       string `json:"jwks_file"`
+	JWTIssuer      string `json:"jwt_issuer"`
+	JWTAudience    string `json:"jwt_audience"`
+	JWTTenantClaim string `json:"jwt_tenant_claim"`
+	// InsecureNoAuth lets the server start without API keys nor JWKS, every request is then allowed
+	InsecureNoAuth bool `json:"insecure_no_auth"`
+}
+
+type Audit struct {
+	// File the entries are appended to, they are kept in memory when empty
+	File   string `json:"file"`
+	Stdout bool   `json:"stdout"`
+}
+
+// Features switch optional parts of the server on and off.
+type Features struct {
+	GRPC        bool `json:"grpc"`
+	Metrics     bool `json:"metrics"`
+	Idempotency bool `json:"idempotency"`
+}
+
+// Default returns the configuration used for the settings not given.
+func Default() Config {
+	return Config{
+		HTTP: HTTP{
+			Addr:         ":8080",
+			Mode:         "release",
+			ReadTimeout:  Duration(10 * time.Second),
+			WriteTimeout: Duration(30 * time.Second),
+			IdleTimeout:  Duration(2 * time.Minute),
+			// ECS kills the task 30s after stopping it
+			ShutdownTimeout: Duration(20 * time.Second),
+		},
+		GRPC: GRPC{
+			Addr: ":9090",
+		},
+		Repository: Repository{
+			Backend: BackendInmem,
+		},
+		Packs: Packs{
+			// copied, decoding the config file reuses the array of the slice
+			Default:      append([]uint64(nil), shipping.DefaultPackSizes...),
+			CacheEntries: 10000,
+			CacheTTL:     Duration(time.Minute),
+			TableBound:   10000,
+		},
+		RatesDir:           "rates",
+		IdempotencyEntries: 100000,
+		Features: Features{
+			GRPC:        true,
+			Metrics:     true,
+			Idempotency: true,
+		},
+	}
+}
+
+// Load reads the configuration from the command line arguments, without the program name, and from the
+// environment through getenv. The config file is given by the -config flag or SHIPPING_CONFIG_FILE.
+func Load(args []string, getenv func(string) string, output io.Writer) (Config, error) {
+	cfg := Default()
+	options := cfg.options()
+	fs := flag.NewFlagSet("shipping", flag.ContinueOnError)
+	fs.SetOutput(output)
+	configFile := fs.String("config", getenv("SHIPPING_CONFIG_FILE"), "path of the JSON config file (env SHIPPING_CONFIG_FILE)")
+	flags := make(map[string]*flagValue, len(options))
+	for _, o := range options {
+		flags[o.flag] = &flagValue{option: o}
+		fs.Var(flags[o.flag], o.flag, fmt.Sprintf("%s (env %s)", o.usage, o.env))
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if *configFile != "" {
+		if err := cfg.readFile(*configFile); err != nil {
+			return Config{}, err
+		}
+	}
+	var errs []error
+	for _, o := range options {
+		if v := getenv(o.env); v != "" {
+			if err := o.value.Set(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", o.env, err))
+			}
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		v, ok := flags[f.Name]
+		if !ok {
+			return
+		}
+		if err := v.value.Set(v.raw); err != nil {
+			errs = append(errs, fmt.Errorf("-%s: %w", f.Name, err))
+		}
+	})
+	if len(errs) > 0 {
+		return Config{}, fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open config file: %w", err)
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	// a misspelled setting would otherwise be silently ignored
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("decode config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports every invalid setting, named by its path in the config file.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	check(validAddr(c.HTTP.Addr), "http.addr: invalid listen address %q, expected host:port", c.HTTP.Addr)
+	check(c.HTTP.Mode == "release" || c.HTTP.Mode == "debug" || c.HTTP.Mode == "test",
+		"http.mode: invalid mode %q, expected release, debug or test", c.HTTP.Mode)
+	check(c.HTTP.ReadTimeout >= 0, "http.read_timeout: cannot be negative")
+	check(c.HTTP.WriteTimeout >= 0, "http.write_timeout: cannot be negative")
+	check(c.HTTP.IdleTimeout >= 0, "http.idle_timeout: cannot be negative")
+	check(c.HTTP.ShutdownDelay >= 0, "http.shutdown_delay: cannot be negative")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout: must be positive")
+	for _, proxy := range c.HTTP.TrustedProxies {
+		check(validProxy(proxy), "http.trusted_proxies: invalid IP or CIDR %q", proxy)
+	}
+	if c.Features.GRPC {
+		check(validAddr(c.GRPC.Addr), "grpc.addr: invalid listen address %q, expected host:port", c.GRPC.Addr)
+	}
+	switch c.Repository.Backend {
+	case BackendInmem:
+	case BackendFile:
+		check(c.Repository.DSN != "", "repository.dsn: required by the %s backend", BackendFile)
+	default:
+		check(false, "repository.backend: unknown backend %q, expected %s or %s", c.Repository.Backend, BackendInmem, BackendFile)
+	}
+	check(len(c.Packs.Default) > 0, "packs.default: at least one pack size is required")
+	for _, size := range c.Packs.Default {
+		check(size > 0 && size <= shipping.MaxPackSize, "packs.default: pack size %d out of range [1, %d]", size, shipping.MaxPackSize)
+	}
+	check(c.Packs.CacheEntries >= 0, "packs.cache_entries: cannot be negative")
+	check(c.Packs.CacheEntries == 0 || c.Packs.CacheTTL > 0, "packs.cache_ttl: must be positive when the cache is enabled")
+	check(c.Packs.TableBound <= product.MaxTableBound, "packs.table_bound: %d out of range [0, %d]", c.Packs.TableBound, product.MaxTableBound)
+	check(c.RatesDir != "", "rates_dir: required")
+	check(!c.Features.Idempotency || c.IdempotencyEntries > 0, "idempotency_entries: must be positive when idempotency is enabled")
+	check(c.Auth.APIKeysFile != "" || c.Auth.JWKSFile != "" || c.Auth.InsecureNoAuth,
+		"auth: api_keys_file or jwks_file required, set insecure_no_auth to run without authentication")
+	if err := c.Log.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("log: %w", err))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func validProxy(proxy string) bool {
+	if _, _, err := net.ParseCIDR(proxy); err == nil {
+		return true
+	}
+	return net.ParseIP(proxy) != nil
+}
+
+func validAddr(addr string) bool {
+	_, _, err := net.SplitHostPort(addr)
+	return err == nil
+}
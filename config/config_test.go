@@ -0,0 +1,140 @@
+package config_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping/config"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(key string) string {
+		return vars[key]
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(`{
+		"http": {"addr": ":8000", "read_timeout": "5s"},
+		"repository": {"backend": "file", "dsn": "/var/lib/shipping/packs.json"},
+		"packs": {"default": [100, 200]},
+		"log": {"level": "debug"},
+		"auth": {"api_keys_file": "/etc/shipping/keys.json"},
+		"features": {"grpc": false}
+	}`), 0o600)
+	require.NoError(t, err)
+
+	cfg, err := config.Load(
+		[]string{"-config", path, "-http-addr", ":7000", "-cache-entries", "0", "-audit-stdout", "-metrics=false"},
+		env(map[string]string{
+			"SHIPPING_HTTP_ADDR":           ":9000",
+			"SHIPPING_DEFAULT_PACK_SIZES":  "23, 31, 53",
+			"SHIPPING_FEATURE_IDEMPOTENCY": "false",
+		}),
+		io.Discard,
+	)
+	require.NoError(t, err)
+
+	expected := config.Default()
+	// flags take precedence over the environment, which takes precedence over the file
+	expected.HTTP.Addr = ":7000"
+	expected.HTTP.ReadTimeout = config.Duration(5 * time.Second)
+	expected.Repository = config.Repository{Backend: config.BackendFile, DSN: "/var/lib/shipping/packs.json"}
+	expected.Packs.Default = []uint64{23, 31, 53}
+	expected.Packs.CacheEntries = 0
+	expected.Log.Level = "debug"
+	expected.Auth.APIKeysFile = "/etc/shipping/keys.json"
+	expected.Audit.Stdout = true
+	expected.Features = config.Features{}
+	require.Equal(t, expected, cfg)
+}
+
+func TestLoad_ConfigFileFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"rates_dir": "/etc/shipping/rates"}`), 0o600))
+
+	cfg, err := config.Load([]string{"-insecure-no-auth"}, env(map[string]string{"SHIPPING_CONFIG_FILE": path}), io.Discard)
+	require.NoError(t, err)
+	require.Equal(t, "/etc/shipping/rates", cfg.RatesDir)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	tests := map[string]struct {
+		args        []string
+		env         map[string]string
+		expectedErr string
+	}{
+		"unknownFlag": {
+			args:        []string{"-port", "80"},
+			expectedErr: "flag provided but not defined: -port",
+		},
+		"missingFile": {
+			args:        []string{"-config", filepath.Join(dir, "missing.json")},
+			expectedErr: "open config file: open " + filepath.Join(dir, "missing.json") + ": no such file or directory",
+		},
+		"misspelledSetting": {
+			args:        []string{"-config", write("typo.json", `{"http": {"adress": ":80"}}`)},
+			expectedErr: "decode config file " + filepath.Join(dir, "typo.json") + `: json: unknown field "adress"`,
+		},
+		"invalidDurationInFile": {
+			args:        []string{"-config", write("duration.json", `{"http": {"read_timeout": 5}}`)},
+			expectedErr: "decode config file " + filepath.Join(dir, "duration.json") + `: duration must be a string like "30s"`,
+		},
+		"unparsableValues": {
+			args: []string{"-cache-ttl", "soon"},
+			env:  map[string]string{"SHIPPING_DEFAULT_PACK_SIZES": "250,five"},
+			expectedErr: "invalid config: SHIPPING_DEFAULT_PACK_SIZES: invalid pack size \"five\"\n" +
+				"-cache-ttl: invalid duration \"soon\"",
+		},
+		"invalidSettings": {
+			args: []string{"-http-addr", "8080", "-repository", "file", "-default-pack-sizes", "0,250", "-log-format", "xml"},
+			expectedErr: "invalid config: http.addr: invalid listen address \"8080\", expected host:port\n" +
+				"repository.dsn: required by the file backend\n" +
+				"packs.default: pack size 0 out of range [1, 4611686018427387903]\n" +
+				"log: invalid log format \"xml\", expected json or text",
+		},
+		"invalidTrustedProxy": {
+			env:         map[string]string{"SHIPPING_HTTP_TRUSTED_PROXIES": "10.0.0.0/16, proxy.local"},
+			expectedErr: "invalid config: http.trusted_proxies: invalid IP or CIDR \"proxy.local\"",
+		},
+		"noShutdownTimeout": {
+			args:        []string{"-http-shutdown-timeout", "0s", "-http-shutdown-delay", "-1s"},
+			expectedErr: "invalid config: http.shutdown_delay: cannot be negative\nhttp.shutdown_timeout: must be positive",
+		},
+		"noAuthentication": {
+			env:         map[string]string{"SHIPPING_INSECURE_NO_AUTH": "false"},
+			expectedErr: "invalid config: auth: api_keys_file or jwks_file required, set insecure_no_auth to run without authentication",
+		},
+		"unknownBackend": {
+			env:         map[string]string{"SHIPPING_REPOSITORY": "postgres", "SHIPPING_CACHE_TTL": "0s"},
+			expectedErr: "invalid config: repository.backend: unknown backend \"postgres\", expected inmem or file\npacks.cache_ttl: must be positive when the cache is enabled",
+		},
+		"outOfBounds": {
+			args: []string{"-idempotency-entries", "0", "-table-bound", "100000000"},
+			expectedErr: "invalid config: packs.table_bound: 100000000 out of range [0, 100000]\n" +
+				"idempotency_entries: must be positive when idempotency is enabled",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			vars := map[string]string{"SHIPPING_INSECURE_NO_AUTH": "true"}
+			for k, v := range tc.env {
+				vars[k] = v
+			}
+			_, err := config.Load(tc.args, env(vars), io.Discard)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
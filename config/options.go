@@ -0,0 +1,207 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// option is a setting that can be given as a flag or an environment variable, its value is bound to the
+// field of the config it sets
+type option struct {
+	flag  string
+	env   string
+	usage string
+	value flag.Value
+}
+
+func (c *Config) options() []option {
+	return []option{
+		{"http-addr", "SHIPPING_HTTP_ADDR", "listen address of the HTTP API", (*stringValue)(&c.HTTP.Addr)},
+		{"http-mode", "SHIPPING_HTTP_MODE", "gin mode: release, debug or test", (*stringValue)(&c.HTTP.Mode)},
+		{"http-read-timeout", "SHIPPING_HTTP_READ_TIMEOUT", "limit to read a request, 0 for none", &c.HTTP.ReadTimeout},
+		{"http-write-timeout", "SHIPPING_HTTP_WRITE_TIMEOUT", "limit to write a response, 0 for none", &c.HTTP.WriteTimeout},
+		{"http-idle-timeout", "SHIPPING_HTTP_IDLE_TIMEOUT", "limit to keep idle connections open, 0 for none", &c.HTTP.IdleTimeout},
+		{"http-shutdown-delay", "SHIPPING_HTTP_SHUTDOWN_DELAY", "time readiness fails before draining the connections on shutdown", &c.HTTP.ShutdownDelay},
+		{"http-shutdown-timeout", "SHIPPING_HTTP_SHUTDOWN_TIMEOUT", "limit to drain the connections on shutdown", &c.HTTP.ShutdownTimeout},
+		{"http-trusted-proxies", "SHIPPING_HTTP_TRUSTED_PROXIES", "comma separated IPs or CIDRs of the proxies trusted to set X-Forwarded-For", (*listValue)(&c.HTTP.TrustedProxies)},
+		{"grpc-addr", "SHIPPING_GRPC_ADDR", "listen address of the gRPC API", (*stringValue)(&c.GRPC.Addr)},
+		{"repository", "SHIPPING_REPOSITORY", "backend of the pack configurations: inmem or file", (*stringValue)(&c.Repository.Backend)},
+		{"repository-dsn", "SHIPPING_REPOSITORY_DSN", "data source of the repository backend, the path of the file backend", (*stringValue)(&c.Repository.DSN)},
+		{"default-pack-sizes", "SHIPPING_DEFAULT_PACK_SIZES", "comma separated pack sizes of tenants without their own default ones", (*sizesValue)(&c.Packs.Default)},
+		{"cache-entries", "SHIPPING_CACHE_ENTRIES", "pack configurations cached, 0 disables the cache", (*intValue)(&c.Packs.CacheEntries)},
+		{"cache-ttl", "SHIPPING_CACHE_TTL", "how long pack configurations are cached", &c.Packs.CacheTTL},
+		{"table-bound", "SHIPPING_TABLE_BOUND", "quantity up to which packings are precomputed, at most 100000, 0 disables precomputing", (*uint64Value)(&c.Packs.TableBound)},
+		{"rates-dir", "SHIPPING_RATES_DIR", "directory of the carrier rate tables", (*stringValue)(&c.RatesDir)},
+		{"log-level", "SHIPPING_LOG_LEVEL", "log level: debug, info, warn or error", (*stringValue)(&c.Log.Level)},
+		{"log-format", "SHIPPING_LOG_FORMAT", "log format: json or text", (*stringValue)(&c.Log.Format)},
+		{"api-keys-file", "SHIPPING_API_KEYS_FILE", "file of the API keys, enables authentication", (*stringValue)(&c.Auth.APIKeysFile)},
+		{"jwks-file", "SHIPPING_JWKS_FILE", "JWKS file verifying the JWTs, enables authentication", (*stringValue)(&c.Auth.JWKSFile)},
+		{"jwt-issuer", "SHIPPING_JWT_ISSUER", "required issuer of the JWTs", (*stringValue)(&c.Auth.JWTIssuer)},
+		{"jwt-audience", "SHIPPING_JWT_AUDIENCE", "required audience of the JWTs", (*stringValue)(&c.Auth.JWTAudience)},
+		{"jwt-tenant-claim", "SHIPPING_JWT_TENANT_CLAIM", "claim of the JWTs holding the tenant", (*stringValue)(&c.Auth.JWTTenantClaim)},
+		{"insecure-no-auth", "SHIPPING_INSECURE_NO_AUTH", "run without authentication, allowing every request", (*boolValue)(&c.Auth.InsecureNoAuth)},
+		{"policy-file", "SHIPPING_POLICY_FILE", "file of the access policy", (*stringValue)(&c.PolicyFile)},
+		{"audit-file", "SHIPPING_AUDIT_FILE", "file the audit entries are appended to", (*stringValue)(&c.Audit.File)},
+		{"audit-stdout", "SHIPPING_AUDIT_STDOUT", "also write the audit entries to stdout", (*boolValue)(&c.Audit.Stdout)},
+		{"rate-limits-file", "SHIPPING_RATE_LIMITS_FILE", "file of the rate limits, enables rate limiting", (*stringValue)(&c.RateLimitsFile)},
+		{"idempotency-entries", "SHIPPING_IDEMPOTENCY_ENTRIES", "idempotency keys kept, the oldest ones are evicted first", (*intValue)(&c.IdempotencyEntries)},
+		{"grpc", "SHIPPING_FEATURE_GRPC", "serve the gRPC API", (*boolValue)(&c.Features.GRPC)},
+		{"metrics", "SHIPPING_FEATURE_METRICS", "serve the Prometheus metrics on /metrics", (*boolValue)(&c.Features.Metrics)},
+		{"idempotency", "SHIPPING_FEATURE_IDEMPOTENCY", "replay the responses of requests with an Idempotency-Key", (*boolValue)(&c.Features.Idempotency)},
+	}
+}
+
+// flagValue records the value of the flag of an option while parsing, it is set once the file and the
+// environment are read so the flag takes precedence over them
+type flagValue struct {
+	option
+	raw string
+}
+
+func (v *flagValue) String() string {
+	if v.value == nil {
+		// the zero value flag package makes to tell whether the default is the zero value
+		return ""
+	}
+	return v.value.String()
+}
+
+func (v *flagValue) Set(s string) error {
+	v.raw = s
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	_, ok := v.value.(*boolValue)
+	return ok
+}
+
+// Duration is a time.Duration written as a string in the config file, e.g. "30s".
+type Duration time.Duration
+
+func (d *Duration) String() string {
+	return time.Duration(*d).String()
+}
+
+func (d *Duration) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", s)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\"")
+	}
+	return d.Set(s)
+}
+
+type stringValue string
+
+func (v *stringValue) String() string {
+	return string(*v)
+}
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+type boolValue bool
+
+func (v *boolValue) String() string {
+	return strconv.FormatBool(bool(*v))
+}
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("invalid boolean %q", s)
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+type intValue int
+
+func (v *intValue) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+func (v *intValue) Set(s string) error {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", s)
+	}
+	*v = intValue(i)
+	return nil
+}
+
+type uint64Value uint64
+
+func (v *uint64Value) String() string {
+	return strconv.FormatUint(uint64(*v), 10)
+}
+
+func (v *uint64Value) Set(s string) error {
+	i, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %q", s)
+	}
+	*v = uint64Value(i)
+	return nil
+}
+
+// sizesValue is a comma separated list of pack sizes
+type sizesValue []uint64
+
+func (v *sizesValue) String() string {
+	sizes := make([]string, len(*v))
+	for i, size := range *v {
+		sizes[i] = strconv.FormatUint(size, 10)
+	}
+	return strings.Join(sizes, ",")
+}
+
+func (v *sizesValue) Set(s string) error {
+	var sizes []uint64
+	for _, field := range strings.Split(s, ",") {
+		size, err := strconv.ParseUint(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid pack size %q", field)
+		}
+		sizes = append(sizes, size)
+	}
+	*v = sizes
+	return nil
+}
+
+// listValue is a comma separated list of strings
+type listValue []string
+
+func (v *listValue) String() string {
+	return strings.Join(*v, ",")
+}
+
+func (v *listValue) Set(s string) error {
+	var list []string
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			list = append(list, field)
+		}
+	}
+	*v = list
+	return nil
+}
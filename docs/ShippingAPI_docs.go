@@ -16,45 +16,1346 @@ const docTemplateShippingAPI = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/v1/audit": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Returns the attempts to change pack configurations, successful or not, the most recent first\nRequires the audit:read scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Find audit entries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the product",
+                        "name": "product_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the principal that attempted the change",
+                        "name": "principal",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time of the oldest entry",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time the entries are older than",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "type": "integer",
+                        "description": "Max number of entries, 100 by default",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/shipping.AuditEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, retry after the seconds of the Retry-After header",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/labels": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Renders one label per physical pack of a computed packing, as ZPL for thermal printers or as a PDF document\nRequires the labels:write scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "labels"
+                ],
+                "summary": "Generate shipping labels",
+                "parameters": [
+                    {
+                        "description": "Computed packing and addresses",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.labelsRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the response of the first request made with the same key for 24h",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, retry after the seconds of the Retry-After header",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/packaging/default": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Returns the pack sizes used for products without configuration\nRequires the packaging:read scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "packaging"
+                ],
+                "summary": "Get default packaging configuration",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, retry after the seconds of the Retry-After header",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Updates the pack sizes used for products without configuration\nRequires the packaging:write scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "packaging"
+                ],
+                "summary": "Update default packaging configuration",
+                "parameters": [
+                    {
+                        "description": "The list of supported pack sizes",
+                        "name": "pack_sizes",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the response of the first request made with the same key for 24h",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, retry after the seconds of the Retry-After header",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/packaging/recommend": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Recommends the set of at most max_sizes pack sizes that minimises the expected overhead and pack count of the historical order quantities.\nOne extra pack weighs as much as pack_weight overhead items, 1 by default. The search is randomised, the seed of the response reproduces the recommendation.\nRequires the packaging:read scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "packaging"
+                ],
+                "summary": "Recommend pack sizes",
+                "parameters": [
+                    {
+                        "description": "Historical order quantities and the max number of sizes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.recommendationRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the response of the first request made with the same key for 24h",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/shipping.Recommendation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, retry after the seconds of the Retry-After header",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
         "/v1/products/{id}/packaging": {
             "get": {
-                "description": "Calculates number of packets based on product configuration",
+                "security": [
+                    {
+                        "ApiKey": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Calculates number of packets based on product configuration.\nReturns the packs and the overhead, along with the estimated shipping cost per carrier when one or more carriers are given.\nIn cost mode the packs minimise the delivered cost with the carrier and the tradeoff against the min overhead packing is returned as shipping.CostOptimalPacking.\nPack sizes are taken from the warehouse, the product or the default configuration, whichever is found first, and the level used is returned in the X-Pack-Config-Source header.\nRequires the packaging:read scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "packaging",
+                    "products"
+                ],
+                "summary": "Get product packaging",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the product",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Order quantity for product, up to 4611686018427387903",
+                        "name": "qty",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Warehouse packing the order",
+                        "name": "warehouse",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated list of carriers to estimate the cost for",
+                        "name": "carrier",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "overhead",
+                            "cost"
+                        ],
+                        "type": "string",
+                        "description": "Packing objective, cost requires a single carrier",
+                        "name": "mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Packs, with the estimates when a carrier is given",
+                        "schema": {
+                            "$ref": "#/definitions/http.packagingResponse"
+                        },
+                        "headers": {
+                            "X-Pack-Config-Source": {
+                                "type": "string",
+                                "description": "Level of the configuration used: warehouse, product or default"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, retry after the seconds of the Retry-After header",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Updates configuration for the specified product, or for the product in a single warehouse when the warehouse is given\nRequires the packaging:write scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "packaging",
+                    "products"
+                ],
+                "summary": "Update product packaging configuration",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the product",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Warehouse to update the configuration for",
+                        "name": "warehouse",
+                        "in": "query"
+                    },
+                    {
+                        "description": "The list of supported pack sizes",
+                        "name": "pack_sizes",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the response of the first request made with the same key for 24h",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, retry after the seconds of the Retry-After header",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/products/{id}/packaging/config": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Returns the pack sizes used for the product, or for the product in a single warehouse when the warehouse is given, and the level of the configuration they come from\nRequires the packaging:read scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "packaging",
+                    "products"
+                ],
+                "summary": "Get product packaging configuration",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the product",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Warehouse to get the configuration for",
+                        "name": "warehouse",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.packagingConfigResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, retry after the seconds of the Retry-After header",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/products/{id}/packaging/simulate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Packs the historical order quantities with the pack sizes currently used for the product and with the candidate ones, and compares the total overhead and pack count.\nWhen a carrier is given, the shipping cost of both is compared too. Negative deltas are savings of the candidate pack sizes.\nRequires the packaging:read scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "packaging",
+                    "products"
+                ],
+                "summary": "Simulate product packaging",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the product",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Candidate pack sizes and historical order quantities",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.simulationRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the response of the first request made with the same key for 24h",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/shipping.Simulation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, retry after the seconds of the Retry-After header",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/shipments": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Lists the shipments planned for an order\nRequires the shipments:read scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shipments"
+                ],
+                "summary": "Find shipments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the order",
+                        "name": "order_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/shipping.Shipment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, retry after the seconds of the Retry-After header",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Calculates the packing for an order, reserves the packs from the warehouse stock and records it as a planned shipment.\nWhen the recommended packs are not in stock, the packing is replanned with the pack sizes available.\nRequires the shipments:write scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shipments"
+                ],
+                "summary": "Plan shipment",
+                "parameters": [
+                    {
+                        "description": "Order to plan the shipment for",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.planShipmentRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the response of the first request made with the same key for 24h",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/shipping.Shipment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, retry after the seconds of the Retry-After header",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/shipments/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Requires the shipments:read scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "packaging",
-                    "products"
+                    "shipments"
+                ],
+                "summary": "Get shipment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the shipment",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/shipping.Shipment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, retry after the seconds of the Retry-After header",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/shipments/{id}/{action}": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Moves the shipment through its lifecycle: planned, packed, dispatched, delivered or cancelled\nRequires the shipments:write scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shipments"
+                ],
+                "summary": "Update shipment status",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the shipment",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pack",
+                            "dispatch",
+                            "deliver",
+                            "cancel"
+                        ],
+                        "type": "string",
+                        "description": "Lifecycle action",
+                        "name": "action",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the response of the first request made with the same key for 24h",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/shipping.Shipment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, retry after the seconds of the Retry-After header",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/warehouses/{warehouse}/stock": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Returns the packaging materials in stock, as pack size to count\nRequires the inventory:read scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "warehouses"
                 ],
-                "summary": "Get product packaging",
+                "summary": "Get packaging stock",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID of the product",
-                        "name": "id",
+                        "type": "string",
+                        "description": "ID of the warehouse",
+                        "name": "warehouse",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Order quantity for product",
-                        "name": "qty",
-                        "in": "query",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/shipping.PackConfig"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "properties": {
@@ -67,43 +1368,64 @@ const docTemplateShippingAPI = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "429": {
+                        "description": "Rate limit exceeded, retry after the seconds of the Retry-After header",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             },
             "put": {
-                "description": "Updates configuration for the specified product",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKey": []
+                    },
+                    {
+                        "BearerToken": []
+                    }
                 ],
-                "produces": [
+                "description": "Sets the stock of the provided pack sizes, other sizes are left unchanged\nRequires the inventory:write scope.",
+                "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "packaging",
-                    "products"
+                    "warehouses"
                 ],
-                "summary": "Update product packaging configuration",
+                "summary": "Update packaging stock",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID of the product",
-                        "name": "id",
+                        "type": "string",
+                        "description": "ID of the warehouse",
+                        "name": "warehouse",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "The list of supported pack sizes",
-                        "name": "pack_sizes",
+                        "description": "Pack size to count",
+                        "name": "stock",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
+                            "type": "object",
+                            "additionalProperties": {
                                 "type": "integer"
                             }
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the response of the first request made with the same key for 24h",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -121,8 +1443,38 @@ const docTemplateShippingAPI = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found"
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, retry after the seconds of the Retry-After header",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
                     },
                     "500": {
                         "description": "Internal Server Error"
@@ -132,6 +1484,275 @@ const docTemplateShippingAPI = `{
         }
     },
     "definitions": {
+        "http.labelsRequest": {
+            "type": "object",
+            "required": [
+                "format",
+                "from",
+                "packs",
+                "product_id",
+                "quantity",
+                "to"
+            ],
+            "properties": {
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "zpl",
+                        "pdf"
+                    ]
+                },
+                "from": {
+                    "$ref": "#/definitions/shipping.Address"
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/shipping.PackConfig"
+                    }
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "to": {
+                    "$ref": "#/definitions/shipping.Address"
+                }
+            }
+        },
+        "http.packagingConfigResponse": {
+            "type": "object",
+            "properties": {
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "source": {
+                    "$ref": "#/definitions/shipping.ConfigLevel"
+                }
+            }
+        },
+        "http.packagingResponse": {
+            "type": "object",
+            "properties": {
+                "estimates": {
+                    "description": "Estimates are only returned when carriers are given",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/shipping.CostEstimate"
+                    }
+                },
+                "overhead": {
+                    "type": "integer"
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/shipping.PackConfig"
+                    }
+                },
+                "source": {
+                    "$ref": "#/definitions/shipping.ConfigLevel"
+                }
+            }
+        },
+        "http.planShipmentRequest": {
+            "type": "object",
+            "required": [
+                "order_id",
+                "product_id",
+                "quantity",
+                "warehouse"
+            ],
+            "properties": {
+                "order_id": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "warehouse": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.recommendationRequest": {
+            "type": "object",
+            "required": [
+                "max_sizes",
+                "quantities"
+            ],
+            "properties": {
+                "max_sizes": {
+                    "type": "integer"
+                },
+                "pack_weight": {
+                    "type": "number"
+                },
+                "quantities": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "seed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.simulationRequest": {
+            "type": "object",
+            "required": [
+                "candidate_sizes",
+                "quantities"
+            ],
+            "properties": {
+                "candidate_sizes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "carrier": {
+                    "type": "string"
+                },
+                "quantities": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "warehouse": {
+                    "type": "string"
+                }
+            }
+        },
+        "shipping.Address": {
+            "type": "object",
+            "required": [
+                "city",
+                "country",
+                "name",
+                "postal_code",
+                "street"
+            ],
+            "properties": {
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "postal_code": {
+                    "type": "string"
+                },
+                "street": {
+                    "type": "string"
+                }
+            }
+        },
+        "shipping.AuditAction": {
+            "type": "string",
+            "enum": [
+                "update_packs",
+                "update_warehouse_packs",
+                "update_default_packs"
+            ],
+            "x-enum-varnames": [
+                "AuditUpdatePacks",
+                "AuditUpdateWarehousePacks",
+                "AuditUpdateDefaultPacks"
+            ]
+        },
+        "shipping.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/shipping.AuditAction"
+                },
+                "after": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "at": {
+                    "type": "string"
+                },
+                "before": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "client": {
+                    "description": "Client is the address and user agent the change was requested from",
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Error explains why a failed attempt was rejected",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "principal": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "warehouse": {
+                    "type": "string"
+                }
+            }
+        },
+        "shipping.ConfigLevel": {
+            "type": "string",
+            "enum": [
+                "warehouse",
+                "product",
+                "default"
+            ],
+            "x-enum-varnames": [
+                "LevelWarehouse",
+                "LevelProduct",
+                "LevelDefault"
+            ]
+        },
+        "shipping.CostEstimate": {
+            "type": "object",
+            "properties": {
+                "carrier": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/shipping.PackCost"
+                    }
+                },
+                "total_cost": {
+                    "type": "integer"
+                }
+            }
+        },
         "shipping.PackConfig": {
             "type": "object",
             "properties": {
@@ -142,6 +1763,207 @@ const docTemplateShippingAPI = `{
                     "type": "integer"
                 }
             }
+        },
+        "shipping.PackCost": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "type": "integer"
+                },
+                "number_of_packs": {
+                    "type": "integer"
+                },
+                "pack_size": {
+                    "type": "integer"
+                },
+                "unit_cost": {
+                    "type": "integer"
+                }
+            }
+        },
+        "shipping.Packing": {
+            "type": "object",
+            "properties": {
+                "overhead": {
+                    "type": "integer"
+                },
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/shipping.PackConfig"
+                    }
+                },
+                "source": {
+                    "$ref": "#/definitions/shipping.ConfigLevel"
+                }
+            }
+        },
+        "shipping.Recommendation": {
+            "type": "object",
+            "properties": {
+                "expected_overhead": {
+                    "type": "number"
+                },
+                "expected_packs": {
+                    "type": "number"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "seed": {
+                    "description": "Seed of the search, the same demand and seed always result in the same recommendation",
+                    "type": "integer"
+                }
+            }
+        },
+        "shipping.Shipment": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/shipping.StatusChange"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "packing": {
+                    "$ref": "#/definitions/shipping.Packing"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "replanned": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "$ref": "#/definitions/shipping.ShipmentStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "warehouse": {
+                    "type": "string"
+                }
+            }
+        },
+        "shipping.ShipmentStatus": {
+            "type": "string",
+            "enum": [
+                "planned",
+                "packed",
+                "dispatched",
+                "delivered",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "StatusPlanned",
+                "StatusPacked",
+                "StatusDispatched",
+                "StatusDelivered",
+                "StatusCancelled"
+            ]
+        },
+        "shipping.Simulation": {
+            "type": "object",
+            "properties": {
+                "candidate": {
+                    "$ref": "#/definitions/shipping.SimulationResult"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "current": {
+                    "$ref": "#/definitions/shipping.SimulationResult"
+                },
+                "delta": {
+                    "description": "Delta is the candidate result minus the current one, negative values are savings",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/shipping.SimulationResult"
+                        }
+                    ]
+                },
+                "orders": {
+                    "type": "integer"
+                }
+            }
+        },
+        "shipping.SimulationResult": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "type": "integer"
+                },
+                "overhead": {
+                    "type": "integer"
+                },
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "packs": {
+                    "type": "integer"
+                },
+                "unpriced": {
+                    "type": "integer"
+                }
+            }
+        },
+        "shipping.StatusChange": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/shipping.ShipmentStatus"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKey": {
+            "description": "Static API key, bound to a tenant and a set of scopes",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerToken": {
+            "description": "JWT as \"Bearer \u003ctoken\u003e\", its scope claim lists the scopes granted and its tenant claim the tenant of the caller",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "TenantID": {
+            "description": "Tenant to act for, only used when the credentials are not bound to a tenant and grant the tenants:admin scope",
+            "type": "apiKey",
+            "name": "X-Tenant-ID",
+            "in": "header"
         }
     }
 }`
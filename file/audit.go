@@ -0,0 +1,118 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// NewAuditRepository appends the audit entries to path as lines of JSON, creating it when missing.
+// The entries already in the file are kept and can be queried.
+func NewAuditRepository(path string) (shipping.AuditRepository, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	ar := &auditRepository{
+		file: f,
+	}
+	err = ar.scan(func(shipping.AuditEntry) {
+		ar.lastID++
+	})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return ar, nil
+}
+
+type auditRepository struct {
+	mtx    sync.RWMutex
+	file   *os.File
+	lastID uint64
+}
+
+// auditLine is an entry as stored in the file, along with its tenant
+type auditLine struct {
+	Tenant string `json:"tenant"`
+	shipping.AuditEntry
+}
+
+func (ar *auditRepository) Store(_ context.Context, entry shipping.AuditEntry) (uint64, error) {
+	ar.mtx.Lock()
+	defer ar.mtx.Unlock()
+	entry.ID = ar.lastID + 1
+	line, err := json.Marshal(auditLine{Tenant: entry.Tenant, AuditEntry: entry})
+	if err != nil {
+		return 0, fmt.Errorf("encode audit entry: %w", err)
+	}
+	_, err = ar.file.Write(append(line, '\n'))
+	if err != nil {
+		return 0, fmt.Errorf("write audit entry: %w", err)
+	}
+	ar.lastID = entry.ID
+	return entry.ID, nil
+}
+
+func (ar *auditRepository) Find(_ context.Context, query shipping.AuditQuery) ([]shipping.AuditEntry, error) {
+	ar.mtx.RLock()
+	defer ar.mtx.RUnlock()
+	entries := make([]shipping.AuditEntry, 0)
+	err := ar.scan(func(entry shipping.AuditEntry) {
+		if query.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the file is in chronological order, the most recent entries come first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if query.Limit > 0 && len(entries) > query.Limit {
+		entries = entries[:query.Limit]
+	}
+	return entries, nil
+}
+
+// Close flushes the entries to disk and closes the file, entries cannot be stored afterwards
+func (ar *auditRepository) Close() error {
+	ar.mtx.Lock()
+	defer ar.mtx.Unlock()
+	err := ar.file.Sync()
+	if err != nil {
+		ar.file.Close()
+		return fmt.Errorf("sync audit log: %w", err)
+	}
+	return ar.file.Close()
+}
+
+// scan reads the entries of the file from the beginning, writes are appended whatever the read offset
+func (ar *auditRepository) scan(fn func(shipping.AuditEntry)) error {
+	f, err := os.Open(ar.file.Name())
+	if err != nil {
+		return fmt.Errorf("read audit log: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var line auditLine
+		err := json.Unmarshal(scanner.Bytes(), &line)
+		if err != nil {
+			return fmt.Errorf("decode audit log %s: %w", ar.file.Name(), err)
+		}
+		line.AuditEntry.Tenant = line.Tenant
+		fn(line.AuditEntry)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read audit log %s: %w", ar.file.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package file_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/file"
+)
+
+func TestAuditRepository(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	repo, err := file.NewAuditRepository(path)
+	require.NoError(t, err)
+	at := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	_, err = repo.Store(context.Background(), shipping.AuditEntry{
+		Tenant:    "tenant-1",
+		Action:    shipping.AuditUpdatePacks,
+		Principal: "user-1",
+		ProductID: 1,
+		After:     []uint64{250},
+		Success:   true,
+		At:        at,
+	})
+	require.NoError(t, err)
+	_, err = repo.Store(context.Background(), shipping.AuditEntry{
+		Tenant:    "tenant-2",
+		Action:    shipping.AuditUpdateDefaultPacks,
+		Principal: "user-2",
+		Error:     "forbidden",
+		At:        at,
+	})
+	require.NoError(t, err)
+	require.NoError(t, repo.(io.Closer).Close())
+	_, err = repo.Store(context.Background(), shipping.AuditEntry{Tenant: "tenant-1"})
+	require.Error(t, err, "entries cannot be stored once closed")
+
+	// entries survive a restart and new ones keep increasing IDs
+	repo, err = file.NewAuditRepository(path)
+	require.NoError(t, err)
+	id, err := repo.Store(context.Background(), shipping.AuditEntry{
+		Tenant:    "tenant-1",
+		Action:    shipping.AuditUpdatePacks,
+		Principal: "user-1",
+		ProductID: 2,
+		Before:    []uint64{250},
+		After:     []uint64{500},
+		Success:   true,
+		At:        at.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), id)
+
+	entries, err := repo.Find(context.Background(), shipping.AuditQuery{Tenant: "tenant-1"})
+	require.NoError(t, err)
+	require.Equal(t, []shipping.AuditEntry{
+		{
+			ID:        3,
+			Tenant:    "tenant-1",
+			Action:    shipping.AuditUpdatePacks,
+			Principal: "user-1",
+			ProductID: 2,
+			Before:    []uint64{250},
+			After:     []uint64{500},
+			Success:   true,
+			At:        at.Add(time.Hour),
+		},
+		{
+			ID:        1,
+			Tenant:    "tenant-1",
+			Action:    shipping.AuditUpdatePacks,
+			Principal: "user-1",
+			ProductID: 1,
+			After:     []uint64{250},
+			Success:   true,
+			At:        at,
+		},
+	}, entries)
+
+	entries, err = repo.Find(context.Background(), shipping.AuditQuery{Tenant: "tenant-1", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, uint64(3), entries[0].ID)
+	require.NoError(t, repo.(io.Closer).Close())
+
+	require.NoError(t, os.WriteFile(path, []byte("not json\n"), 0o600))
+	_, err = file.NewAuditRepository(path)
+	require.Error(t, err)
+}
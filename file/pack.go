@@ -0,0 +1,229 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// NewPackRepository keeps the pack configurations of every tenant in the JSON file at path, creating it on
+// the first update. Every update rewrites the whole file, so it suits the small number of configurations
+// a deployment has. Tenants that did not configure default pack sizes get defaultConfig.
+func NewPackRepository(path string, defaultConfig []uint64) (shipping.PackRepository, error) {
+	pr := &packRepository{
+		path:          path,
+		defaultConfig: defaultConfig,
+		tenants:       make(map[string]*tenantConfigs),
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return pr, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read pack configurations: %w", err)
+	}
+	if err := json.Unmarshal(b, &pr.tenants); err != nil {
+		return nil, fmt.Errorf("decode pack configurations %s: %w", path, err)
+	}
+	// a null file or tenant holds no configuration
+	if pr.tenants == nil {
+		pr.tenants = make(map[string]*tenantConfigs)
+	}
+	for tenant, tc := range pr.tenants {
+		if tc == nil {
+			delete(pr.tenants, tenant)
+		}
+	}
+	return pr, nil
+}
+
+// tenantConfigs are the configurations of a tenant as stored in the file, JSON objects are keyed by strings
+// so the products are keyed by their decimal ID
+type tenantConfigs struct {
+	Default    []uint64                       `json:"default,omitempty"`
+	Products   map[string][]uint64            `json:"products,omitempty"`
+	Warehouses map[string]map[string][]uint64 `json:"warehouses,omitempty"`
+}
+
+type packRepository struct {
+	mtx           sync.RWMutex
+	path          string
+	defaultConfig []uint64
+	tenants       map[string]*tenantConfigs
+}
+
+func (pr *packRepository) GetByProductID(ctx context.Context, productID uint64) ([]uint64, error) {
+	pr.mtx.RLock()
+	defer pr.mtx.RUnlock()
+	tc, err := pr.tenantConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config, ok := tc.Products[productKey(productID)]
+	if !ok {
+		return nil, shipping.ErrNotFound
+	}
+	return config, nil
+}
+
+func (pr *packRepository) GetByWarehouse(ctx context.Context, warehouse string, productID uint64) ([]uint64, error) {
+	pr.mtx.RLock()
+	defer pr.mtx.RUnlock()
+	tc, err := pr.tenantConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config, ok := tc.Warehouses[warehouse][productKey(productID)]
+	if !ok {
+		return nil, shipping.ErrNotFound
+	}
+	return config, nil
+}
+
+func (pr *packRepository) GetDefault(ctx context.Context) ([]uint64, error) {
+	pr.mtx.RLock()
+	defer pr.mtx.RUnlock()
+	tc, err := pr.tenantConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tc.Default == nil {
+		return pr.defaultConfig, nil
+	}
+	return tc.Default, nil
+}
+
+func (pr *packRepository) UpdateConfig(ctx context.Context, productID uint64, config []uint64) ([]uint64, error) {
+	return pr.update(ctx, func(tc *tenantConfigs) []uint64 {
+		if tc.Products == nil {
+			tc.Products = make(map[string][]uint64)
+		}
+		previous := tc.Products[productKey(productID)]
+		tc.Products[productKey(productID)] = config
+		return previous
+	})
+}
+
+func (pr *packRepository) UpdateWarehouseConfig(ctx context.Context, warehouse string, productID uint64, config []uint64) ([]uint64, error) {
+	return pr.update(ctx, func(tc *tenantConfigs) []uint64 {
+		if tc.Warehouses == nil {
+			tc.Warehouses = make(map[string]map[string][]uint64)
+		}
+		if tc.Warehouses[warehouse] == nil {
+			tc.Warehouses[warehouse] = make(map[string][]uint64)
+		}
+		previous := tc.Warehouses[warehouse][productKey(productID)]
+		tc.Warehouses[warehouse][productKey(productID)] = config
+		return previous
+	})
+}
+
+func (pr *packRepository) UpdateDefault(ctx context.Context, config []uint64) ([]uint64, error) {
+	return pr.update(ctx, func(tc *tenantConfigs) []uint64 {
+		previous := tc.Default
+		if previous == nil {
+			previous = pr.defaultConfig
+		}
+		tc.Default = config
+		return previous
+	})
+}
+
+// tenantConfigs returns the configurations of the tenant ctx is scoped to, callers must hold the read lock
+func (pr *packRepository) tenantConfigs(ctx context.Context) (*tenantConfigs, error) {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return nil, shipping.ErrMissingTenant
+	}
+	tc, ok := pr.tenants[tenant]
+	if !ok {
+		return &tenantConfigs{}, nil
+	}
+	return tc, nil
+}
+
+// update applies the change to a copy of the configurations of the tenant ctx is scoped to and keeps it
+// only once the file is written, so failed writes leave the repository unchanged. It returns the
+// configuration the change replaced.
+func (pr *packRepository) update(ctx context.Context, change func(tc *tenantConfigs) []uint64) ([]uint64, error) {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return nil, shipping.ErrMissingTenant
+	}
+	pr.mtx.Lock()
+	defer pr.mtx.Unlock()
+	tc := &tenantConfigs{}
+	if current, ok := pr.tenants[tenant]; ok {
+		tc = current.clone()
+	}
+	previous := change(tc)
+	tenants := make(map[string]*tenantConfigs, len(pr.tenants)+1)
+	for t, c := range pr.tenants {
+		tenants[t] = c
+	}
+	tenants[tenant] = tc
+	if err := pr.write(tenants); err != nil {
+		return nil, err
+	}
+	pr.tenants = tenants
+	return previous, nil
+}
+
+// write replaces the file with the configurations, through a temporary file so readers never see it partially written
+func (pr *packRepository) write(tenants map[string]*tenantConfigs) error {
+	b, err := json.MarshalIndent(tenants, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode pack configurations: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(pr.path), filepath.Base(pr.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create pack configurations: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write pack configurations: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync pack configurations: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close pack configurations: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), pr.path); err != nil {
+		return fmt.Errorf("replace pack configurations: %w", err)
+	}
+	return nil
+}
+
+func (tc *tenantConfigs) clone() *tenantConfigs {
+	c := &tenantConfigs{
+		Default:  tc.Default,
+		Products: make(map[string][]uint64, len(tc.Products)),
+	}
+	for id, config := range tc.Products {
+		c.Products[id] = config
+	}
+	if tc.Warehouses != nil {
+		c.Warehouses = make(map[string]map[string][]uint64, len(tc.Warehouses))
+		for warehouse, configs := range tc.Warehouses {
+			c.Warehouses[warehouse] = make(map[string][]uint64, len(configs))
+			for id, config := range configs {
+				c.Warehouses[warehouse][id] = config
+			}
+		}
+	}
+	return c
+}
+
+func productKey(productID uint64) string {
+	return strconv.FormatUint(productID, 10)
+}
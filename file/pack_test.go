@@ -0,0 +1,104 @@
+package file_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/file"
+)
+
+func TestPackRepository(t *testing.T) {
+	ctx := context.Background()
+	tenantA := shipping.WithTenant(ctx, "tenant-a")
+	tenantB := shipping.WithTenant(ctx, "tenant-b")
+	path := filepath.Join(t.TempDir(), "packs.json")
+	repo, err := file.NewPackRepository(path, []uint64{250, 500})
+	require.NoError(t, err)
+
+	_, err = repo.GetByProductID(tenantA, 1)
+	require.ErrorIs(t, err, shipping.ErrNotFound)
+	previous, err := repo.UpdateConfig(tenantA, 1, []uint64{100, 200})
+	require.NoError(t, err)
+	require.Nil(t, previous)
+	_, err = repo.UpdateWarehouseConfig(tenantA, "w1", 1, []uint64{300})
+	require.NoError(t, err)
+	previous, err = repo.UpdateDefault(tenantA, []uint64{42})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{250, 500}, previous)
+
+	// configurations survive a restart, tenants without their own default get the configured one
+	repo, err = file.NewPackRepository(path, []uint64{1000})
+	require.NoError(t, err)
+	config, err := repo.GetByProductID(tenantA, 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{100, 200}, config)
+	config, err = repo.GetByWarehouse(tenantA, "w1", 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{300}, config)
+	config, err = repo.GetDefault(tenantA)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, config)
+
+	_, err = repo.GetByProductID(tenantB, 1)
+	require.ErrorIs(t, err, shipping.ErrNotFound, "product configured by another tenant must not be visible")
+	_, err = repo.GetByWarehouse(tenantB, "w1", 1)
+	require.ErrorIs(t, err, shipping.ErrNotFound, "warehouse configured by another tenant must not be visible")
+	config, err = repo.GetDefault(tenantB)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1000}, config)
+
+	_, err = repo.GetDefault(ctx)
+	require.ErrorIs(t, err, shipping.ErrMissingTenant)
+	_, err = repo.UpdateConfig(ctx, 1, []uint64{100})
+	require.ErrorIs(t, err, shipping.ErrMissingTenant)
+}
+
+func TestPackRepository_Errors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "packs.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+	_, err := file.NewPackRepository(path, nil)
+	require.ErrorContains(t, err, "decode pack configurations")
+
+	// failed writes leave the configurations unchanged
+	repo, err := file.NewPackRepository(filepath.Join(dir, "missing", "packs.json"), nil)
+	require.NoError(t, err)
+	tenantCtx := shipping.WithTenant(context.Background(), "tenant-a")
+	_, err = repo.UpdateConfig(tenantCtx, 1, []uint64{100})
+	require.ErrorContains(t, err, "create pack configurations")
+	_, err = repo.GetByProductID(tenantCtx, 1)
+	require.ErrorIs(t, err, shipping.ErrNotFound)
+}
+
+func TestPackRepository_NullEntries(t *testing.T) {
+	tests := map[string]string{
+		"nullFile":   `null`,
+		"nullTenant": `{"tenant-a": null, "tenant-b": {"default": [42]}}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "packs.json")
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+			repo, err := file.NewPackRepository(path, []uint64{250})
+			require.NoError(t, err)
+
+			// null entries are loaded as tenants without configurations
+			tenantCtx := shipping.WithTenant(context.Background(), "tenant-a")
+			_, err = repo.GetByProductID(tenantCtx, 1)
+			require.ErrorIs(t, err, shipping.ErrNotFound)
+			config, err := repo.GetDefault(tenantCtx)
+			require.NoError(t, err)
+			require.Equal(t, []uint64{250}, config)
+			_, err = repo.UpdateConfig(tenantCtx, 1, []uint64{100})
+			require.NoError(t, err)
+			config, err = repo.GetByProductID(tenantCtx, 1)
+			require.NoError(t, err)
+			require.Equal(t, []uint64{100}, config)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// anyMatch matches every principal, role, category or action in a rule, as a role it matches the principals
+// holding at least one role
+const anyMatch = "*"
+
+// policyFile assigns the products of every tenant to categories and lists the rules granting actions on them:
+//
+//	{
+//		"categories": [{"tenant": "tenant-1", "name": "fragile", "products": [1, 2]}],
+//		"rules": [
+//			{"roles": ["fragile-goods"], "categories": ["fragile"], "actions": ["read", "write"]},
+//			{"principals": ["*"], "categories": ["*"], "actions": ["read"]}
+//		]
+//	}
+type policyFile struct {
+	Categories []category `json:"categories"`
+	Rules      []rule     `json:"rules"`
+}
+
+type category struct {
+	Tenant   string   `json:"tenant"`
+	Name     string   `json:"name"`
+	Products []uint64 `json:"products"`
+}
+
+// rule grants the actions on the products of the categories to the principals and to the members of the roles.
+// Uncategorised products and the default configuration are only matched by the "*" category.
+type rule struct {
+	Principals []string          `json:"principals"`
+	Roles      []string          `json:"roles"`
+	Categories []string          `json:"categories"`
+	Actions    []shipping.Action `json:"actions"`
+}
+
+// NewPolicy loads the categories and rules of the policy file. Actions not granted by any rule are forbidden.
+func NewPolicy(path string) (shipping.Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+	var f policyFile
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return nil, fmt.Errorf("decode policy %s: %w", path, err)
+	}
+	p := &policy{
+		categories: make(map[string]map[uint64]string),
+		rules:      f.Rules,
+	}
+	for _, c := range f.Categories {
+		if c.Tenant == "" || c.Name == "" || c.Name == anyMatch {
+			return nil, fmt.Errorf("invalid policy %s: category %q of tenant %q needs a tenant and a name", path, c.Name, c.Tenant)
+		}
+		products, ok := p.categories[c.Tenant]
+		if !ok {
+			products = make(map[uint64]string)
+			p.categories[c.Tenant] = products
+		}
+		for _, id := range c.Products {
+			if other, ok := products[id]; ok && other != c.Name {
+				return nil, fmt.Errorf("invalid policy %s: product %d of tenant %q is in categories %s and %s", path, id, c.Tenant, other, c.Name)
+			}
+			products[id] = c.Name
+		}
+	}
+	for i, r := range f.Rules {
+		if len(r.Principals) == 0 && len(r.Roles) == 0 {
+			return nil, fmt.Errorf("invalid policy %s: rule %d has neither principals nor roles", path, i)
+		}
+		for _, action := range r.Actions {
+			if action != shipping.ActionRead && action != shipping.ActionWrite && action != anyMatch {
+				return nil, fmt.Errorf("invalid policy %s: rule %d has unknown action %q", path, i, action)
+			}
+		}
+	}
+	return p, nil
+}
+
+type policy struct {
+	// categories holds the category of every categorised product, per tenant
+	categories map[string]map[uint64]string
+	rules      []rule
+}
+
+func (p *policy) AuthorizeProduct(ctx context.Context, action shipping.Action, productID uint64) error {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return shipping.ErrMissingTenant
+	}
+	return p.authorize(ctx, action, p.categories[tenant][productID])
+}
+
+func (p *policy) AuthorizeDefault(ctx context.Context, action shipping.Action) error {
+	if _, ok := shipping.TenantFromContext(ctx); !ok {
+		return shipping.ErrMissingTenant
+	}
+	return p.authorize(ctx, action, "")
+}
+
+// authorize looks for a rule granting the action on the category to the principal of ctx, the empty
+// category being the one of uncategorised products and of the default configuration
+func (p *policy) authorize(ctx context.Context, action shipping.Action, category string) error {
+	principal, ok := shipping.PrincipalFromContext(ctx)
+	if !ok {
+		principal.ID = "unknown"
+	}
+	for _, r := range p.rules {
+		if r.matchesPrincipal(principal) && r.matchesCategory(category) && r.matchesAction(action) {
+			return nil
+		}
+	}
+	return &shipping.ForbiddenError{
+		Principal: principal.ID,
+		Action:    action,
+		Category:  category,
+	}
+}
+
+func (r rule) matchesPrincipal(principal shipping.Principal) bool {
+	for _, id := range r.Principals {
+		if id == anyMatch || id == principal.ID {
+			return true
+		}
+	}
+	for _, role := range r.Roles {
+		for _, principalRole := range principal.Roles {
+			if role == anyMatch || role == principalRole {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r rule) matchesCategory(category string) bool {
+	for _, c := range r.Categories {
+		if c == anyMatch || (category != "" && c == category) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r rule) matchesAction(action shipping.Action) bool {
+	for _, a := range r.Actions {
+		if a == anyMatch || a == action {
+			return true
+		}
+	}
+	return false
+}
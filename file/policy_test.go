@@ -0,0 +1,163 @@
+package file_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/file"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestNewPolicy_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"malformed":             `{"rules": [`,
+		"categoryWithoutTenant": `{"categories": [{"name": "fragile", "products": [1]}]}`,
+		"productInTwoCategories": `{"categories": [
+			{"tenant": "tenant-1", "name": "fragile", "products": [1]},
+			{"tenant": "tenant-1", "name": "bulk", "products": [1]}
+		]}`,
+		"ruleWithoutSubject": `{"rules": [{"categories": ["*"], "actions": ["read"]}]}`,
+		"unknownAction":      `{"rules": [{"principals": ["*"], "categories": ["*"], "actions": ["delete"]}]}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := file.NewPolicy(writePolicy(t, content))
+			require.Error(t, err)
+		})
+	}
+	_, err := file.NewPolicy(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+func TestPolicy_AuthorizeProduct(t *testing.T) {
+	policy, err := file.NewPolicy(writePolicy(t, `{
+		"categories": [
+			{"tenant": "tenant-1", "name": "fragile", "products": [1, 2]},
+			{"tenant": "tenant-2", "name": "bulk", "products": [1]}
+		],
+		"rules": [
+			{"roles": ["fragile-goods"], "categories": ["fragile"], "actions": ["read", "write"]},
+			{"principals": ["admin"], "categories": ["*"], "actions": ["*"]},
+			{"principals": ["*"], "categories": ["*"], "actions": ["read"]}
+		]
+	}`))
+	require.NoError(t, err)
+
+	ctx := func(tenant string, p shipping.Principal) context.Context {
+		return shipping.WithPrincipal(shipping.WithTenant(context.Background(), tenant), p)
+	}
+	fragileTeam := shipping.Principal{ID: "user-1", Roles: []string{"fragile-goods"}}
+	other := shipping.Principal{ID: "user-2", Roles: []string{"bulk-goods"}}
+	admin := shipping.Principal{ID: "admin"}
+
+	tests := map[string]struct {
+		ctx         context.Context
+		action      shipping.Action
+		productID   uint64
+		expectedErr error
+	}{
+		"roleGrantedCategory_allowed": {
+			ctx:       ctx("tenant-1", fragileTeam),
+			action:    shipping.ActionWrite,
+			productID: 2,
+		},
+		"roleNotGrantedUncategorised_forbidden": {
+			ctx:       ctx("tenant-1", fragileTeam),
+			action:    shipping.ActionWrite,
+			productID: 3,
+			expectedErr: &shipping.ForbiddenError{
+				Principal: "user-1",
+				Action:    shipping.ActionWrite,
+			},
+		},
+		"categoryOfOtherTenant_forbidden": {
+			ctx:       ctx("tenant-2", fragileTeam),
+			action:    shipping.ActionWrite,
+			productID: 1,
+			expectedErr: &shipping.ForbiddenError{
+				Principal: "user-1",
+				Action:    shipping.ActionWrite,
+				Category:  "bulk",
+			},
+		},
+		"otherRole_forbidden": {
+			ctx:       ctx("tenant-1", other),
+			action:    shipping.ActionWrite,
+			productID: 1,
+			expectedErr: &shipping.ForbiddenError{
+				Principal: "user-2",
+				Action:    shipping.ActionWrite,
+				Category:  "fragile",
+			},
+		},
+		"anyPrincipalRead_allowed": {
+			ctx:       ctx("tenant-1", other),
+			action:    shipping.ActionRead,
+			productID: 1,
+		},
+		"anyCategoryAnyAction_allowed": {
+			ctx:       ctx("tenant-2", admin),
+			action:    shipping.ActionWrite,
+			productID: 1,
+		},
+		"tenantMissing_returnErrMissingTenant": {
+			ctx:         shipping.WithPrincipal(context.Background(), admin),
+			action:      shipping.ActionRead,
+			productID:   1,
+			expectedErr: shipping.ErrMissingTenant,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := policy.AuthorizeProduct(tc.ctx, tc.action, tc.productID)
+			require.Equal(t, tc.expectedErr, err)
+		})
+	}
+
+	require.NoError(t, policy.AuthorizeDefault(ctx("tenant-1", admin), shipping.ActionWrite))
+	err = policy.AuthorizeDefault(ctx("tenant-1", fragileTeam), shipping.ActionWrite)
+	require.ErrorIs(t, err, shipping.ErrForbidden)
+	require.EqualError(t, err, "forbidden: user-1 may not write uncategorised products")
+}
+
+func TestPolicy_AnyRole(t *testing.T) {
+	policy, err := file.NewPolicy(writePolicy(t, `{
+		"categories": [{"tenant": "tenant-1", "name": "fragile", "products": [1]}],
+		"rules": [{"roles": ["*"], "categories": ["fragile"], "actions": ["write"]}]
+	}`))
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		principal   shipping.Principal
+		expectedErr error
+	}{
+		"principalWithRole_allowed": {
+			principal: shipping.Principal{ID: "user-1", Roles: []string{"bulk-goods"}},
+		},
+		"principalWithoutRole_forbidden": {
+			principal: shipping.Principal{ID: "user-2"},
+			expectedErr: &shipping.ForbiddenError{
+				Principal: "user-2",
+				Action:    shipping.ActionWrite,
+				Category:  "fragile",
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := shipping.WithPrincipal(shipping.WithTenant(context.Background(), "tenant-1"), tc.principal)
+			err := policy.AuthorizeProduct(ctx, shipping.ActionWrite, 1)
+			require.Equal(t, tc.expectedErr, err)
+		})
+	}
+}
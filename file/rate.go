@@ -0,0 +1,61 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// NewRateRepository loads every *.json rate table found in dir. A missing
+// directory results in an empty repository.
+func NewRateRepository(dir string) (shipping.RateRepository, error) {
+	rr := &rateRepository{
+		tables: make(map[string]shipping.RateTable),
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("list rate tables: %w", err)
+	}
+	for _, path := range paths {
+		table, err := readRateTable(path)
+		if err != nil {
+			return nil, err
+		}
+		rr.tables[strings.ToLower(table.Carrier)] = table
+	}
+	return rr, nil
+}
+
+type rateRepository struct {
+	tables map[string]shipping.RateTable
+}
+
+func (rr *rateRepository) GetByCarrier(_ context.Context, carrier string) (shipping.RateTable, error) {
+	table, ok := rr.tables[strings.ToLower(carrier)]
+	if !ok {
+		return shipping.RateTable{}, shipping.ErrNotFound
+	}
+	return table, nil
+}
+
+func readRateTable(path string) (shipping.RateTable, error) {
+	var table shipping.RateTable
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return table, fmt.Errorf("read rate table %s: %w", path, err)
+	}
+	err = json.Unmarshal(data, &table)
+	if err != nil {
+		return table, fmt.Errorf("decode rate table %s: %w", path, err)
+	}
+	if table.Carrier == "" {
+		// fall back to the file name when the carrier is not specified
+		table.Carrier = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return table, nil
+}
@@ -0,0 +1,98 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/auth"
+	"github.com/silvan-talos/shipping/grpc/pb"
+)
+
+// apiKeyKey is the metadata counterpart of the X-API-Key header of the http server
+const apiKeyKey = "x-api-key"
+
+// methodScopes holds the scope required by every method of the API, methods missing from it are rejected
+var methodScopes = map[string]string{
+	pb.PackagingService_CalculatePacks_FullMethodName:      auth.ScopePackagingRead,
+	pb.PackagingService_UpdatePacks_FullMethodName:         auth.ScopePackagingWrite,
+	pb.PackagingService_GetConfig_FullMethodName:           auth.ScopePackagingRead,
+	pb.PackagingService_BatchCalculatePacks_FullMethodName: auth.ScopePackagingRead,
+}
+
+func authenticateUnary(a *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := withPrincipal(ctx, a, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func authenticateStream(a *auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := withPrincipal(ss.Context(), a, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// withPrincipal authenticates the call from its bearer token or API key and checks that the principal
+// was granted the scope of the method. Without an authenticator every call is made by the anonymous principal.
+func withPrincipal(ctx context.Context, a *auth.Authenticator, method string) (context.Context, error) {
+	principal := auth.Anonymous
+	if a != nil {
+		md, _ := metadata.FromIncomingContext(ctx)
+		var err error
+		if token, ok := strings.CutPrefix(first(md, "authorization"), "Bearer "); ok {
+			principal, err = a.AuthenticateToken(token)
+		} else if key := first(md, apiKeyKey); key != "" {
+			principal, err = a.AuthenticateAPIKey(key)
+		} else {
+			err = auth.ErrUnauthenticated
+		}
+		if err != nil {
+			if !errors.Is(err, auth.ErrUnauthenticated) {
+				slog.ErrorContext(ctx, "failed to authenticate call", "err", err)
+			}
+			return nil, status.Error(codes.Unauthenticated, "invalid or missing credentials")
+		}
+	}
+	scope, ok := methodScopes[method]
+	if !ok || !principal.HasScope(scope) {
+		return nil, status.Error(codes.PermissionDenied, "missing scope "+scope)
+	}
+	return shipping.WithClient(shipping.WithPrincipal(ctx, principal), describeClient(ctx)), nil
+}
+
+// describeClient returns the address and user agent of the caller, for auditing
+func describeClient(ctx context.Context) string {
+	var client string
+	if p, ok := peer.FromContext(ctx); ok {
+		client = p.Addr.String()
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ua := first(md, "user-agent"); ua != "" {
+		client += " " + ua
+	}
+	return client
+}
+
+func first(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
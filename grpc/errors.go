@@ -0,0 +1,27 @@
+package grpc
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// toStatus maps domain errors to status codes the same way the http server maps them to status codes:
+// internal errors are hidden, missing resources are reported as not found, forbidden actions as denied and the rest are invalid requests.
+func toStatus(err error) *status.Status {
+	switch {
+	case errors.Is(err, shipping.InternalServerErr):
+		return status.New(codes.Internal, "internal error occurred")
+	case errors.Is(err, shipping.ErrNotFound):
+		return status.New(codes.NotFound, "no configuration found for the specified product")
+	case errors.Is(err, shipping.ErrMissingTenant):
+		return status.New(codes.Unauthenticated, "tenant missing")
+	case errors.Is(err, shipping.ErrForbidden):
+		return status.New(codes.PermissionDenied, err.Error())
+	default:
+		return status.New(codes.InvalidArgument, err.Error())
+	}
+}
@@ -0,0 +1,184 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/grpc/pb"
+	"github.com/silvan-talos/shipping/internal/idempotency"
+)
+
+const (
+	// idempotencyKeyKey and replayedKey are the metadata counterparts of the Idempotency-Key and
+	// Idempotent-Replayed headers of the http server
+	idempotencyKeyKey = "idempotency-key"
+	replayedKey       = "idempotent-replayed"
+	// maxIdempotencyKey bounds the length of the keys clients may send
+	maxIdempotencyKey = 255
+	// inProgressTTL bounds how long a key stays reserved by a call that never completes, e.g. after a crash.
+	// Calls still processed extend it every half of it.
+	inProgressTTL = time.Minute
+)
+
+// idempotentMethods create the responses of the methods writing through the product service, the ones
+// replayed to the retries of the calls made with an idempotency key
+var idempotentMethods = map[string]func() proto.Message{
+	pb.PackagingService_UpdatePacks_FullMethodName: func() proto.Message { return &pb.UpdatePacksResponse{} },
+}
+
+type IdempotencyArgs struct {
+	Store shipping.IdempotencyStore `validate:"required"`
+	// TTL is how long the responses are replayed for, defaults to 24h
+	TTL time.Duration `validate:"gte=0"`
+	// InProgressTTL is how long a key stays reserved once its call stops extending it, defaults to 1m
+	InProgressTTL time.Duration `validate:"gte=0"`
+}
+
+// makeIdempotentUnary replays the outcome of the first write call made with an idempotency-key metadata to the
+// retries of the same call, so it is processed only once, like the http server does for the Idempotency-Key
+// header. Reusing a key for a different call is rejected with InvalidArgument, retrying while the first call is
+// still processed with Aborted. Server errors and panics are not recorded, so the call can be retried. Calls are
+// rejected with Unavailable while the store is full of calls in progress.
+func makeIdempotentUnary(args *IdempotencyArgs) grpc.UnaryServerInterceptor {
+	ttl, reservedTTL := 24*time.Hour, inProgressTTL
+	if args != nil && args.TTL > 0 {
+		ttl = args.TTL
+	}
+	if args != nil && args.InProgressTTL > 0 {
+		reservedTTL = args.InProgressTTL
+	}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		newResponse, ok := idempotentMethods[info.FullMethod]
+		md, _ := metadata.FromIncomingContext(ctx)
+		key := first(md, idempotencyKeyKey)
+		if args == nil || !ok || key == "" {
+			return handler(ctx, req)
+		}
+		if len(key) > maxIdempotencyKey {
+			return nil, status.Error(codes.InvalidArgument, "idempotency key too long")
+		}
+		fingerprint, err := fingerprintCall(info.FullMethod, req)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid request")
+		}
+		key = idempotencyScope(ctx) + " " + key
+
+		record, reserved, err := args.Store.Reserve(ctx, key, fingerprint, reservedTTL)
+		if errors.Is(err, shipping.ErrIdempotencyStoreFull) {
+			slog.WarnContext(ctx, "idempotency store full, rejecting call", "err", err)
+			return nil, status.Error(codes.Unavailable, "too many requests in progress, retry later")
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to reserve idempotency key", "err", err)
+			return nil, status.Error(codes.Internal, "internal error occurred")
+		}
+		if !reserved {
+			switch {
+			case record.Fingerprint != fingerprint:
+				return nil, status.Error(codes.InvalidArgument, "idempotency key already used for a different request")
+			case !record.Completed:
+				return nil, status.Error(codes.Aborted, "a request with this idempotency key is in progress")
+			default:
+				return replayCall(ctx, record, newResponse())
+			}
+		}
+
+		// the outcome is stored even when the client gave up waiting for it
+		storeCtx := context.WithoutCancel(ctx)
+		completed := false
+		stopExtending := idempotency.ExtendReservation(storeCtx, args.Store, key, reservedTTL)
+		defer func() {
+			stopExtending()
+			// a panicking handler must not leave the key reserved
+			if completed {
+				return
+			}
+			if err := args.Store.Release(storeCtx, key); err != nil {
+				slog.ErrorContext(ctx, "failed to release idempotency key", "err", err)
+			}
+		}()
+
+		resp, err := handler(ctx, req)
+		st := status.Convert(err)
+		if serverError(st.Code()) {
+			return resp, err
+		}
+		body := []byte(st.Message())
+		if err == nil {
+			var encodeErr error
+			body, encodeErr = proto.Marshal(resp.(proto.Message))
+			if encodeErr != nil {
+				slog.ErrorContext(ctx, "failed to encode idempotent response", "err", encodeErr)
+				return resp, nil
+			}
+		}
+		completed = true
+		record = shipping.IdempotentRecord{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			Status:      int(st.Code()),
+			Body:        body,
+		}
+		if err := args.Store.Complete(storeCtx, key, record, ttl); err != nil {
+			slog.ErrorContext(ctx, "failed to record idempotent response", "err", err)
+		}
+		return resp, err
+	}
+}
+
+// replayCall returns the recorded outcome, the response decoded into resp or the error
+func replayCall(ctx context.Context, record shipping.IdempotentRecord, resp proto.Message) (any, error) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(replayedKey, "true"))
+	if code := codes.Code(record.Status); code != codes.OK {
+		return nil, status.Error(code, string(record.Body))
+	}
+	if err := proto.Unmarshal(record.Body, resp); err != nil {
+		slog.ErrorContext(ctx, "failed to decode idempotent response", "err", err)
+		return nil, status.Error(codes.Internal, "internal error occurred")
+	}
+	return resp, nil
+}
+
+// idempotencyScope keeps the keys of tenants and principals apart, and apart from the ones of the http server
+func idempotencyScope(ctx context.Context) string {
+	tenant, _ := shipping.TenantFromContext(ctx)
+	principal, _ := shipping.PrincipalFromContext(ctx)
+	return "grpc " + tenant + " " + principal.ID
+}
+
+// fingerprintCall hashes the method and request of the call
+func fingerprintCall(method string, req any) (string, error) {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return "", errors.New("request is not a protocol buffers message")
+	}
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(method + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// serverError tells whether the call failed on the server side, so that it may succeed once retried
+func serverError(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss, codes.DeadlineExceeded, codes.Canceled:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,36 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// requestIDKey is the metadata counterpart of the X-Request-ID header of the http server
+const requestIDKey = "x-request-id"
+
+func identifyCallUnary(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, id := withRequestID(ctx)
+	// the header is only missing when the call already ended, nothing is left to correlate then
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+	return handler(ctx, req)
+}
+
+func identifyCallStream(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, id := withRequestID(ss.Context())
+	_ = ss.SetHeader(metadata.Pairs(requestIDKey, id))
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// withRequestID scopes ctx to the ID of the x-request-id metadata, or to a new one when it is missing or invalid
+func withRequestID(ctx context.Context) (context.Context, string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := first(md, requestIDKey)
+	if !shipping.ValidRequestID(id) {
+		id = shipping.NewRequestID()
+	}
+	return shipping.WithRequestID(ctx, id), id
+}
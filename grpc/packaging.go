@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/grpc/pb"
+	"github.com/silvan-talos/shipping/product"
+)
+
+type packagingHandler struct {
+	pb.UnimplementedPackagingServiceServer
+	ps product.Service
+}
+
+func (ph *packagingHandler) CalculatePacks(ctx context.Context, req *pb.CalculatePacksRequest) (*pb.CalculatePacksResponse, error) {
+	resp, err := ph.calculatePacks(ctx, req)
+	if err != nil {
+		return nil, toStatus(err).Err()
+	}
+	return resp, nil
+}
+
+func (ph *packagingHandler) UpdatePacks(ctx context.Context, req *pb.UpdatePacksRequest) (*pb.UpdatePacksResponse, error) {
+	var err error
+	if req.GetWarehouse() != "" {
+		err = ph.ps.UpdateWarehousePacksConfiguration(ctx, req.GetWarehouse(), req.GetProductId(), req.GetPackSizes())
+	} else {
+		err = ph.ps.UpdatePacksConfiguration(ctx, req.GetProductId(), req.GetPackSizes())
+	}
+	if err != nil {
+		return nil, toStatus(err).Err()
+	}
+	return &pb.UpdatePacksResponse{}, nil
+}
+
+func (ph *packagingHandler) GetConfig(ctx context.Context, req *pb.GetConfigRequest) (*pb.GetConfigResponse, error) {
+	packSizes, source, err := ph.ps.GetPacksConfiguration(ctx, req.GetProductId(), req.GetWarehouse())
+	if err != nil {
+		return nil, toStatus(err).Err()
+	}
+	return &pb.GetConfigResponse{
+		Source:    string(source),
+		PackSizes: packSizes,
+	}, nil
+}
+
+func (ph *packagingHandler) BatchCalculatePacks(stream pb.PackagingService_BatchCalculatePacksServer) error {
+	for index := uint32(0); ; index++ {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		res := &pb.BatchCalculatePacksResponse{
+			Index: index,
+		}
+		packing, err := ph.calculatePacks(stream.Context(), req)
+		if err != nil {
+			st := toStatus(err)
+			res.Result = &pb.BatchCalculatePacksResponse_Error{
+				Error: &pb.Error{
+					Code:    int32(st.Code()),
+					Message: st.Message(),
+				},
+			}
+		} else {
+			res.Result = &pb.BatchCalculatePacksResponse_Packing{
+				Packing: packing,
+			}
+		}
+		if err := stream.Send(res); err != nil {
+			return err
+		}
+	}
+}
+
+func (ph *packagingHandler) calculatePacks(ctx context.Context, req *pb.CalculatePacksRequest) (*pb.CalculatePacksResponse, error) {
+	packing, err := ph.ps.CalculatePacking(ctx, req.GetProductId(), req.GetQuantity(), req.GetWarehouse())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CalculatePacksResponse{
+		Source:   string(packing.Source),
+		Packs:    toPbPacks(packing.Packs),
+		Overhead: packing.Overhead,
+	}, nil
+}
+
+func toPbPacks(packs []shipping.PackConfig) []*pb.Pack {
+	res := make([]*pb.Pack, 0, len(packs))
+	for _, p := range packs {
+		res = append(res, &pb.Pack{
+			Count: p.Count,
+			Size:  p.Size,
+		})
+	}
+	return res
+}
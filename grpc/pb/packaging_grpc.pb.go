@@ -0,0 +1,263 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: packaging.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	PackagingService_CalculatePacks_FullMethodName      = "/shipping.v1.PackagingService/CalculatePacks"
+	PackagingService_UpdatePacks_FullMethodName         = "/shipping.v1.PackagingService/UpdatePacks"
+	PackagingService_GetConfig_FullMethodName           = "/shipping.v1.PackagingService/GetConfig"
+	PackagingService_BatchCalculatePacks_FullMethodName = "/shipping.v1.PackagingService/BatchCalculatePacks"
+)
+
+// PackagingServiceClient is the client API for PackagingService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PackagingServiceClient interface {
+	// CalculatePacks calculates the packs for the ordered quantity of a product.
+	CalculatePacks(ctx context.Context, in *CalculatePacksRequest, opts ...grpc.CallOption) (*CalculatePacksResponse, error)
+	// UpdatePacks updates the pack sizes of a product, or of the product in a single warehouse when the warehouse is set.
+	UpdatePacks(ctx context.Context, in *UpdatePacksRequest, opts ...grpc.CallOption) (*UpdatePacksResponse, error)
+	// GetConfig returns the pack sizes used for a product and the configuration level they come from.
+	GetConfig(ctx context.Context, in *GetConfigRequest, opts ...grpc.CallOption) (*GetConfigResponse, error)
+	// BatchCalculatePacks calculates the packs of every request on the stream. A failing request does not end
+	// the stream, its error is returned in the matching response instead.
+	BatchCalculatePacks(ctx context.Context, opts ...grpc.CallOption) (PackagingService_BatchCalculatePacksClient, error)
+}
+
+type packagingServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPackagingServiceClient(cc grpc.ClientConnInterface) PackagingServiceClient {
+	return &packagingServiceClient{cc}
+}
+
+func (c *packagingServiceClient) CalculatePacks(ctx context.Context, in *CalculatePacksRequest, opts ...grpc.CallOption) (*CalculatePacksResponse, error) {
+	out := new(CalculatePacksResponse)
+	err := c.cc.Invoke(ctx, PackagingService_CalculatePacks_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packagingServiceClient) UpdatePacks(ctx context.Context, in *UpdatePacksRequest, opts ...grpc.CallOption) (*UpdatePacksResponse, error) {
+	out := new(UpdatePacksResponse)
+	err := c.cc.Invoke(ctx, PackagingService_UpdatePacks_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packagingServiceClient) GetConfig(ctx context.Context, in *GetConfigRequest, opts ...grpc.CallOption) (*GetConfigResponse, error) {
+	out := new(GetConfigResponse)
+	err := c.cc.Invoke(ctx, PackagingService_GetConfig_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packagingServiceClient) BatchCalculatePacks(ctx context.Context, opts ...grpc.CallOption) (PackagingService_BatchCalculatePacksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &PackagingService_ServiceDesc.Streams[0], PackagingService_BatchCalculatePacks_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &packagingServiceBatchCalculatePacksClient{stream}
+	return x, nil
+}
+
+type PackagingService_BatchCalculatePacksClient interface {
+	Send(*CalculatePacksRequest) error
+	Recv() (*BatchCalculatePacksResponse, error)
+	grpc.ClientStream
+}
+
+type packagingServiceBatchCalculatePacksClient struct {
+	grpc.ClientStream
+}
+
+func (x *packagingServiceBatchCalculatePacksClient) Send(m *CalculatePacksRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *packagingServiceBatchCalculatePacksClient) Recv() (*BatchCalculatePacksResponse, error) {
+	m := new(BatchCalculatePacksResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// PackagingServiceServer is the server API for PackagingService service.
+// All implementations must embed UnimplementedPackagingServiceServer
+// for forward compatibility
+type PackagingServiceServer interface {
+	// CalculatePacks calculates the packs for the ordered quantity of a product.
+	CalculatePacks(context.Context, *CalculatePacksRequest) (*CalculatePacksResponse, error)
+	// UpdatePacks updates the pack sizes of a product, or of the product in a single warehouse when the warehouse is set.
+	UpdatePacks(context.Context, *UpdatePacksRequest) (*UpdatePacksResponse, error)
+	// GetConfig returns the pack sizes used for a product and the configuration level they come from.
+	GetConfig(context.Context, *GetConfigRequest) (*GetConfigResponse, error)
+	// BatchCalculatePacks calculates the packs of every request on the stream. A failing request does not end
+	// the stream, its error is returned in the matching response instead.
+	BatchCalculatePacks(PackagingService_BatchCalculatePacksServer) error
+	mustEmbedUnimplementedPackagingServiceServer()
+}
+
+// UnimplementedPackagingServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedPackagingServiceServer struct {
+}
+
+func (UnimplementedPackagingServiceServer) CalculatePacks(context.Context, *CalculatePacksRequest) (*CalculatePacksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CalculatePacks not implemented")
+}
+func (UnimplementedPackagingServiceServer) UpdatePacks(context.Context, *UpdatePacksRequest) (*UpdatePacksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePacks not implemented")
+}
+func (UnimplementedPackagingServiceServer) GetConfig(context.Context, *GetConfigRequest) (*GetConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConfig not implemented")
+}
+func (UnimplementedPackagingServiceServer) BatchCalculatePacks(PackagingService_BatchCalculatePacksServer) error {
+	return status.Errorf(codes.Unimplemented, "method BatchCalculatePacks not implemented")
+}
+func (UnimplementedPackagingServiceServer) mustEmbedUnimplementedPackagingServiceServer() {}
+
+// UnsafePackagingServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PackagingServiceServer will
+// result in compilation errors.
+type UnsafePackagingServiceServer interface {
+	mustEmbedUnimplementedPackagingServiceServer()
+}
+
+func RegisterPackagingServiceServer(s grpc.ServiceRegistrar, srv PackagingServiceServer) {
+	s.RegisterService(&PackagingService_ServiceDesc, srv)
+}
+
+func _PackagingService_CalculatePacks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CalculatePacksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackagingServiceServer).CalculatePacks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PackagingService_CalculatePacks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackagingServiceServer).CalculatePacks(ctx, req.(*CalculatePacksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackagingService_UpdatePacks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePacksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackagingServiceServer).UpdatePacks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PackagingService_UpdatePacks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackagingServiceServer).UpdatePacks(ctx, req.(*UpdatePacksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackagingService_GetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackagingServiceServer).GetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PackagingService_GetConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackagingServiceServer).GetConfig(ctx, req.(*GetConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackagingService_BatchCalculatePacks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PackagingServiceServer).BatchCalculatePacks(&packagingServiceBatchCalculatePacksServer{stream})
+}
+
+type PackagingService_BatchCalculatePacksServer interface {
+	Send(*BatchCalculatePacksResponse) error
+	Recv() (*CalculatePacksRequest, error)
+	grpc.ServerStream
+}
+
+type packagingServiceBatchCalculatePacksServer struct {
+	grpc.ServerStream
+}
+
+func (x *packagingServiceBatchCalculatePacksServer) Send(m *BatchCalculatePacksResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *packagingServiceBatchCalculatePacksServer) Recv() (*CalculatePacksRequest, error) {
+	m := new(CalculatePacksRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// PackagingService_ServiceDesc is the grpc.ServiceDesc for PackagingService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PackagingService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "shipping.v1.PackagingService",
+	HandlerType: (*PackagingServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CalculatePacks",
+			Handler:    _PackagingService_CalculatePacks_Handler,
+		},
+		{
+			MethodName: "UpdatePacks",
+			Handler:    _PackagingService_UpdatePacks_Handler,
+		},
+		{
+			MethodName: "GetConfig",
+			Handler:    _PackagingService_GetConfig_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "BatchCalculatePacks",
+			Handler:       _PackagingService_BatchCalculatePacks_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "packaging.proto",
+}
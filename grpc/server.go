@@ -0,0 +1,69 @@
+package grpc
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net"
+
+	"google.golang.org/grpc"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/auth"
+	"github.com/silvan-talos/shipping/grpc/pb"
+	"github.com/silvan-talos/shipping/product"
+)
+
+type Server struct {
+	server *grpc.Server
+}
+
+func NewServer(args ServerArgs) *Server {
+	err := shipping.Validate.Struct(args)
+	if err != nil {
+		log.Fatal("grpc server failed to start, args missing, err:", err)
+	}
+
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(identifyCallUnary, authenticateUnary(args.Authenticator), scopeToTenantUnary,
+			makeIdempotentUnary(args.Idempotency)),
+		grpc.ChainStreamInterceptor(identifyCallStream, authenticateStream(args.Authenticator), scopeToTenantStream),
+	)
+	pb.RegisterPackagingServiceServer(s, &packagingHandler{
+		ps: args.ProductService,
+	})
+
+	return &Server{
+		server: s,
+	}
+}
+
+type ServerArgs struct {
+	ProductService product.Service `validate:"required"`
+	// Authenticator is optional, without it authentication is disabled
+	Authenticator *auth.Authenticator
+	// Idempotency is optional, without it the idempotency-key metadata is ignored
+	Idempotency *IdempotencyArgs
+}
+
+func (s *Server) Serve(lis net.Listener) error {
+	slog.Info("starting grpc server", "address", lis.Addr().String())
+	return s.server.Serve(lis)
+}
+
+// Shutdown stops accepting connections and waits for the calls in flight to complete. The connections
+// still open when ctx is done are closed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,287 @@
+package grpc_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/auth"
+	"github.com/silvan-talos/shipping/grpc"
+	"github.com/silvan-talos/shipping/grpc/pb"
+	"github.com/silvan-talos/shipping/inmem"
+	"github.com/silvan-talos/shipping/mock"
+	"github.com/silvan-talos/shipping/product"
+)
+
+func newClient(t *testing.T, packs shipping.PackRepository) pb.PackagingServiceClient {
+	return dial(t, grpc.ServerArgs{
+		ProductService: product.NewService(product.ServiceArgs{
+			Packs: packs,
+		}),
+	})
+}
+
+func dial(t *testing.T, args grpc.ServerArgs) pb.PackagingServiceClient {
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer(args)
+	go s.Serve(lis)
+	conn, err := grpclib.Dial("bufnet",
+		grpclib.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpclib.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+		lis.Close()
+	})
+	return pb.NewPackagingServiceClient(conn)
+}
+
+func TestServer_CalculatePacks(t *testing.T) {
+	client := newClient(t, inmem.NewPackRepository(shipping.DefaultPackSizes))
+	tenantCtx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "tenant-1")
+
+	_, err := client.CalculatePacks(context.Background(), &pb.CalculatePacksRequest{ProductId: 1, Quantity: 1})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.UpdatePacks(tenantCtx, &pb.UpdatePacksRequest{ProductId: 1})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.UpdatePacks(tenantCtx, &pb.UpdatePacksRequest{ProductId: 1, PackSizes: []uint64{100, 300}})
+	require.NoError(t, err)
+
+	config, err := client.GetConfig(tenantCtx, &pb.GetConfigRequest{ProductId: 1})
+	require.NoError(t, err)
+	require.Equal(t, "product", config.GetSource())
+	require.Equal(t, []uint64{100, 300}, config.GetPackSizes())
+
+	resp, err := client.CalculatePacks(tenantCtx, &pb.CalculatePacksRequest{ProductId: 1, Quantity: 350})
+	require.NoError(t, err)
+	require.Equal(t, int64(50), resp.GetOverhead())
+	require.Len(t, resp.GetPacks(), 2)
+	require.Equal(t, uint64(300), resp.GetPacks()[0].GetSize())
+	require.Equal(t, uint64(100), resp.GetPacks()[1].GetSize())
+}
+
+func TestServer_BatchCalculatePacks(t *testing.T) {
+	client := newClient(t, &mock.PackRepository{
+		GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+			if productID == 2 {
+				return nil, shipping.ErrNotFound
+			}
+			return []uint64{250, 500}, nil
+		},
+		GetDefaultFn: func(ctx context.Context) ([]uint64, error) {
+			return nil, shipping.ErrNotFound
+		},
+	})
+	tenantCtx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "tenant-1")
+
+	stream, err := client.BatchCalculatePacks(tenantCtx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.CalculatePacksRequest{ProductId: 1, Quantity: 750}))
+	require.NoError(t, stream.Send(&pb.CalculatePacksRequest{ProductId: 2, Quantity: 1}))
+	require.NoError(t, stream.Send(&pb.CalculatePacksRequest{ProductId: 1, Quantity: 1}))
+	require.NoError(t, stream.CloseSend())
+
+	var responses []*pb.BatchCalculatePacksResponse
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		responses = append(responses, resp)
+	}
+	require.Len(t, responses, 3)
+	require.Equal(t, uint32(0), responses[0].GetIndex())
+	require.Equal(t, int64(0), responses[0].GetPacking().GetOverhead())
+	require.Equal(t, uint32(1), responses[1].GetIndex())
+	require.Equal(t, int32(codes.NotFound), responses[1].GetError().GetCode())
+	require.Equal(t, uint32(2), responses[2].GetIndex())
+	require.Equal(t, int64(249), responses[2].GetPacking().GetOverhead())
+}
+
+func TestServer_RequestID(t *testing.T) {
+	client := newClient(t, inmem.NewPackRepository(shipping.DefaultPackSizes))
+	tests := map[string]struct {
+		requestID string
+		generated bool
+	}{
+		"given": {
+			requestID: "req-1",
+		},
+		"missing": {
+			generated: true,
+		},
+		"invalid": {
+			requestID: "req 1",
+			generated: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "tenant-1")
+			if tc.requestID != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", tc.requestID)
+			}
+			var header metadata.MD
+			_, err := client.CalculatePacks(ctx, &pb.CalculatePacksRequest{ProductId: 1, Quantity: 250}, grpclib.Header(&header))
+			require.NoError(t, err)
+			ids := header.Get("x-request-id")
+			require.Len(t, ids, 1)
+			if tc.generated {
+				require.Len(t, ids[0], 32)
+				return
+			}
+			require.Equal(t, tc.requestID, ids[0])
+		})
+	}
+}
+
+func TestServer_Idempotency(t *testing.T) {
+	var calls atomic.Int32
+	client := dial(t, grpc.ServerArgs{
+		ProductService: product.NewService(product.ServiceArgs{
+			Packs: &mock.PackRepository{
+				UpdateConfigFn: func(context.Context, uint64, []uint64) ([]uint64, error) {
+					calls.Add(1)
+					return nil, nil
+				},
+			},
+		}),
+		Idempotency: &grpc.IdempotencyArgs{
+			Store: inmem.NewIdempotencyStore(100),
+		},
+	})
+	update := func(key string, sizes []uint64) (metadata.MD, error) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "tenant-1", "idempotency-key", key)
+		var header metadata.MD
+		_, err := client.UpdatePacks(ctx, &pb.UpdatePacksRequest{ProductId: 1, PackSizes: sizes}, grpclib.Header(&header))
+		return header, err
+	}
+
+	header, err := update("key-1", []uint64{250, 500})
+	require.NoError(t, err)
+	require.Empty(t, header.Get("idempotent-replayed"))
+
+	header, err = update("key-1", []uint64{250, 500})
+	require.NoError(t, err)
+	require.Equal(t, []string{"true"}, header.Get("idempotent-replayed"))
+	require.Equal(t, int32(1), calls.Load())
+
+	_, err = update("key-1", []uint64{1000})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, int32(1), calls.Load())
+
+	// failed calls are replayed too
+	_, err = update("key-2", nil)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	header, err = update("key-2", nil)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, []string{"true"}, header.Get("idempotent-replayed"))
+
+	// calls without a key are processed every time
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "tenant-1")
+	_, err = client.UpdatePacks(ctx, &pb.UpdatePacksRequest{ProductId: 1, PackSizes: []uint64{250}})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestServer_Authentication(t *testing.T) {
+	sum := sha256.Sum256([]byte("reader-key"))
+	unbound := sha256.Sum256([]byte("unbound-key"))
+	keys, err := json.Marshal([]auth.APIKey{
+		{
+			ID:        "reader",
+			KeySHA256: hex.EncodeToString(sum[:]),
+			Tenant:    "tenant-1",
+			Scopes:    []string{auth.ScopePackagingRead},
+		},
+		{
+			ID:        "unbound",
+			KeySHA256: hex.EncodeToString(unbound[:]),
+			Scopes:    []string{auth.ScopePackagingRead},
+		},
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(path, keys, 0o600))
+	authenticator, err := auth.NewAuthenticator(auth.AuthenticatorArgs{APIKeysFile: path})
+	require.NoError(t, err)
+	client := dial(t, grpc.ServerArgs{
+		ProductService: product.NewService(product.ServiceArgs{
+			Packs: &mock.PackRepository{},
+		}),
+		Authenticator: authenticator,
+	})
+	readerCtx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "reader-key")
+
+	_, err = client.GetConfig(context.Background(), &pb.GetConfigRequest{ProductId: 1})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.GetConfig(metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "other"), &pb.GetConfigRequest{ProductId: 1})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.GetConfig(readerCtx, &pb.GetConfigRequest{ProductId: 1})
+	require.NoError(t, err)
+
+	_, err = client.UpdatePacks(readerCtx, &pb.UpdatePacksRequest{ProductId: 1, PackSizes: []uint64{100}})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.GetConfig(metadata.AppendToOutgoingContext(readerCtx, "x-tenant-id", "tenant-2"), &pb.GetConfigRequest{ProductId: 1})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// principals without a tenant need the tenants:admin scope to pick one
+	unboundCtx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "unbound-key", "x-tenant-id", "tenant-2")
+	_, err = client.GetConfig(unboundCtx, &pb.GetConfigRequest{ProductId: 1})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer(grpc.ServerArgs{
+		ProductService: product.NewService(product.ServiceArgs{
+			Packs: inmem.NewPackRepository(shipping.DefaultPackSizes),
+		}),
+	})
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(lis)
+	}()
+	conn, err := grpclib.Dial("bufnet",
+		grpclib.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpclib.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewPackagingServiceClient(conn)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "tenant-1")
+	_, err = client.CalculatePacks(ctx, &pb.CalculatePacksRequest{ProductId: 1, Quantity: 250})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Shutdown(context.Background()))
+	require.NoError(t, <-served)
+	_, err = client.CalculatePacks(ctx, &pb.CalculatePacksRequest{ProductId: 1, Quantity: 250})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
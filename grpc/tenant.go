@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/auth"
+)
+
+// tenantKey is the metadata counterpart of the X-Tenant-ID header of the http server
+const tenantKey = "x-tenant-id"
+
+func scopeToTenantUnary(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := withTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func scopeToTenantStream(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := withTenant(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// withTenant scopes ctx to the tenant of the principal, or to the one found in the incoming metadata
+// when the principal is not bound to a tenant and may act for any, rejecting calls without one
+func withTenant(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	tenant := first(md, tenantKey)
+	principal, _ := shipping.PrincipalFromContext(ctx)
+	switch {
+	case principal.Tenant != "":
+		if tenant != "" && tenant != principal.Tenant {
+			return nil, status.Error(codes.PermissionDenied, "tenant not allowed")
+		}
+		tenant = principal.Tenant
+	case !principal.HasScope(auth.ScopeTenantsAdmin):
+		return nil, status.Error(codes.PermissionDenied, "principal not bound to a tenant")
+	}
+	if tenant == "" {
+		return nil, status.Error(codes.Unauthenticated, "tenant missing")
+	}
+	return shipping.WithTenant(ctx, tenant), nil
+}
+
+// contextStream overrides the context of the stream with the one scoped by the interceptors
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/audit"
+	"github.com/silvan-talos/shipping/auth"
+)
+
+type auditHandler struct {
+	as audit.Service
+}
+
+func (ah *auditHandler) addRoutes(r *gin.RouterGroup) {
+	r.GET("", requireScope(auth.ScopeAuditRead), ah.findEntries)
+}
+
+//	@Summary		Find audit entries
+//	@Description	Returns the attempts to change pack configurations, successful or not, the most recent first
+//	@Description	Requires the audit:read scope.
+//	@Tags			audit
+//	@Produce		json
+//	@Param			product_id	query		int64	false	"ID of the product"
+//	@Param			principal	query		string	false	"ID of the principal that attempted the change"
+//	@Param			from		query		string	false	"RFC 3339 time of the oldest entry"
+//	@Param			to			query		string	false	"RFC 3339 time the entries are older than"
+//	@Param			limit		query		int		false	"Max number of entries, 100 by default"	maximum(1000)
+//	@Success		200			{array}		shipping.AuditEntry
+//	@Failure		400			{object}	object{error=string}
+//	@Failure		401			{object}	object{error=string}
+//	@Failure		403			{object}	object{error=string}
+//	@Failure		429			{object}	object{error=string}	"Rate limit exceeded, retry after the seconds of the Retry-After header"
+//	@Failure		500
+//	@Security		ApiKey
+//	@Security		BearerToken
+//	@Router			/v1/audit [get]
+func (ah *auditHandler) findEntries(c *gin.Context) {
+	var query shipping.AuditQuery
+	var err error
+	if v := c.Query("product_id"); v != "" {
+		query.ProductID, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid product ID"})
+			return
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		query.Limit, err = strconv.Atoi(v)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+	query.From, err = parseTime(c.Query("from"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid from, expected an RFC 3339 time"})
+		return
+	}
+	query.To, err = parseTime(c.Query("to"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid to, expected an RFC 3339 time"})
+		return
+	}
+	query.Principal = c.Query("principal")
+	resp, err := ah.as.FindEntries(c.Request.Context(), query)
+	if err != nil {
+		switch {
+		case errors.Is(err, shipping.InternalServerErr):
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error occurred"})
+			return
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseTime parses an optional RFC 3339 time, the zero time is returned for an empty value
+func parseTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// describeClient scopes the request context to the address and user agent of the client, for auditing
+func describeClient() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := c.ClientIP()
+		if ua := c.Request.UserAgent(); ua != "" {
+			client += " " + ua
+		}
+		c.Request = c.Request.WithContext(shipping.WithClient(c.Request.Context(), client))
+		c.Next()
+	}
+}
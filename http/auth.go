@@ -0,0 +1,58 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/auth"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// authenticate sets the principal of the request from its bearer token or API key. Without an
+// authenticator every request is made by the anonymous principal, which has every scope.
+func authenticate(a *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if a == nil {
+			c.Request = c.Request.WithContext(shipping.WithPrincipal(c.Request.Context(), auth.Anonymous))
+			c.Next()
+			return
+		}
+		var principal shipping.Principal
+		var err error
+		if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+			principal, err = a.AuthenticateToken(token)
+		} else if key := c.GetHeader(apiKeyHeader); key != "" {
+			principal, err = a.AuthenticateAPIKey(key)
+		} else {
+			err = auth.ErrUnauthenticated
+		}
+		if err != nil {
+			if !errors.Is(err, auth.ErrUnauthenticated) {
+				slog.ErrorContext(c.Request.Context(), "failed to authenticate request", "err", err)
+			}
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or missing credentials"})
+			return
+		}
+		c.Request = c.Request.WithContext(shipping.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// requireScope rejects requests of principals not granted the scope
+func requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := shipping.PrincipalFromContext(c.Request.Context())
+		if !ok || !principal.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing scope " + scope})
+			return
+		}
+		c.Next()
+	}
+}
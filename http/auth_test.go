@@ -0,0 +1,105 @@
+package http_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping/auth"
+	shippinghttp "github.com/silvan-talos/shipping/http"
+)
+
+func TestServer_Authentication(t *testing.T) {
+	authenticator := newAuthenticator(t, map[string]auth.APIKey{
+		"reader-key":  {ID: "reader", Tenant: "tenant-1", Scopes: []string{auth.ScopePackagingRead}},
+		"unbound-key": {ID: "unbound", Scopes: []string{auth.ScopePackagingRead}},
+		"admin-key":   {ID: "admin", Scopes: []string{auth.ScopePackagingRead, auth.ScopeTenantsAdmin}},
+	})
+	tests := map[string]struct {
+		authenticator  *auth.Authenticator
+		method         string
+		header         map[string]string
+		expectedStatus int
+		expectedError  string
+	}{
+		"noCredentials_unauthorized": {
+			authenticator:  authenticator,
+			header:         map[string]string{},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "invalid or missing credentials",
+		},
+		"unknownAPIKey_unauthorized": {
+			authenticator:  authenticator,
+			header:         map[string]string{"X-API-Key": "other-key"},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "invalid or missing credentials",
+		},
+		"invalidBearerToken_unauthorized": {
+			authenticator:  authenticator,
+			header:         map[string]string{"Authorization": "Bearer not-a-jwt", "X-API-Key": "reader-key"},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "invalid or missing credentials",
+		},
+		"scopeGranted_allowed": {
+			authenticator:  authenticator,
+			header:         map[string]string{"X-API-Key": "reader-key"},
+			expectedStatus: http.StatusOK,
+		},
+		"scopeMissing_forbidden": {
+			authenticator:  authenticator,
+			method:         http.MethodPut,
+			header:         map[string]string{"X-API-Key": "reader-key"},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "missing scope packaging:write",
+		},
+		"otherTenant_forbidden": {
+			authenticator:  authenticator,
+			header:         map[string]string{"X-API-Key": "reader-key", tenantHeader: "tenant-2"},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "tenant not allowed",
+		},
+		"unboundPrincipalPickingTenant_forbidden": {
+			authenticator:  authenticator,
+			header:         map[string]string{"X-API-Key": "unbound-key", tenantHeader: "tenant-2"},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "principal not bound to a tenant",
+		},
+		"tenantsAdminPickingTenant_allowed": {
+			authenticator:  authenticator,
+			header:         map[string]string{"X-API-Key": "admin-key", tenantHeader: "tenant-2"},
+			expectedStatus: http.StatusOK,
+		},
+		"tenantsAdminWithoutTenant_unauthorized": {
+			authenticator:  authenticator,
+			header:         map[string]string{"X-API-Key": "admin-key"},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "tenant missing",
+		},
+		"authenticationDisabled_anonymousAllowed": {
+			method:         http.MethodPut,
+			header:         map[string]string{tenantHeader: "tenant-1"},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newServer(func(args *shippinghttp.ServerArgs) {
+				args.Authenticator = tc.authenticator
+			})
+			r := request{method: http.MethodGet, path: "/v1/packaging/default", header: tc.header}
+			if tc.method != "" {
+				r.method = tc.method
+			}
+			res := serveBody(s, r, "[250, 500]")
+			require.Equal(t, tc.expectedStatus, res.Code, res.Body.String())
+			if tc.expectedStatus == http.StatusUnauthorized && tc.expectedError != "tenant missing" {
+				require.Equal(t, "Bearer", res.Header().Get("WWW-Authenticate"))
+			}
+			if tc.expectedError != "" {
+				require.True(t, strings.Contains(res.Body.String(), tc.expectedError), res.Body.String())
+			}
+		})
+	}
+}
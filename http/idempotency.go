@@ -0,0 +1,181 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/internal/idempotency"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	replayedHeader       = "Idempotent-Replayed"
+	// maxIdempotencyKey bounds the length of the keys clients may send
+	maxIdempotencyKey = 255
+	// inProgressTTL bounds how long a key stays reserved by a request that never completes, e.g. after a crash.
+	// Requests still processed extend it every half of it.
+	inProgressTTL = time.Minute
+	// maxIdempotentBody bounds the request bodies read to fingerprint requests
+	maxIdempotentBody = 10 << 20
+)
+
+type IdempotencyArgs struct {
+	Store shipping.IdempotencyStore `validate:"required"`
+	// TTL is how long the responses are replayed for, defaults to 24h
+	TTL time.Duration `validate:"gte=0"`
+	// InProgressTTL is how long a key stays reserved once its request stops extending it, defaults to 1m
+	InProgressTTL time.Duration `validate:"gte=0"`
+}
+
+// makeIdempotent replays the response of the first PUT or POST request made with an Idempotency-Key to the
+// retries of the same request, so it is processed only once. Keys are scoped to the tenant and principal,
+// reusing one for a different request is rejected with 422, and retrying while the first request is still
+// processed with 409. Server errors and panics are not recorded, so the request can be retried. Requests are
+// rejected with 503 while the store is full of requests in progress.
+func makeIdempotent(args *IdempotencyArgs) gin.HandlerFunc {
+	ttl, reservedTTL := 24*time.Hour, inProgressTTL
+	if args != nil && args.TTL > 0 {
+		ttl = args.TTL
+	}
+	if args != nil && args.InProgressTTL > 0 {
+		reservedTTL = args.InProgressTTL
+	}
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if args == nil || key == "" || (c.Request.Method != http.MethodPut && c.Request.Method != http.MethodPost) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "idempotency key too long"})
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBody))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		ctx := c.Request.Context()
+		key = idempotencyScope(c) + " " + key
+		fingerprint := fingerprintRequest(c.Request, body)
+
+		record, reserved, err := args.Store.Reserve(ctx, key, fingerprint, reservedTTL)
+		if errors.Is(err, shipping.ErrIdempotencyStoreFull) {
+			slog.WarnContext(ctx, "idempotency store full, rejecting request", "err", err)
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "too many requests in progress, retry later"})
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to reserve idempotency key", "err", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error occurred"})
+			return
+		}
+		if !reserved {
+			switch {
+			case record.Fingerprint != fingerprint:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "idempotency key already used for a different request"})
+			case !record.Completed:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this idempotency key is in progress"})
+			default:
+				replay(c, record)
+			}
+			return
+		}
+
+		// the outcome is stored even when the client gave up waiting for it
+		ctx = context.WithoutCancel(ctx)
+		completed := false
+		stopExtending := idempotency.ExtendReservation(ctx, args.Store, key, reservedTTL)
+		defer func() {
+			stopExtending()
+			// a panicking handler must not leave the key reserved, the panic goes on to the recovery
+			if completed {
+				return
+			}
+			if err := args.Store.Release(ctx, key); err != nil {
+				slog.ErrorContext(ctx, "failed to release idempotency key", "err", err)
+			}
+		}()
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		// headers set before the handler, like the rate limit ones, describe this request only
+		before := c.Writer.Header().Clone()
+		c.Next()
+		if w.Status() >= http.StatusInternalServerError {
+			return
+		}
+		completed = true
+		header := make(map[string][]string)
+		for k, v := range w.Header() {
+			if _, ok := before[k]; !ok {
+				header[k] = v
+			}
+		}
+		err = args.Store.Complete(ctx, key, shipping.IdempotentRecord{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			Status:      w.Status(),
+			Header:      header,
+			Body:        w.body.Bytes(),
+		}, ttl)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to record idempotent response", "err", err)
+		}
+	}
+}
+
+func replay(c *gin.Context, record shipping.IdempotentRecord) {
+	for k, v := range record.Header {
+		c.Writer.Header()[k] = v
+	}
+	c.Header(replayedHeader, "true")
+	c.Status(record.Status)
+	if len(record.Body) > 0 {
+		c.Writer.Write(record.Body)
+	}
+	c.Abort()
+}
+
+// idempotencyScope keeps the keys of tenants and principals apart
+func idempotencyScope(c *gin.Context) string {
+	tenant, _ := shipping.TenantFromContext(c.Request.Context())
+	principal, _ := shipping.PrincipalFromContext(c.Request.Context())
+	return tenant + " " + principal.ID
+}
+
+// fingerprintRequest hashes the method, URL and body of the request
+func fingerprintRequest(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,156 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	shippinghttp "github.com/silvan-talos/shipping/http"
+	"github.com/silvan-talos/shipping/inmem"
+	"github.com/silvan-talos/shipping/mock"
+	"github.com/silvan-talos/shipping/product"
+)
+
+// idempotentPut is an update of the packaging of product 1 made with the Idempotency-Key key
+func idempotentPut(key string) request {
+	return request{
+		method: http.MethodPut,
+		path:   "/v1/products/1/packaging",
+		header: map[string]string{tenantHeader: "tenant-1", "Idempotency-Key": key},
+	}
+}
+
+func TestServer_Idempotency(t *testing.T) {
+	s := newServer(func(args *shippinghttp.ServerArgs) {
+		args.Idempotency = &shippinghttp.IdempotencyArgs{
+			Store: inmem.NewIdempotencyStore(100),
+		}
+	})
+
+	res := serveBody(s, idempotentPut("key-1"), "[250, 500]")
+	require.Equal(t, http.StatusNoContent, res.Code)
+	require.Empty(t, res.Header().Get("Idempotent-Replayed"))
+
+	res = serveBody(s, idempotentPut("key-1"), "[250, 500]")
+	require.Equal(t, http.StatusNoContent, res.Code)
+	require.Equal(t, "true", res.Header().Get("Idempotent-Replayed"))
+
+	res = serveBody(s, idempotentPut("key-1"), "[1000]")
+	require.Equal(t, http.StatusUnprocessableEntity, res.Code)
+
+	// failed requests are replayed too
+	res = serveBody(s, idempotentPut("key-2"), "[0]")
+	require.Equal(t, http.StatusBadRequest, res.Code)
+	res = serveBody(s, idempotentPut("key-2"), "[0]")
+	require.Equal(t, http.StatusBadRequest, res.Code)
+	require.Equal(t, "true", res.Header().Get("Idempotent-Replayed"))
+
+	res = serve(s, request{method: http.MethodGet, path: "/v1/products/1/packaging/config", header: map[string]string{tenantHeader: "tenant-1"}})
+	require.Equal(t, http.StatusOK, res.Code)
+	require.JSONEq(t, `{"pack_sizes": [250, 500], "source": "product"}`, res.Body.String())
+}
+
+func TestServer_IdempotencyPanic(t *testing.T) {
+	var calls atomic.Int32
+	s := newServer(func(args *shippinghttp.ServerArgs) {
+		args.ProductService = product.NewService(product.ServiceArgs{
+			Packs: &mock.PackRepository{
+				UpdateConfigFn: func(context.Context, uint64, []uint64) ([]uint64, error) {
+					if calls.Add(1) == 1 {
+						panic("repository failure")
+					}
+					return nil, nil
+				},
+			},
+		})
+		args.Idempotency = &shippinghttp.IdempotencyArgs{
+			Store: inmem.NewIdempotencyStore(100),
+		}
+	})
+
+	res := serveBody(s, idempotentPut("key-1"), "[250]")
+	require.Equal(t, http.StatusInternalServerError, res.Code)
+
+	// the key was released by the panicking request, the retry is processed
+	res = serveBody(s, idempotentPut("key-1"), "[250]")
+	require.Equal(t, http.StatusNoContent, res.Code)
+	require.Empty(t, res.Header().Get("Idempotent-Replayed"))
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestServer_IdempotencyLongRequest(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var calls atomic.Int32
+	s := newServer(func(args *shippinghttp.ServerArgs) {
+		args.ProductService = product.NewService(product.ServiceArgs{
+			Packs: &mock.PackRepository{
+				UpdateConfigFn: func(context.Context, uint64, []uint64) ([]uint64, error) {
+					if calls.Add(1) == 1 {
+						close(started)
+						<-release
+					}
+					return nil, nil
+				},
+			},
+		})
+		args.Idempotency = &shippinghttp.IdempotencyArgs{
+			Store:         inmem.NewIdempotencyStore(100),
+			InProgressTTL: 20 * time.Millisecond,
+		}
+	})
+
+	first := make(chan int)
+	go func() {
+		first <- serveBody(s, idempotentPut("key-1"), "[250]").Code
+	}()
+	<-started
+	// the request runs for several times the TTL of its reservation, which it keeps extending
+	time.Sleep(100 * time.Millisecond)
+	res := serveBody(s, idempotentPut("key-1"), "[250]")
+	require.Equal(t, http.StatusConflict, res.Code)
+	close(release)
+	require.Equal(t, http.StatusNoContent, <-first)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestServer_IdempotencyStoreFull(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var calls atomic.Int32
+	s := newServer(func(args *shippinghttp.ServerArgs) {
+		args.ProductService = product.NewService(product.ServiceArgs{
+			Packs: &mock.PackRepository{
+				UpdateConfigFn: func(context.Context, uint64, []uint64) ([]uint64, error) {
+					if calls.Add(1) == 1 {
+						close(started)
+						<-release
+					}
+					return nil, nil
+				},
+			},
+		})
+		args.Idempotency = &shippinghttp.IdempotencyArgs{
+			Store: inmem.NewIdempotencyStore(1),
+		}
+	})
+
+	first := make(chan int)
+	go func() {
+		first <- serveBody(s, idempotentPut("key-1"), "[250]").Code
+	}()
+	<-started
+	// the only record is in progress, evicting it would let its retries be processed again
+	res := serveBody(s, idempotentPut("key-2"), "[250]")
+	require.Equal(t, http.StatusServiceUnavailable, res.Code)
+	require.Equal(t, "1", res.Header().Get("Retry-After"))
+	close(release)
+	require.Equal(t, http.StatusNoContent, <-first)
+
+	// the completed record makes room for the next key
+	res = serveBody(s, idempotentPut("key-2"), "[250]")
+	require.Equal(t, http.StatusNoContent, res.Code)
+	require.Equal(t, int32(2), calls.Load())
+}
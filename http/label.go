@@ -0,0 +1,66 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/auth"
+	"github.com/silvan-talos/shipping/label"
+)
+
+type labelHandler struct {
+	ls label.Service
+}
+
+type labelsRequest struct {
+	ProductID uint64                `json:"product_id" binding:"required"`
+	Quantity  uint64                `json:"quantity" binding:"required"`
+	Packs     []shipping.PackConfig `json:"packs" binding:"required"`
+	From      shipping.Address      `json:"from" binding:"required"`
+	To        shipping.Address      `json:"to" binding:"required"`
+	Format    string                `json:"format" binding:"required" enums:"zpl,pdf"`
+}
+
+func (lh *labelHandler) addRoutes(r *gin.RouterGroup) {
+	r.POST("", requireScope(auth.ScopeLabelsWrite), lh.generateLabels)
+}
+
+//	@Summary		Generate shipping labels
+//	@Description	Renders one label per physical pack of a computed packing, as ZPL for thermal printers or as a PDF document
+//	@Description	Requires the labels:write scope.
+//	@Tags			labels
+//	@Accept			json
+//	@Produce		plain,application/pdf
+//	@Param			request			body		labelsRequest	true	"Computed packing and addresses"
+//	@Param			Idempotency-Key	header		string			false	"Replays the response of the first request made with the same key for 24h"
+//	@Success		200				{file}		file
+//	@Failure		400				{object}	object{error=string}
+//	@Failure		401				{object}	object{error=string}
+//	@Failure		403				{object}	object{error=string}
+//	@Failure		429				{object}	object{error=string}	"Rate limit exceeded, retry after the seconds of the Retry-After header"
+//	@Security		ApiKey
+//	@Security		BearerToken
+//	@Router			/v1/labels [post]
+func (lh *labelHandler) generateLabels(c *gin.Context) {
+	var req labelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	format := label.Format(req.Format)
+	labels, err := lh.ls.GenerateLabels(c.Request.Context(), label.Request{
+		ProductID: req.ProductID,
+		Quantity:  req.Quantity,
+		Packs:     req.Packs,
+		From:      req.From,
+		To:        req.To,
+		Format:    format,
+	})
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, format.ContentType(), labels)
+}
@@ -0,0 +1,89 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Labels(t *testing.T) {
+	const addresses = `"from": {"name": "Warehouse 1", "street": "Main St 1", "city": "Cluj", "postal_code": "400001", "country": "RO"},
+		"to": {"name": "Jane Doe", "street": "High St 2", "city": "Bucharest", "postal_code": "010001", "country": "RO"}`
+	tests := map[string]struct {
+		body                string
+		expectedStatus      int
+		expectedContentType string
+		// expectedError starts the error returned
+		expectedError string
+	}{
+		"zplLabels": {
+			body:                `{"product_id": 1, "quantity": 501, "packs": [{"number_of_packs": 1, "pack_size": 500}, {"number_of_packs": 1, "pack_size": 250}], "format": "zpl", ` + addresses + `}`,
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		"pdfLabels": {
+			body:                `{"product_id": 1, "quantity": 250, "packs": [{"number_of_packs": 1, "pack_size": 250}], "format": "pdf", ` + addresses + `}`,
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/pdf",
+		},
+		"missingFormat_badRequest": {
+			body:           `{"product_id": 1, "quantity": 250, "packs": [{"number_of_packs": 1, "pack_size": 250}], ` + addresses + `}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid body",
+		},
+		"missingAddress_badRequest": {
+			body:           `{"product_id": 1, "quantity": 250, "packs": [{"number_of_packs": 1, "pack_size": 250}], "format": "zpl"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid request: sender address",
+		},
+		"unknownFormat_badRequest": {
+			body:           `{"product_id": 1, "quantity": 250, "packs": [{"number_of_packs": 1, "pack_size": 250}], "format": "png", ` + addresses + `}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid request: format must be zpl or pdf",
+		},
+		"packsBelowQuantity_badRequest": {
+			body:           `{"product_id": 1, "quantity": 501, "packs": [{"number_of_packs": 1, "pack_size": 500}], "format": "zpl", ` + addresses + `}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid request: packs do not cover the ordered quantity",
+		},
+		"tooManyLabels_badRequest": {
+			body:           `{"product_id": 1, "quantity": 1001, "packs": [{"number_of_packs": 1001, "pack_size": 1}], "format": "zpl", ` + addresses + `}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid request: more than 1000 labels requested",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newServer(nil)
+			res := serveBody(s, request{
+				method: http.MethodPost,
+				path:   "/v1/labels",
+				header: map[string]string{tenantHeader: "tenant-1"},
+			}, tc.body)
+			require.Equal(t, tc.expectedStatus, res.Code)
+			if tc.expectedError != "" {
+				var body struct {
+					Error string `json:"error"`
+				}
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+				require.True(t, strings.HasPrefix(body.Error, tc.expectedError), "error %q", body.Error)
+				return
+			}
+			require.Equal(t, tc.expectedContentType, res.Header().Get("Content-Type"))
+			require.NotEmpty(t, res.Body.Bytes())
+		})
+	}
+
+	// every pack gets its own label
+	s := newServer(nil)
+	res := serveBody(s, request{
+		method: http.MethodPost,
+		path:   "/v1/labels",
+		header: map[string]string{tenantHeader: "tenant-1"},
+	}, `{"product_id": 1, "quantity": 1000, "packs": [{"number_of_packs": 2, "pack_size": 500}], "format": "zpl", `+addresses+`}`)
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, 2, strings.Count(res.Body.String(), "^XA"))
+}
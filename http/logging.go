@@ -0,0 +1,48 @@
+package http
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/silvan-talos/shipping"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// identifyRequest scopes the request context to the ID of the X-Request-ID header, or to a new one when the
+// header is missing or invalid, and returns it in the same header of the response
+func identifyRequest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !shipping.ValidRequestID(id) {
+			id = shipping.NewRequestID()
+		}
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(shipping.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// logRequests logs every request once handled, server errors at error level
+func logRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		// the context of the request as scoped by the handlers, to log its tenant and trace
+		slog.LogAttrs(c.Request.Context(), level, "request handled",
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		)
+	}
+}
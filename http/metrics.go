@@ -0,0 +1,39 @@
+package http
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// instrument counts the requests and records their duration by method, route and status. Requests that
+// match no route are labelled with an empty route, so unknown paths do not grow the number of series.
+func instrument(reg prometheus.Registerer) gin.HandlerFunc {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "shipping",
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled.",
+	}, []string{"method", "route", "status"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "shipping",
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of the HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	reg.MustRegister(requests, duration)
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		status := strconv.Itoa(c.Writer.Status())
+		requests.WithLabelValues(c.Request.Method, c.FullPath(), status).Inc()
+		duration.WithLabelValues(c.Request.Method, c.FullPath(), status).Observe(time.Since(start).Seconds())
+	}
+}
+
+func metricsHandler(reg *prometheus.Registry) gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg}))
+}
@@ -4,32 +4,87 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/auth"
+	"github.com/silvan-talos/shipping/carrier"
 	"github.com/silvan-talos/shipping/product"
 )
 
 type productHandler struct {
 	ps product.Service
+	cs carrier.Service
+}
+
+// configSourceHeader tells which level of the fallback chain supplied the pack sizes
+const configSourceHeader = "X-Pack-Config-Source"
+
+type packagingResponse struct {
+	Source   shipping.ConfigLevel  `json:"source"`
+	Packs    []shipping.PackConfig `json:"packs"`
+	Overhead int64                 `json:"overhead"`
+	// Estimates are only returned when carriers are given
+	Estimates []shipping.CostEstimate `json:"estimates,omitempty"`
+}
+
+type packagingConfigResponse struct {
+	Source    shipping.ConfigLevel `json:"source"`
+	PackSizes []uint64             `json:"pack_sizes"`
+}
+
+type simulationRequest struct {
+	CandidateSizes []uint64 `json:"candidate_sizes" binding:"required"`
+	Quantities     []uint64 `json:"quantities" binding:"required"`
+	Warehouse      string   `json:"warehouse"`
+	Carrier        string   `json:"carrier"`
+}
+
+type recommendationRequest struct {
+	Quantities []uint64 `json:"quantities" binding:"required"`
+	MaxSizes   int      `json:"max_sizes" binding:"required"`
+	PackWeight float64  `json:"pack_weight"`
+	Seed       int64    `json:"seed"`
 }
 
 func (ph *productHandler) addRoutes(r *gin.RouterGroup) {
-	r.GET("/:id/packaging", ph.getProductPackaging)
-	r.PUT("/:id/packaging", ph.updateProductPackaging)
+	r.GET("/:id/packaging", requireScope(auth.ScopePackagingRead), ph.getProductPackaging)
+	r.PUT("/:id/packaging", requireScope(auth.ScopePackagingWrite), ph.updateProductPackaging)
+	r.GET("/:id/packaging/config", requireScope(auth.ScopePackagingRead), ph.getProductPackagingConfig)
+	r.POST("/:id/packaging/simulate", requireScope(auth.ScopePackagingRead), ph.simulatePackaging)
+}
+
+func (ph *productHandler) addPackagingRoutes(r *gin.RouterGroup) {
+	r.GET("/default", requireScope(auth.ScopePackagingRead), ph.getDefaultPackaging)
+	r.PUT("/default", requireScope(auth.ScopePackagingWrite), ph.updateDefaultPackaging)
+	r.POST("/recommend", requireScope(auth.ScopePackagingRead), ph.recommendPackaging)
 }
 
 //	@Summary		Get product packaging
-//	@Description	Calculates number of packets based on product configuration
+//	@Description	Calculates number of packets based on product configuration.
+//	@Description	Returns the packs and the overhead, along with the estimated shipping cost per carrier when one or more carriers are given.
+//	@Description	In cost mode the packs minimise the delivered cost with the carrier and the tradeoff against the min overhead packing is returned as shipping.CostOptimalPacking.
+//	@Description	Pack sizes are taken from the warehouse, the product or the default configuration, whichever is found first, and the level used is returned in the X-Pack-Config-Source header.
+//	@Description	Requires the packaging:read scope.
 //	@Tags			packaging, products
 //	@Produce		json
-//	@Param			id	path		int64	true	"ID of the product"
-//	@Param			qty	query		int64	true	"Order quantity for product"
-//	@Success		200	{object}	[]shipping.PackConfig
-//	@Failure		400	{object}	object{error=string}
+//	@Param			id			path		int64					true	"ID of the product"
+//	@Param			qty			query		int64					true	"Order quantity for product, up to 4611686018427387903"
+//	@Param			warehouse	query		string					false	"Warehouse packing the order"
+//	@Param			carrier		query		string					false	"Comma separated list of carriers to estimate the cost for"
+//	@Param			mode		query		string					false	"Packing objective, cost requires a single carrier"	Enums(overhead, cost)
+//	@Success		200			{object}	packagingResponse		"Packs, with the estimates when a carrier is given"
+//	@Header			200			{string}	X-Pack-Config-Source	"Level of the configuration used: warehouse, product or default"
+//	@Failure		400			{object}	object{error=string}
+//	@Failure		401			{object}	object{error=string}
+//	@Failure		403			{object}	object{error=string}
 //	@Failure		404
+//	@Failure		429	{object}	object{error=string}	"Rate limit exceeded, retry after the seconds of the Retry-After header"
 //	@Failure		500
+//	@Security		ApiKey
+//	@Security		BearerToken
 //	@Router			/v1/products/{id}/packaging [get]
 func (ph *productHandler) getProductPackaging(c *gin.Context) {
 	productID := c.Param("id")
@@ -45,10 +100,126 @@ func (ph *productHandler) getProductPackaging(c *gin.Context) {
 	}
 	qty, err := strconv.ParseUint(quantity, 10, 64)
 	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			err = shipping.CheckQuantity(qty)
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid qty"})
 		return
 	}
-	resp, err := ph.ps.CalculatePacksConfiguration(c.Request.Context(), id, qty)
+	warehouse := c.Query("warehouse")
+	if c.Query("mode") == "cost" {
+		ph.getCostOptimalPackaging(c, id, qty, warehouse)
+		return
+	}
+	packing, err := ph.ps.CalculatePacking(c.Request.Context(), id, qty, warehouse)
+	if err != nil {
+		switch {
+		case errors.Is(err, shipping.InternalServerErr):
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error occurred"})
+			return
+		case errors.Is(err, shipping.ErrNotFound):
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "no configuration found for the specified product"})
+			return
+		case errors.Is(err, shipping.ErrOutOfRange):
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, shipping.ErrForbidden):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.Header(configSourceHeader, string(packing.Source))
+	resp := packagingResponse{
+		Source:   packing.Source,
+		Packs:    packing.Packs,
+		Overhead: packing.Overhead,
+	}
+	carriers := c.Query("carrier")
+	if carriers == "" {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	for _, name := range strings.Split(carriers, ",") {
+		name = strings.TrimSpace(name)
+		estimate, err := ph.cs.EstimateCost(c.Request.Context(), name, packing.Packs)
+		if err != nil {
+			switch {
+			case errors.Is(err, shipping.InternalServerErr):
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error occurred"})
+				return
+			case errors.Is(err, shipping.ErrNotFound):
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "no rates found for carrier " + name})
+				return
+			default:
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		resp.Estimates = append(resp.Estimates, estimate)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (ph *productHandler) getCostOptimalPackaging(c *gin.Context, id, qty uint64, warehouse string) {
+	carrierName := strings.TrimSpace(c.Query("carrier"))
+	if carrierName == "" || strings.Contains(carrierName, ",") {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "cost mode requires exactly one carrier"})
+		return
+	}
+	resp, err := ph.ps.CalculateCostOptimalPacks(c.Request.Context(), id, qty, warehouse, carrierName)
+	if err != nil {
+		switch {
+		case errors.Is(err, shipping.InternalServerErr):
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error occurred"})
+			return
+		case errors.Is(err, shipping.ErrNotFound):
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "no configuration or carrier rates found"})
+			return
+		case errors.Is(err, shipping.ErrOutOfRange):
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, shipping.ErrForbidden):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.Header(configSourceHeader, string(resp.Source))
+	c.JSON(http.StatusOK, resp)
+}
+
+//	@Summary		Get product packaging configuration
+//	@Description	Returns the pack sizes used for the product, or for the product in a single warehouse when the warehouse is given, and the level of the configuration they come from
+//	@Description	Requires the packaging:read scope.
+//	@Tags			packaging, products
+//	@Produce		json
+//	@Param			id			path		int64	true	"ID of the product"
+//	@Param			warehouse	query		string	false	"Warehouse to get the configuration for"
+//	@Success		200			{object}	packagingConfigResponse
+//	@Failure		400			{object}	object{error=string}
+//	@Failure		401			{object}	object{error=string}
+//	@Failure		403			{object}	object{error=string}
+//	@Failure		404
+//	@Failure		429	{object}	object{error=string}	"Rate limit exceeded, retry after the seconds of the Retry-After header"
+//	@Failure		500
+//	@Security		ApiKey
+//	@Security		BearerToken
+//	@Router			/v1/products/{id}/packaging/config [get]
+func (ph *productHandler) getProductPackagingConfig(c *gin.Context) {
+	productID := c.Param("id")
+	id, err := strconv.ParseUint(productID, 10, 64)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid product ID"})
+		return
+	}
+	packSizes, source, err := ph.ps.GetPacksConfiguration(c.Request.Context(), id, c.Query("warehouse"))
 	if err != nil {
 		switch {
 		case errors.Is(err, shipping.InternalServerErr):
@@ -57,6 +228,67 @@ func (ph *productHandler) getProductPackaging(c *gin.Context) {
 		case errors.Is(err, shipping.ErrNotFound):
 			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "no configuration found for the specified product"})
 			return
+		case errors.Is(err, shipping.ErrForbidden):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, packagingConfigResponse{
+		Source:    source,
+		PackSizes: packSizes,
+	})
+}
+
+//	@Summary		Simulate product packaging
+//	@Description	Packs the historical order quantities with the pack sizes currently used for the product and with the candidate ones, and compares the total overhead and pack count.
+//	@Description	When a carrier is given, the shipping cost of both is compared too. Negative deltas are savings of the candidate pack sizes.
+//	@Description	Requires the packaging:read scope.
+//	@Tags			packaging, products
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int64				true	"ID of the product"
+//	@Param			request			body		simulationRequest	true	"Candidate pack sizes and historical order quantities"
+//	@Param			Idempotency-Key	header		string				false	"Replays the response of the first request made with the same key for 24h"
+//	@Success		200				{object}	shipping.Simulation
+//	@Failure		400				{object}	object{error=string}
+//	@Failure		401				{object}	object{error=string}
+//	@Failure		403				{object}	object{error=string}
+//	@Failure		404
+//	@Failure		429	{object}	object{error=string}	"Rate limit exceeded, retry after the seconds of the Retry-After header"
+//	@Failure		500
+//	@Security		ApiKey
+//	@Security		BearerToken
+//	@Router			/v1/products/{id}/packaging/simulate [post]
+func (ph *productHandler) simulatePackaging(c *gin.Context) {
+	productID := c.Param("id")
+	id, err := strconv.ParseUint(productID, 10, 64)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid product ID"})
+		return
+	}
+	var req simulationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	resp, err := ph.ps.SimulatePackSizes(c.Request.Context(), id, req.Warehouse, req.CandidateSizes, req.Quantities, req.Carrier)
+	if err != nil {
+		switch {
+		case errors.Is(err, shipping.InternalServerErr):
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error occurred"})
+			return
+		case errors.Is(err, shipping.ErrNotFound):
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "no configuration or carrier rates found"})
+			return
+		case errors.Is(err, shipping.ErrOutOfRange):
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, shipping.ErrForbidden):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
 		default:
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -66,16 +298,24 @@ func (ph *productHandler) getProductPackaging(c *gin.Context) {
 }
 
 //	@Summary		Update product packaging configuration
-//	@Description	Updates configuration for the specified product
+//	@Description	Updates configuration for the specified product, or for the product in a single warehouse when the warehouse is given
+//	@Description	Requires the packaging:write scope.
 //	@Tags			packaging, products
 //	@Accept			json
 //	@Produce		json
-//	@Param			id			path	int64		true	"ID of the product"
-//	@Param			pack_sizes	body	[]uint64	true	"The list of supported pack sizes"
+//	@Param			id				path	int64		true	"ID of the product"
+//	@Param			warehouse		query	string		false	"Warehouse to update the configuration for"
+//	@Param			pack_sizes		body	[]uint64	true	"The list of supported pack sizes"
+//	@Param			Idempotency-Key	header	string		false	"Replays the response of the first request made with the same key for 24h"
 //	@Success		204
 //	@Failure		400	{object}	object{error=string}
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
 //	@Failure		404
+//	@Failure		429	{object}	object{error=string}	"Rate limit exceeded, retry after the seconds of the Retry-After header"
 //	@Failure		500
+//	@Security		ApiKey
+//	@Security		BearerToken
 //	@Router			/v1/products/{id}/packaging [put]
 func (ph *productHandler) updateProductPackaging(c *gin.Context) {
 	productID := c.Param("id")
@@ -89,7 +329,11 @@ func (ph *productHandler) updateProductPackaging(c *gin.Context) {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
 		return
 	}
-	err = ph.ps.UpdatePacksConfiguration(c.Request.Context(), id, req)
+	if warehouse := c.Query("warehouse"); warehouse != "" {
+		err = ph.ps.UpdateWarehousePacksConfiguration(c.Request.Context(), warehouse, id, req)
+	} else {
+		err = ph.ps.UpdatePacksConfiguration(c.Request.Context(), id, req)
+	}
 	if err != nil {
 		switch {
 		case errors.Is(err, shipping.InternalServerErr):
@@ -98,6 +342,89 @@ func (ph *productHandler) updateProductPackaging(c *gin.Context) {
 		case errors.Is(err, shipping.ErrNotFound):
 			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "product id not found"})
 			return
+		case errors.Is(err, shipping.ErrOutOfRange):
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, shipping.ErrForbidden):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
+
+//	@Summary		Get default packaging configuration
+//	@Description	Returns the pack sizes used for products without configuration
+//	@Description	Requires the packaging:read scope.
+//	@Tags			packaging
+//	@Produce		json
+//	@Success		200	{object}	[]uint64
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
+//	@Failure		404
+//	@Failure		429	{object}	object{error=string}	"Rate limit exceeded, retry after the seconds of the Retry-After header"
+//	@Failure		500
+//	@Security		ApiKey
+//	@Security		BearerToken
+//	@Router			/v1/packaging/default [get]
+func (ph *productHandler) getDefaultPackaging(c *gin.Context) {
+	resp, err := ph.ps.GetDefaultPacksConfiguration(c.Request.Context())
+	if err != nil {
+		switch {
+		case errors.Is(err, shipping.InternalServerErr):
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error occurred"})
+			return
+		case errors.Is(err, shipping.ErrNotFound):
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "no default configuration found"})
+			return
+		case errors.Is(err, shipping.ErrForbidden):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+//	@Summary		Update default packaging configuration
+//	@Description	Updates the pack sizes used for products without configuration
+//	@Description	Requires the packaging:write scope.
+//	@Tags			packaging
+//	@Accept			json
+//	@Param			pack_sizes		body	[]uint64	true	"The list of supported pack sizes"
+//	@Param			Idempotency-Key	header	string		false	"Replays the response of the first request made with the same key for 24h"
+//	@Success		204
+//	@Failure		400	{object}	object{error=string}
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
+//	@Failure		429	{object}	object{error=string}	"Rate limit exceeded, retry after the seconds of the Retry-After header"
+//	@Failure		500
+//	@Security		ApiKey
+//	@Security		BearerToken
+//	@Router			/v1/packaging/default [put]
+func (ph *productHandler) updateDefaultPackaging(c *gin.Context) {
+	var req []uint64
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	err := ph.ps.UpdateDefaultPacksConfiguration(c.Request.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, shipping.InternalServerErr):
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error occurred"})
+			return
+		case errors.Is(err, shipping.ErrOutOfRange):
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, shipping.ErrForbidden):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
 		default:
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -105,3 +432,46 @@ func (ph *productHandler) updateProductPackaging(c *gin.Context) {
 	}
 	c.Status(http.StatusNoContent)
 }
+
+//	@Summary		Recommend pack sizes
+//	@Description	Recommends the set of at most max_sizes pack sizes that minimises the expected overhead and pack count of the historical order quantities.
+//	@Description	One extra pack weighs as much as pack_weight overhead items, 1 by default. The search is randomised, the seed of the response reproduces the recommendation.
+//	@Description	Requires the packaging:read scope.
+//	@Tags			packaging
+//	@Accept			json
+//	@Produce		json
+//	@Param			request			body		recommendationRequest	true	"Historical order quantities and the max number of sizes"
+//	@Param			Idempotency-Key	header		string					false	"Replays the response of the first request made with the same key for 24h"
+//	@Success		200				{object}	shipping.Recommendation
+//	@Failure		400				{object}	object{error=string}
+//	@Failure		401				{object}	object{error=string}
+//	@Failure		403				{object}	object{error=string}
+//	@Failure		429				{object}	object{error=string}	"Rate limit exceeded, retry after the seconds of the Retry-After header"
+//	@Failure		500
+//	@Security		ApiKey
+//	@Security		BearerToken
+//	@Router			/v1/packaging/recommend [post]
+func (ph *productHandler) recommendPackaging(c *gin.Context) {
+	var req recommendationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	resp, err := ph.ps.RecommendPackSizes(c.Request.Context(), product.RecommendRequest{
+		Quantities: req.Quantities,
+		MaxSizes:   req.MaxSizes,
+		PackWeight: req.PackWeight,
+		Seed:       req.Seed,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, shipping.InternalServerErr):
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error occurred"})
+			return
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
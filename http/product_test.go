@@ -0,0 +1,213 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/carrier"
+	shippinghttp "github.com/silvan-talos/shipping/http"
+	"github.com/silvan-talos/shipping/inmem"
+	"github.com/silvan-talos/shipping/mock"
+	"github.com/silvan-talos/shipping/product"
+)
+
+func TestServer_OutOfRange(t *testing.T) {
+	rates := &mock.RateRepository{
+		GetByCarrierFn: func(ctx context.Context, carrier string) (shipping.RateTable, error) {
+			return shipping.RateTable{
+				Carrier:     carrier,
+				Currency:    "EUR",
+				WeightBands: []shipping.WeightBand{{MaxWeight: 2, Price: 400}},
+				Parcels: []shipping.Parcel{
+					{PackSize: 600000, Weight: 1},
+					{PackSize: 1000000, Weight: 1},
+				},
+			}, nil
+		},
+	}
+	packs := inmem.NewPackRepository([]uint64{600000, 1000000})
+	s := newServer(func(args *shippinghttp.ServerArgs) {
+		args.ProductService = product.NewService(product.ServiceArgs{
+			Packs: packs,
+			Rates: rates,
+		})
+	})
+	tests := map[string]struct {
+		method        string
+		path          string
+		body          string
+		expectedError string
+	}{
+		"quantityAboveMax": {
+			method:        http.MethodGet,
+			path:          fmt.Sprintf("/v1/products/1/packaging?qty=%d", shipping.MaxQuantity+1),
+			expectedError: "invalid request: quantity exceeds the maximum of 4611686018427387903",
+		},
+		"quantityAboveUint64": {
+			method:        http.MethodGet,
+			path:          "/v1/products/1/packaging?qty=18446744073709551616",
+			expectedError: "invalid request: quantity exceeds the maximum of 4611686018427387903",
+		},
+		"packSizeAboveMax": {
+			method:        http.MethodPut,
+			path:          "/v1/products/1/packaging",
+			body:          fmt.Sprintf("[250, %d]", shipping.MaxPackSize+1),
+			expectedError: "invalid request: pack size exceeds the maximum of 4611686018427387903",
+		},
+		"defaultPackSizeAboveMax": {
+			method:        http.MethodPut,
+			path:          "/v1/packaging/default",
+			body:          fmt.Sprintf("[%d]", shipping.MaxPackSize+1),
+			expectedError: "invalid request: pack size exceeds the maximum of 4611686018427387903",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set(tenantHeader, "tenant-1")
+			res := httptest.NewRecorder()
+			s.ServeHTTP(res, req)
+			require.Equal(t, http.StatusBadRequest, res.Code)
+			var body struct {
+				Error string `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+			require.Equal(t, tc.expectedError, body.Error)
+		})
+	}
+
+	// sizes too large to minimise the cost exactly are packed approximately rather than rejected
+	res := serve(s, request{
+		method: http.MethodGet,
+		path:   "/v1/products/1/packaging?qty=1000900000&mode=cost&carrier=express",
+		header: map[string]string{tenantHeader: "tenant-1"},
+	})
+	require.Equal(t, http.StatusOK, res.Code)
+	var packing shipping.CostOptimalPacking
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &packing))
+	require.True(t, packing.Approximate)
+	require.NotEmpty(t, packing.Packs)
+}
+
+func TestServer_CostModeCarrier(t *testing.T) {
+	s := newServer(func(args *shippinghttp.ServerArgs) {
+		args.ProductService = product.NewService(product.ServiceArgs{
+			Packs: inmem.NewPackRepository(shipping.DefaultPackSizes),
+			Rates: &mock.RateRepository{
+				GetByCarrierFn: func(ctx context.Context, carrier string) (shipping.RateTable, error) {
+					return shipping.RateTable{}, shipping.ErrNotFound
+				},
+			},
+		})
+	})
+	tests := map[string]struct {
+		carrier        string
+		expectedStatus int
+		expectedError  string
+	}{
+		"unknownCarrier": {
+			carrier:        "expres",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "no configuration or carrier rates found",
+		},
+		"missingCarrier": {
+			carrier:        "%20",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "cost mode requires exactly one carrier",
+		},
+		"severalCarriers": {
+			carrier:        "express,economy",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "cost mode requires exactly one carrier",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			res := serve(s, request{
+				method: http.MethodGet,
+				path:   "/v1/products/1/packaging?qty=501&mode=cost&carrier=" + tc.carrier,
+				header: map[string]string{tenantHeader: "tenant-1"},
+			})
+			require.Equal(t, tc.expectedStatus, res.Code)
+			require.JSONEq(t, fmt.Sprintf(`{"error": %q}`, tc.expectedError), res.Body.String())
+		})
+	}
+}
+
+func TestServer_Packaging(t *testing.T) {
+	s := newServer(func(args *shippinghttp.ServerArgs) {
+		args.CarrierService = carrier.NewService(carrier.ServiceArgs{
+			Rates: &mock.RateRepository{
+				GetByCarrierFn: func(ctx context.Context, name string) (shipping.RateTable, error) {
+					if name == "unknown" {
+						return shipping.RateTable{}, shipping.ErrNotFound
+					}
+					return (&mock.RateRepository{}).GetByCarrier(ctx, name)
+				},
+			},
+		})
+	})
+	tests := map[string]struct {
+		carriers         string
+		expectedStatus   int
+		expectedCarriers []string
+		expectedError    string
+	}{
+		"withoutCarrier": {
+			expectedStatus: http.StatusOK,
+		},
+		"severalCarriers": {
+			carriers:         "express,%20economy",
+			expectedStatus:   http.StatusOK,
+			expectedCarriers: []string{"express", "economy"},
+		},
+		"unknownCarrier": {
+			carriers:       "express,%20unknown",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "no rates found for carrier unknown",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := "/v1/products/1/packaging?qty=251"
+			if tc.carriers != "" {
+				path += "&carrier=" + tc.carriers
+			}
+			res := serve(s, request{
+				method: http.MethodGet,
+				path:   path,
+				header: map[string]string{tenantHeader: "tenant-1"},
+			})
+			require.Equal(t, tc.expectedStatus, res.Code)
+			if tc.expectedError != "" {
+				require.JSONEq(t, fmt.Sprintf(`{"error": %q}`, tc.expectedError), res.Body.String())
+				return
+			}
+			// the response is an object whether or not carriers are given, the estimates being omitted without them
+			var body map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+			require.JSONEq(t, `"default"`, string(body["source"]))
+			require.JSONEq(t, `[{"number_of_packs": 1, "pack_size": 500}]`, string(body["packs"]))
+			require.JSONEq(t, `249`, string(body["overhead"]))
+			estimates, ok := body["estimates"]
+			require.Equal(t, tc.expectedCarriers != nil, ok)
+			if ok {
+				var list []shipping.CostEstimate
+				require.NoError(t, json.Unmarshal(estimates, &list))
+				var carriers []string
+				for _, e := range list {
+					carriers = append(carriers, e.Carrier)
+				}
+				require.Equal(t, tc.expectedCarriers, carriers)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/auth"
+)
+
+type RateLimitArgs struct {
+	Store   shipping.RateLimitStore `json:"-" validate:"required"`
+	Default shipping.RateLimit      `json:"default"`
+	// IP limits the requests of every client IP across the routes, counted before authenticating, so clients
+	// failing to authenticate are limited too. Defaults to the default limit.
+	IP *shipping.RateLimit `json:"ip"`
+	// Routes override the default limit, keyed by method and route, e.g. "GET /v1/products/:id/packaging"
+	Routes map[string]shipping.RateLimit `json:"routes" validate:"dive"`
+}
+
+// limitIPRate rejects the requests of client IPs above the IP limit with 429, whether they authenticate or not,
+// so credentials cannot be guessed at the pace of the client. The IP is taken from X-Forwarded-For only when
+// set by a trusted proxy.
+func limitIPRate(args *RateLimitArgs) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if args == nil {
+			c.Next()
+			return
+		}
+		limit := args.Default
+		if args.IP != nil {
+			limit = *args.IP
+		}
+		if takeToken(c, args.Store, "ip:"+c.ClientIP(), limit) {
+			c.Next()
+		}
+	}
+}
+
+// limitRate rejects the requests of clients above the limit of the route with 429. Clients are told apart by
+// their authenticated principal, or by their IP when anonymous, and every route has its own bucket per client.
+func limitRate(args *RateLimitArgs) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if args == nil {
+			c.Next()
+			return
+		}
+		route := c.Request.Method + " " + c.FullPath()
+		limit, ok := args.Routes[route]
+		if !ok {
+			limit = args.Default
+		}
+		if takeToken(c, args.Store, clientKey(c)+" "+route, limit) {
+			c.Next()
+		}
+	}
+}
+
+// takeToken takes a token from the bucket of the key, aborting the request with 429 when it is empty.
+// Errors of the store let requests through, so a shared store going down does not take the API with it.
+func takeToken(c *gin.Context, store shipping.RateLimitStore, key string, limit shipping.RateLimit) bool {
+	decision, err := store.Take(c.Request.Context(), key, limit, time.Now())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to apply rate limit, letting request through", "err", err)
+		return true
+	}
+	if decision.Remaining >= 0 {
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(decision.Remaining, 10))
+	}
+	if !decision.Allowed {
+		c.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(decision.RetryAfter.Seconds())), 10))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+		return false
+	}
+	return true
+}
+
+// clientKey identifies the client by its principal, credentials it sends without authenticating do not count.
+// Anonymous clients are identified by their IP, taken from X-Forwarded-For only when set by a trusted proxy.
+func clientKey(c *gin.Context) string {
+	principal, ok := shipping.PrincipalFromContext(c.Request.Context())
+	if ok && principal.ID != auth.Anonymous.ID {
+		return "principal:" + principal.ID
+	}
+	return "ip:" + c.ClientIP()
+}
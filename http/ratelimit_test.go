@@ -0,0 +1,115 @@
+package http_test
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/auth"
+	shippinghttp "github.com/silvan-talos/shipping/http"
+	"github.com/silvan-talos/shipping/inmem"
+)
+
+func TestServer_RateLimit(t *testing.T) {
+	authenticator := newAuthenticator(t, map[string]auth.APIKey{
+		"key-a": {ID: "client-a", Tenant: "tenant-1", Scopes: []string{auth.ScopePackagingRead}},
+		"key-b": {ID: "client-b", Tenant: "tenant-1", Scopes: []string{auth.ScopePackagingRead}},
+	})
+	tests := map[string]struct {
+		authenticator  *auth.Authenticator
+		trustedProxies []string
+		// request builds the i-th request of the client
+		request          func(i int) request
+		expectedStatuses []int
+	}{
+		"anonymousRotatingAPIKey_limitedByIP": {
+			request: func(i int) request {
+				return request{header: map[string]string{"X-API-Key": fmt.Sprint("random-", i)}, remoteAddr: "192.0.2.1:1234"}
+			},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		"authenticatedRotatingAPIKey_limitedByPrincipal": {
+			authenticator: authenticator,
+			request: func(i int) request {
+				// unauthenticated requests only take a token of their IP, not of the principal
+				if i%2 == 1 {
+					return request{header: map[string]string{"X-API-Key": fmt.Sprint("random-", i)}}
+				}
+				return request{header: map[string]string{"X-API-Key": "key-a"}}
+			},
+			expectedStatuses: []int{http.StatusOK, http.StatusUnauthorized, http.StatusOK, http.StatusUnauthorized, http.StatusTooManyRequests},
+		},
+		"failedAuthentication_limitedByIP": {
+			authenticator: authenticator,
+			request: func(i int) request {
+				return request{header: map[string]string{"X-API-Key": fmt.Sprint("random-", i)}, remoteAddr: "192.0.2.1:1234"}
+			},
+			expectedStatuses: []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests},
+		},
+		"failedAuthenticationFromOtherIP_limitedApart": {
+			authenticator: authenticator,
+			request: func(i int) request {
+				return request{header: map[string]string{"X-API-Key": fmt.Sprint("random-", i)}, remoteAddr: fmt.Sprintf("192.0.2.%d:1234", i%2+1)}
+			},
+			expectedStatuses: []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized},
+		},
+		"principals_limitedApart": {
+			authenticator: authenticator,
+			request: func(i int) request {
+				return request{header: map[string]string{"X-API-Key": []string{"key-a", "key-a", "key-b"}[i%3]}}
+			},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		"principalRotatingIP_limitedByPrincipal": {
+			authenticator: authenticator,
+			request: func(i int) request {
+				return request{header: map[string]string{"X-API-Key": "key-a"}, remoteAddr: fmt.Sprintf("192.0.2.%d:1234", i+1)}
+			},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		"spoofedForwardedFor_limitedByPeer": {
+			request: func(i int) request {
+				return request{header: map[string]string{"X-Forwarded-For": fmt.Sprintf("198.51.100.%d", i+1)}, remoteAddr: "192.0.2.1:1234"}
+			},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		"trustedProxy_limitedByForwardedClient": {
+			trustedProxies: []string{"10.0.0.0/8"},
+			request: func(i int) request {
+				return request{header: map[string]string{"X-Forwarded-For": fmt.Sprintf("198.51.100.%d", i+1)}, remoteAddr: "10.0.0.1:1234"}
+			},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newServer(func(args *shippinghttp.ServerArgs) {
+				args.Authenticator = tc.authenticator
+				args.TrustedProxies = tc.trustedProxies
+				args.RateLimits = &shippinghttp.RateLimitArgs{
+					Store:   inmem.NewRateLimitStore(),
+					Default: shipping.RateLimit{Rate: 0.001, Burst: 2},
+					IP:      &shipping.RateLimit{Rate: 0.001, Burst: 4},
+				}
+			})
+			for i, expected := range tc.expectedStatuses {
+				r := tc.request(i)
+				r.method, r.path = http.MethodGet, "/v1/packaging/default"
+				if tc.authenticator == nil {
+					r.header[tenantHeader] = "tenant-1"
+				}
+				res := serve(s, r)
+				require.Equal(t, expected, res.Code, "status of request %d", i)
+				if expected == http.StatusTooManyRequests {
+					retryAfter, err := strconv.Atoi(res.Header().Get("Retry-After"))
+					require.NoError(t, err)
+					require.Positive(t, retryAfter)
+				}
+			}
+		})
+	}
+}
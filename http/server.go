@@ -1,79 +1,207 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
 	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/audit"
+	"github.com/silvan-talos/shipping/auth"
+	"github.com/silvan-talos/shipping/carrier"
 	_ "github.com/silvan-talos/shipping/docs"
+	"github.com/silvan-talos/shipping/inventory"
+	"github.com/silvan-talos/shipping/label"
 	"github.com/silvan-talos/shipping/product"
+	"github.com/silvan-talos/shipping/shipment"
 )
 
-//	@title			Shipping API docs
-//	@description	Shipping is a small API that calculates packaging configuration for a certain amount of ordered product quantity.
-//	@version		1.0.0
-//	@host			cbhbw91cn7.execute-api.eu-west-1.amazonaws.com
-//	@schemes		https
+//	@title						Shipping API docs
+//	@description				Shipping is a small API that calculates packaging configuration for a certain amount of ordered product quantity.
+//	@version					1.0.0
+//	@host						cbhbw91cn7.execute-api.eu-west-1.amazonaws.com
+//	@schemes					https
+//
+//	@securityDefinitions.apikey	ApiKey
+//	@in							header
+//	@name						X-API-Key
+//	@description				Static API key, bound to a tenant and a set of scopes
+//
+//	@securityDefinitions.apikey	BearerToken
+//	@in							header
+//	@name						Authorization
+//	@description				JWT as "Bearer <token>", its scope claim lists the scopes granted and its tenant claim the tenant of the caller
+//
+//	@securityDefinitions.apikey	TenantID
+//	@in							header
+//	@name						X-Tenant-ID
+//	@description				Tenant to act for, only used when the credentials are not bound to a tenant and grant the tenants:admin scope
 type Server struct {
-	router *gin.Engine
+	router        *gin.Engine
+	server        *http.Server
+	shutdownDelay time.Duration
+	draining      atomic.Bool
 }
 
 func NewServer(args ServerArgs) *Server {
-	gin.SetMode(gin.ReleaseMode)
-	r := gin.New()
-	setLogsFormat(r)
-	r.Use(gin.Recovery())
-
 	err := shipping.Validate.Struct(args)
 	if err != nil {
 		log.Fatal("http server failed to start, args missing, err:", err)
 	}
 
-	v1 := r.Group("/v1")
+	mode := args.Mode
+	if mode == "" {
+		mode = gin.ReleaseMode
+	}
+	gin.SetMode(mode)
+	r := gin.New()
+	// gin trusts the X-Forwarded-For header of every peer by default, letting clients pick their IP
+	err = r.SetTrustedProxies(args.TrustedProxies)
+	if err != nil {
+		log.Fatal("http server failed to start, invalid trusted proxies, err:", err)
+	}
+	r.Use(identifyRequest(), logRequests(), gin.Recovery(), traceRequests())
+	if args.Metrics != nil {
+		r.Use(instrument(args.Metrics))
+		r.GET("/metrics", metricsHandler(args.Metrics))
+	}
+
+	v1 := r.Group("/v1", describeClient(), limitIPRate(args.RateLimits), authenticate(args.Authenticator), limitRate(args.RateLimits), scopeToTenant(), makeIdempotent(args.Idempotency))
 	{
 		productRoutes := v1.Group("/products")
 		{
 			h := productHandler{
 				ps: args.ProductService,
+				cs: args.CarrierService,
 			}
 			h.addRoutes(productRoutes)
+			h.addPackagingRoutes(v1.Group("/packaging"))
+		}
+		labelRoutes := v1.Group("/labels")
+		{
+			h := labelHandler{
+				ls: args.LabelService,
+			}
+			h.addRoutes(labelRoutes)
+		}
+		shipmentRoutes := v1.Group("/shipments")
+		{
+			h := shipmentHandler{
+				ss: args.ShipmentService,
+			}
+			h.addRoutes(shipmentRoutes)
+		}
+		warehouseRoutes := v1.Group("/warehouses")
+		{
+			h := warehouseHandler{
+				is: args.InventoryService,
+			}
+			h.addRoutes(warehouseRoutes)
+		}
+		auditRoutes := v1.Group("/audit")
+		{
+			h := auditHandler{
+				as: args.AuditService,
+			}
+			h.addRoutes(auditRoutes)
 		}
 	}
 
+	s := &Server{
+		router: r,
+		server: &http.Server{
+			Handler:      r,
+			ReadTimeout:  args.ReadTimeout,
+			WriteTimeout: args.WriteTimeout,
+			IdleTimeout:  args.IdleTimeout,
+		},
+		shutdownDelay: args.ShutdownDelay,
+	}
 	r.GET("/ping", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "alive"})
 	})
+	r.GET("/ready", func(c *gin.Context) {
+		if s.draining.Load() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready"})
+	})
 	r.GET("/docs/*any", ginSwagger.WrapHandler(swaggerfiles.Handler, ginSwagger.InstanceName("ShippingAPI")))
 
-	return &Server{
-		router: r,
-	}
+	return s
 }
 
 type ServerArgs struct {
-	ProductService product.Service `validate:"required"`
+	ProductService   product.Service   `validate:"required"`
+	CarrierService   carrier.Service   `validate:"required"`
+	LabelService     label.Service     `validate:"required"`
+	ShipmentService  shipment.Service  `validate:"required"`
+	InventoryService inventory.Service `validate:"required"`
+	AuditService     audit.Service     `validate:"required"`
+	// Authenticator is optional, without it authentication is disabled
+	Authenticator *auth.Authenticator
+	// RateLimits are optional, without them requests are not limited
+	RateLimits *RateLimitArgs
+	// Idempotency is optional, without it the Idempotency-Key header is ignored
+	Idempotency *IdempotencyArgs
+	// Metrics is optional, when set the requests are instrumented and its metrics are served on /metrics
+	Metrics *prometheus.Registry
+	// Mode is the gin mode, defaults to release
+	Mode string `validate:"omitempty,oneof=release debug test"`
+	// ReadTimeout, WriteTimeout and IdleTimeout limit the time spent on a connection, 0 means no limit
+	ReadTimeout  time.Duration `validate:"gte=0"`
+	WriteTimeout time.Duration `validate:"gte=0"`
+	IdleTimeout  time.Duration `validate:"gte=0"`
+	// TrustedProxies are the IPs or CIDRs of the proxies whose X-Forwarded-For header gives the client IP,
+	// none by default
+	TrustedProxies []string `validate:"dive,ip|cidr"`
+	// ShutdownDelay is how long /ready fails on shutdown before the connections are drained, so load
+	// balancers stop routing requests to the server first
+	ShutdownDelay time.Duration `validate:"gte=0"`
 }
 
+// Serve accepts the connections of lis until the server is shut down, when it returns nil
 func (s *Server) Serve(lis net.Listener) error {
-	log.Println("Starting http server, address:", lis.Addr().String())
-	return s.router.RunListener(lis)
+	slog.Info("starting http server", "address", lis.Addr().String())
+	err := s.server.Serve(lis)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown fails readiness and, after the shutdown delay, stops accepting connections and waits for the
+// requests in flight to complete. The connections still open when ctx is done are closed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.draining.Store(true)
+	slog.InfoContext(ctx, "draining http server", "delay", s.shutdownDelay)
+	delay := time.NewTimer(s.shutdownDelay)
+	defer delay.Stop()
+	select {
+	case <-delay.C:
+	case <-ctx.Done():
+	}
+	err := s.server.Shutdown(ctx)
+	if err != nil {
+		s.server.Close()
+		return fmt.Errorf("drain http connections: %w", err)
+	}
+	return nil
 }
 
-func setLogsFormat(r *gin.Engine) {
-	r.Use(gin.LoggerWithFormatter(func(params gin.LogFormatterParams) string {
-		return fmt.Sprintf("[GIN]: %s - client=%s\tlatency=%s\tstatus=%d\tpath=\"%s %s\"\n",
-			params.TimeStamp,
-			params.ClientIP,
-			params.Latency,
-			params.StatusCode,
-			params.Method,
-			params.Path,
-		)
-	}))
+// ServeHTTP lets the server be mounted as a handler, e.g. in httptest servers
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.router.ServeHTTP(w, req)
 }
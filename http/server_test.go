@@ -0,0 +1,108 @@
+package http_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/audit"
+	"github.com/silvan-talos/shipping/auth"
+	"github.com/silvan-talos/shipping/carrier"
+	shippinghttp "github.com/silvan-talos/shipping/http"
+	"github.com/silvan-talos/shipping/inmem"
+	"github.com/silvan-talos/shipping/inventory"
+	"github.com/silvan-talos/shipping/label"
+	"github.com/silvan-talos/shipping/mock"
+	"github.com/silvan-talos/shipping/product"
+	"github.com/silvan-talos/shipping/shipment"
+)
+
+// newServer creates a server of in-memory services, customize sets the optional args
+func newServer(customize func(args *shippinghttp.ServerArgs)) *shippinghttp.Server {
+	productService := product.NewService(product.ServiceArgs{
+		Packs: inmem.NewPackRepository(shipping.DefaultPackSizes),
+	})
+	inventoryRepository := inmem.NewInventoryRepository()
+	args := shippinghttp.ServerArgs{
+		ProductService: productService,
+		CarrierService: carrier.NewService(carrier.ServiceArgs{
+			Rates: &mock.RateRepository{},
+		}),
+		LabelService: label.NewService(),
+		ShipmentService: shipment.NewService(shipment.ServiceArgs{
+			Shipments: inmem.NewShipmentRepository(),
+			Inventory: inventoryRepository,
+			Products:  productService,
+		}),
+		InventoryService: inventory.NewService(inventory.ServiceArgs{
+			Inventory: inventoryRepository,
+		}),
+		AuditService: audit.NewService(audit.ServiceArgs{
+			Entries: inmem.NewAuditRepository(),
+		}),
+		Mode: "test",
+	}
+	if customize != nil {
+		customize(&args)
+	}
+	return shippinghttp.NewServer(args)
+}
+
+// newAuthenticator authenticates the API keys, mapped to the principals they are issued to
+func newAuthenticator(t *testing.T, keys map[string]auth.APIKey) *auth.Authenticator {
+	var list []auth.APIKey
+	for key, apiKey := range keys {
+		sum := sha256.Sum256([]byte(key))
+		apiKey.KeySHA256 = hex.EncodeToString(sum[:])
+		list = append(list, apiKey)
+	}
+	data, err := json.Marshal(list)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	a, err := auth.NewAuthenticator(auth.AuthenticatorArgs{APIKeysFile: path})
+	require.NoError(t, err)
+	return a
+}
+
+const tenantHeader = "X-Tenant-ID"
+
+// request is a request made to the server by the client at remoteAddr
+type request struct {
+	method     string
+	path       string
+	header     map[string]string
+	remoteAddr string
+}
+
+func serve(s *shippinghttp.Server, r request) *httptest.ResponseRecorder {
+	return serveBody(s, r, "")
+}
+
+func serveBody(s *shippinghttp.Server, r request, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(r.method, r.path, strings.NewReader(body))
+	if r.remoteAddr != "" {
+		req.RemoteAddr = r.remoteAddr
+	}
+	for k, v := range r.header {
+		req.Header.Set(k, v)
+	}
+	res := httptest.NewRecorder()
+	s.ServeHTTP(res, req)
+	return res
+}
+
+func TestServer_Ready(t *testing.T) {
+	s := newServer(nil)
+	res := serve(s, request{method: http.MethodGet, path: "/ready"})
+	require.Equal(t, http.StatusOK, res.Code)
+}
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/auth"
+	"github.com/silvan-talos/shipping/shipment"
+)
+
+type shipmentHandler struct {
+	ss shipment.Service
+}
+
+type planShipmentRequest struct {
+	OrderID   string `json:"order_id" binding:"required"`
+	Warehouse string `json:"warehouse" binding:"required"`
+	ProductID uint64 `json:"product_id" binding:"required"`
+	Quantity  uint64 `json:"quantity" binding:"required"`
+}
+
+func (sh *shipmentHandler) addRoutes(r *gin.RouterGroup) {
+	r.POST("", requireScope(auth.ScopeShipmentsWrite), sh.planShipment)
+	r.GET("", requireScope(auth.ScopeShipmentsRead), sh.findShipments)
+	r.GET("/:id", requireScope(auth.ScopeShipmentsRead), sh.getShipment)
+	r.POST("/:id/pack", requireScope(auth.ScopeShipmentsWrite), sh.updateStatus(shipping.StatusPacked))
+	r.POST("/:id/dispatch", requireScope(auth.ScopeShipmentsWrite), sh.updateStatus(shipping.StatusDispatched))
+	r.POST("/:id/deliver", requireScope(auth.ScopeShipmentsWrite), sh.updateStatus(shipping.StatusDelivered))
+	r.POST("/:id/cancel", requireScope(auth.ScopeShipmentsWrite), sh.updateStatus(shipping.StatusCancelled))
+}
+
+//	@Summary		Plan shipment
+//	@Description	Calculates the packing for an order, reserves the packs from the warehouse stock and records it as a planned shipment.
+//	@Description	When the recommended packs are not in stock, the packing is replanned with the pack sizes available.
+//	@Description	Requires the shipments:write scope.
+//	@Tags			shipments
+//	@Accept			json
+//	@Produce		json
+//	@Param			request			body		planShipmentRequest	true	"Order to plan the shipment for"
+//	@Param			Idempotency-Key	header		string				false	"Replays the response of the first request made with the same key for 24h"
+//	@Success		201				{object}	shipping.Shipment
+//	@Failure		400				{object}	object{error=string}
+//	@Failure		401				{object}	object{error=string}
+//	@Failure		403				{object}	object{error=string}
+//	@Failure		404
+//	@Failure		409	{object}	object{error=string}
+//	@Failure		429	{object}	object{error=string}	"Rate limit exceeded, retry after the seconds of the Retry-After header"
+//	@Failure		500
+//	@Security		ApiKey
+//	@Security		BearerToken
+//	@Router			/v1/shipments [post]
+func (sh *shipmentHandler) planShipment(c *gin.Context) {
+	var req planShipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	resp, err := sh.ss.PlanShipment(c.Request.Context(), req.OrderID, req.Warehouse, req.ProductID, req.Quantity)
+	if err != nil {
+		abortWithShipmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+//	@Summary		Find shipments
+//	@Description	Lists the shipments planned for an order
+//	@Description	Requires the shipments:read scope.
+//	@Tags			shipments
+//	@Produce		json
+//	@Param			order_id	query		string	true	"ID of the order"
+//	@Success		200			{object}	[]shipping.Shipment
+//	@Failure		400			{object}	object{error=string}
+//	@Failure		401			{object}	object{error=string}
+//	@Failure		403			{object}	object{error=string}
+//	@Failure		429			{object}	object{error=string}	"Rate limit exceeded, retry after the seconds of the Retry-After header"
+//	@Failure		500
+//	@Security		ApiKey
+//	@Security		BearerToken
+//	@Router			/v1/shipments [get]
+func (sh *shipmentHandler) findShipments(c *gin.Context) {
+	resp, err := sh.ss.FindShipments(c.Request.Context(), c.Query("order_id"))
+	if err != nil {
+		abortWithShipmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+//	@Summary		Get shipment
+//	@Description	Requires the shipments:read scope.
+//	@Tags			shipments
+//	@Produce		json
+//	@Param			id	path		int64	true	"ID of the shipment"
+//	@Success		200	{object}	shipping.Shipment
+//	@Failure		400	{object}	object{error=string}
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
+//	@Failure		404
+//	@Failure		429	{object}	object{error=string}	"Rate limit exceeded, retry after the seconds of the Retry-After header"
+//	@Failure		500
+//	@Security		ApiKey
+//	@Security		BearerToken
+//	@Router			/v1/shipments/{id} [get]
+func (sh *shipmentHandler) getShipment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid shipment ID"})
+		return
+	}
+	resp, err := sh.ss.GetShipment(c.Request.Context(), id)
+	if err != nil {
+		abortWithShipmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+//	@Summary		Update shipment status
+//	@Description	Moves the shipment through its lifecycle: planned, packed, dispatched, delivered or cancelled
+//	@Description	Requires the shipments:write scope.
+//	@Tags			shipments
+//	@Produce		json
+//	@Param			id				path		int64	true	"ID of the shipment"
+//	@Param			action			path		string	true	"Lifecycle action"	Enums(pack, dispatch, deliver, cancel)
+//	@Param			Idempotency-Key	header		string	false	"Replays the response of the first request made with the same key for 24h"
+//	@Success		200				{object}	shipping.Shipment
+//	@Failure		400				{object}	object{error=string}
+//	@Failure		401				{object}	object{error=string}
+//	@Failure		403				{object}	object{error=string}
+//	@Failure		404
+//	@Failure		409	{object}	object{error=string}
+//	@Failure		429	{object}	object{error=string}	"Rate limit exceeded, retry after the seconds of the Retry-After header"
+//	@Failure		500
+//	@Security		ApiKey
+//	@Security		BearerToken
+//	@Router			/v1/shipments/{id}/{action} [post]
+func (sh *shipmentHandler) updateStatus(status shipping.ShipmentStatus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid shipment ID"})
+			return
+		}
+		resp, err := sh.ss.UpdateStatus(c.Request.Context(), id, status)
+		if err != nil {
+			abortWithShipmentError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func abortWithShipmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, shipping.InternalServerErr):
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error occurred"})
+	case errors.Is(err, shipping.ErrNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, shipping.ErrForbidden):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, shipping.ErrInvalidTransition), errors.Is(err, shipping.ErrInsufficientStock),
+		errors.Is(err, shipping.ErrStatusChanged):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
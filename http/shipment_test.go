@@ -0,0 +1,130 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+)
+
+func TestServer_Shipments(t *testing.T) {
+	tests := map[string]struct {
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		// expectedShipment is the status of the shipment returned, expectedError the error otherwise
+		expectedShipment shipping.ShipmentStatus
+		expectedError    string
+	}{
+		"planShipment": {
+			method:           http.MethodPost,
+			path:             "/v1/shipments",
+			body:             `{"order_id": "order-2", "warehouse": "w1", "product_id": 1, "quantity": 251}`,
+			expectedStatus:   http.StatusCreated,
+			expectedShipment: shipping.StatusPlanned,
+		},
+		"planShipmentWithoutOrder_badRequest": {
+			method:         http.MethodPost,
+			path:           "/v1/shipments",
+			body:           `{"warehouse": "w1", "product_id": 1, "quantity": 251}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid body",
+		},
+		"planShipmentAboveStock_conflict": {
+			method:         http.MethodPost,
+			path:           "/v1/shipments",
+			body:           `{"order_id": "order-2", "warehouse": "w1", "product_id": 1, "quantity": 100000}`,
+			expectedStatus: http.StatusConflict,
+			expectedError:  shipping.ErrInsufficientStock.Error(),
+		},
+		"planShipmentInUnknownWarehouse_notFound": {
+			method:         http.MethodPost,
+			path:           "/v1/shipments",
+			body:           `{"order_id": "order-2", "warehouse": "w2", "product_id": 1, "quantity": 251}`,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "not found",
+		},
+		"getShipment": {
+			method:           http.MethodGet,
+			path:             "/v1/shipments/1",
+			expectedStatus:   http.StatusOK,
+			expectedShipment: shipping.StatusPlanned,
+		},
+		"getUnknownShipment_notFound": {
+			method:         http.MethodGet,
+			path:           "/v1/shipments/2",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "not found",
+		},
+		"getShipmentWithInvalidID_badRequest": {
+			method:         http.MethodGet,
+			path:           "/v1/shipments/first",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid shipment ID",
+		},
+		"packShipment": {
+			method:           http.MethodPost,
+			path:             "/v1/shipments/1/pack",
+			expectedStatus:   http.StatusOK,
+			expectedShipment: shipping.StatusPacked,
+		},
+		"deliverPlannedShipment_conflict": {
+			method:         http.MethodPost,
+			path:           "/v1/shipments/1/deliver",
+			expectedStatus: http.StatusConflict,
+			expectedError:  "invalid shipment status transition: planned to delivered",
+		},
+		"cancelUnknownShipment_notFound": {
+			method:         http.MethodPost,
+			path:           "/v1/shipments/2/cancel",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "not found",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newServer(nil)
+			header := map[string]string{tenantHeader: "tenant-1"}
+			res := serveBody(s, request{method: http.MethodPut, path: "/v1/warehouses/w1/stock", header: header}, `{"250": 10, "500": 10}`)
+			require.Equal(t, http.StatusNoContent, res.Code)
+			res = serveBody(s, request{method: http.MethodPost, path: "/v1/shipments", header: header},
+				`{"order_id": "order-1", "warehouse": "w1", "product_id": 1, "quantity": 501}`)
+			require.Equal(t, http.StatusCreated, res.Code)
+
+			res = serveBody(s, request{method: tc.method, path: tc.path, header: header}, tc.body)
+			require.Equal(t, tc.expectedStatus, res.Code)
+			if tc.expectedError != "" {
+				require.JSONEq(t, `{"error": "`+tc.expectedError+`"}`, res.Body.String())
+				return
+			}
+			var shipment shipping.Shipment
+			require.NoError(t, json.Unmarshal(res.Body.Bytes(), &shipment))
+			require.Equal(t, tc.expectedShipment, shipment.Status)
+		})
+	}
+}
+
+func TestServer_FindShipments(t *testing.T) {
+	s := newServer(nil)
+	header := map[string]string{tenantHeader: "tenant-1"}
+	res := serveBody(s, request{method: http.MethodPut, path: "/v1/warehouses/w1/stock", header: header}, `{"250": 10, "500": 10}`)
+	require.Equal(t, http.StatusNoContent, res.Code)
+	for _, order := range []string{"order-1", "order-1", "order-2"} {
+		res = serveBody(s, request{method: http.MethodPost, path: "/v1/shipments", header: header},
+			`{"order_id": "`+order+`", "warehouse": "w1", "product_id": 1, "quantity": 251}`)
+		require.Equal(t, http.StatusCreated, res.Code)
+	}
+
+	res = serve(s, request{method: http.MethodGet, path: "/v1/shipments?order_id=order-1", header: header})
+	require.Equal(t, http.StatusOK, res.Code)
+	var shipments []shipping.Shipment
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &shipments))
+	require.Len(t, shipments, 2)
+	for _, shipment := range shipments {
+		require.Equal(t, "order-1", shipment.OrderID)
+	}
+}
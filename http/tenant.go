@@ -0,0 +1,39 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/auth"
+)
+
+// tenantHeader selects the tenant of principals that may act for any tenant
+const tenantHeader = "X-Tenant-ID"
+
+// scopeToTenant scopes the request context to the tenant of the principal, or to the one of the tenant
+// header when the principal is not bound to a tenant and may act for any, rejecting requests without one
+func scopeToTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenant := c.GetHeader(tenantHeader)
+		principal, _ := shipping.PrincipalFromContext(c.Request.Context())
+		switch {
+		case principal.Tenant != "":
+			if tenant != "" && tenant != principal.Tenant {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "tenant not allowed"})
+				return
+			}
+			tenant = principal.Tenant
+		case !principal.HasScope(auth.ScopeTenantsAdmin):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "principal not bound to a tenant"})
+			return
+		}
+		if tenant == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "tenant missing"})
+			return
+		}
+		c.Request = c.Request.WithContext(shipping.WithTenant(c.Request.Context(), tenant))
+		c.Next()
+	}
+}
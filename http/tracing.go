@@ -0,0 +1,40 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/semconv/v1.17.0/httpconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceRequests starts a span for every request, continuing the trace of the caller when the request carries
+// a W3C traceparent header. Spans are recorded by the global tracer provider, a no-op one unless tracing
+// is enabled.
+func traceRequests() gin.HandlerFunc {
+	tracer := otel.Tracer("github.com/silvan-talos/shipping/http")
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		name := c.Request.Method + " " + route
+		if route == "" {
+			name = c.Request.Method
+		}
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(httpconv.ServerRequest("", c.Request)...),
+		)
+		defer span.End()
+		if route != "" {
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		span.SetStatus(httpconv.ServerStatus(status))
+	}
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/auth"
+	"github.com/silvan-talos/shipping/inventory"
+)
+
+type warehouseHandler struct {
+	is inventory.Service
+}
+
+func (wh *warehouseHandler) addRoutes(r *gin.RouterGroup) {
+	r.GET("/:warehouse/stock", requireScope(auth.ScopeInventoryRead), wh.getStock)
+	r.PUT("/:warehouse/stock", requireScope(auth.ScopeInventoryWrite), wh.updateStock)
+}
+
+//	@Summary		Get packaging stock
+//	@Description	Returns the packaging materials in stock, as pack size to count
+//	@Description	Requires the inventory:read scope.
+//	@Tags			warehouses
+//	@Produce		json
+//	@Param			warehouse	path		string	true	"ID of the warehouse"
+//	@Success		200			{object}	map[string]int64
+//	@Failure		401			{object}	object{error=string}
+//	@Failure		403			{object}	object{error=string}
+//	@Failure		404
+//	@Failure		429	{object}	object{error=string}	"Rate limit exceeded, retry after the seconds of the Retry-After header"
+//	@Failure		500
+//	@Security		ApiKey
+//	@Security		BearerToken
+//	@Router			/v1/warehouses/{warehouse}/stock [get]
+func (wh *warehouseHandler) getStock(c *gin.Context) {
+	resp, err := wh.is.GetStock(c.Request.Context(), c.Param("warehouse"))
+	if err != nil {
+		switch {
+		case errors.Is(err, shipping.ErrNotFound):
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "no stock found for the specified warehouse"})
+			return
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error occurred"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+//	@Summary		Update packaging stock
+//	@Description	Sets the stock of the provided pack sizes, other sizes are left unchanged
+//	@Description	Requires the inventory:write scope.
+//	@Tags			warehouses
+//	@Accept			json
+//	@Param			warehouse		path	string				true	"ID of the warehouse"
+//	@Param			stock			body	map[string]int64	true	"Pack size to count"
+//	@Param			Idempotency-Key	header	string				false	"Replays the response of the first request made with the same key for 24h"
+//	@Success		204
+//	@Failure		400	{object}	object{error=string}
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
+//	@Failure		429	{object}	object{error=string}	"Rate limit exceeded, retry after the seconds of the Retry-After header"
+//	@Failure		500
+//	@Security		ApiKey
+//	@Security		BearerToken
+//	@Router			/v1/warehouses/{warehouse}/stock [put]
+func (wh *warehouseHandler) updateStock(c *gin.Context) {
+	var req map[uint64]int64
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	err := wh.is.UpdateStock(c.Request.Context(), c.Param("warehouse"), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, shipping.InternalServerErr):
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error occurred"})
+			return
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,105 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	shippinghttp "github.com/silvan-talos/shipping/http"
+	"github.com/silvan-talos/shipping/inventory"
+	"github.com/silvan-talos/shipping/mock"
+)
+
+func TestServer_Stock(t *testing.T) {
+	tests := map[string]struct {
+		method         string
+		path           string
+		body           string
+		inventory      *mock.InventoryRepository
+		expectedStatus int
+		expectedBody   string
+	}{
+		"getStock": {
+			method:         http.MethodGet,
+			path:           "/v1/warehouses/w1/stock",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"250": 10, "500": 0}`,
+		},
+		"getStockOfUnknownWarehouse_notFound": {
+			method:         http.MethodGet,
+			path:           "/v1/warehouses/w2/stock",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error": "no stock found for the specified warehouse"}`,
+		},
+		"getStockFailed_internalError": {
+			method: http.MethodGet,
+			path:   "/v1/warehouses/w1/stock",
+			inventory: &mock.InventoryRepository{
+				GetStockFn: func(ctx context.Context, warehouse string) (map[uint64]int64, error) {
+					return nil, errors.New("failed to get stock")
+				},
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error": "internal error occurred"}`,
+		},
+		"updateStock": {
+			method:         http.MethodPut,
+			path:           "/v1/warehouses/w1/stock",
+			body:           `{"250": 5}`,
+			expectedStatus: http.StatusNoContent,
+		},
+		"updateStockWithInvalidBody_badRequest": {
+			method:         http.MethodPut,
+			path:           "/v1/warehouses/w1/stock",
+			body:           `{"large": 5}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error": "invalid body"}`,
+		},
+		"updateStockWithNegativeCount_badRequest": {
+			method:         http.MethodPut,
+			path:           "/v1/warehouses/w1/stock",
+			body:           `{"250": -1}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error": "invalid stock: pack sizes must be positive and counts cannot be negative"}`,
+		},
+		"updateStockFailed_internalError": {
+			method: http.MethodPut,
+			path:   "/v1/warehouses/w1/stock",
+			body:   `{"250": 5}`,
+			inventory: &mock.InventoryRepository{
+				UpdateStockFn: func(ctx context.Context, warehouse string, stock map[uint64]int64) error {
+					return errors.New("failed to update stock")
+				},
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error": "internal error occurred"}`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newServer(func(args *shippinghttp.ServerArgs) {
+				if tc.inventory != nil {
+					args.InventoryService = inventory.NewService(inventory.ServiceArgs{
+						Inventory: tc.inventory,
+					})
+				}
+			})
+			header := map[string]string{tenantHeader: "tenant-1"}
+			if tc.inventory == nil {
+				res := serveBody(s, request{method: http.MethodPut, path: "/v1/warehouses/w1/stock", header: header}, `{"250": 10, "500": 0}`)
+				require.Equal(t, http.StatusNoContent, res.Code)
+			}
+
+			res := serveBody(s, request{method: tc.method, path: tc.path, header: header}, tc.body)
+			require.Equal(t, tc.expectedStatus, res.Code)
+			if tc.expectedBody == "" {
+				require.Empty(t, res.Body.String())
+				return
+			}
+			require.JSONEq(t, tc.expectedBody, res.Body.String())
+		})
+	}
+}
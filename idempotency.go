@@ -0,0 +1,38 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrIdempotencyStoreFull is returned by stores bounded in size when every record they keep is still in
+// progress, evicting one would let its request be processed again.
+var ErrIdempotencyStoreFull = errors.New("idempotency store full")
+
+// IdempotentRecord is the outcome of the first request made with an idempotency key. Fingerprint identifies
+// the request, so a retry can be told apart from another request reusing the key.
+type IdempotentRecord struct {
+	Fingerprint string
+	// Completed is false while the first request is still being processed
+	Completed bool
+	Status    int
+	Header    map[string][]string
+	Body      []byte
+}
+
+// IdempotencyStore keeps the records of idempotency keys until they expire. Reserve must be atomic,
+// so instances sharing a store process a key only once.
+type IdempotencyStore interface {
+	// Reserve records the key as in progress for the fingerprint, unless the key is already recorded,
+	// in which case the existing record is returned with reserved false. Stores bounded in size may return
+	// ErrIdempotencyStoreFull, the request is then rejected rather than processed without a reservation.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (record IdempotentRecord, reserved bool, err error)
+	// Extend postpones the expiry of the in-progress record of the key, while the request is still processed.
+	// Completed and missing records are left as they are.
+	Extend(ctx context.Context, key string, ttl time.Duration) error
+	// Complete replaces the in-progress record of the key with the outcome of the request.
+	Complete(ctx context.Context, key string, record IdempotentRecord, ttl time.Duration) error
+	// Release forgets the key, so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
@@ -16,6 +16,14 @@ resource "aws_ecs_task_definition" "shipping" {
           containerPort = 8080
           hostPort      = 8080
       }]
+      # readiness fails long enough for the health checks to notice, then requests drain for up to 20s
+      environment = [
+        {
+          name  = "SHIPPING_HTTP_SHUTDOWN_DELAY"
+          value = "15s"
+        }
+      ]
+      stopTimeout = 45
     }
   ])
   requires_compatibilities = ["FARGATE"]
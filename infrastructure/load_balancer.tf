@@ -11,9 +11,12 @@ resource "aws_lb_target_group" "shipping" {
   protocol    = "HTTP"
   target_type = "ip"
   vpc_id      = var.vpc_id
+  # /ready fails while the tasks drain, so they are taken out of rotation before they stop
   health_check {
-    path     = "/ping"
-    interval = 300
+    path                = "/ready"
+    interval            = 5
+    healthy_threshold   = 2
+    unhealthy_threshold = 2
   }
 }
 
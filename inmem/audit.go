@@ -0,0 +1,43 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+
+	"github.com/silvan-talos/shipping"
+)
+
+func NewAuditRepository() shipping.AuditRepository {
+	return &auditRepository{}
+}
+
+type auditRepository struct {
+	mtx     sync.RWMutex
+	entries []shipping.AuditEntry
+}
+
+func (ar *auditRepository) Store(_ context.Context, entry shipping.AuditEntry) (uint64, error) {
+	ar.mtx.Lock()
+	defer ar.mtx.Unlock()
+	entry.ID = uint64(len(ar.entries)) + 1
+	ar.entries = append(ar.entries, cloneAuditEntry(entry))
+	return entry.ID, nil
+}
+
+func (ar *auditRepository) Find(_ context.Context, query shipping.AuditQuery) ([]shipping.AuditEntry, error) {
+	ar.mtx.RLock()
+	defer ar.mtx.RUnlock()
+	entries := make([]shipping.AuditEntry, 0)
+	for i := len(ar.entries) - 1; i >= 0 && (query.Limit == 0 || len(entries) < query.Limit); i-- {
+		if query.Matches(ar.entries[i]) {
+			entries = append(entries, cloneAuditEntry(ar.entries[i]))
+		}
+	}
+	return entries, nil
+}
+
+func cloneAuditEntry(entry shipping.AuditEntry) shipping.AuditEntry {
+	entry.Before = append([]uint64(nil), entry.Before...)
+	entry.After = append([]uint64(nil), entry.After...)
+	return entry
+}
@@ -0,0 +1,142 @@
+package inmem
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// NewIdempotencyStore keeps the records in memory, so retries must reach the same instance. At most maxEntries
+// records are kept, it must be positive. The oldest expired or completed records are evicted first, while the
+// records in progress are never evicted, so keys are rejected with shipping.ErrIdempotencyStoreFull once
+// every record is in progress.
+func NewIdempotencyStore(maxEntries int) shipping.IdempotencyStore {
+	return &idempotencyStore{
+		maxEntries: maxEntries,
+		records:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+type idempotencyStore struct {
+	maxEntries int
+
+	mtx     sync.Mutex
+	records map[string]*list.Element
+	// order holds the records by reservation, the records reserved first expire first
+	order *list.List
+	takes int
+}
+
+type idempotentRecord struct {
+	key string
+	shipping.IdempotentRecord
+	expiresAt time.Time
+}
+
+func (is *idempotencyStore) Reserve(_ context.Context, key, fingerprint string, ttl time.Duration) (shipping.IdempotentRecord, bool, error) {
+	is.mtx.Lock()
+	defer is.mtx.Unlock()
+	now := time.Now()
+	is.takes++
+	if is.takes%sweepInterval == 0 {
+		is.sweep(now)
+	}
+	if el, ok := is.records[key]; ok {
+		r := el.Value.(*idempotentRecord)
+		if now.Before(r.expiresAt) {
+			return cloneIdempotentRecord(r.IdempotentRecord), false, nil
+		}
+		is.remove(el)
+	}
+	if !is.makeRoom(now) {
+		return shipping.IdempotentRecord{}, false, shipping.ErrIdempotencyStoreFull
+	}
+	is.records[key] = is.order.PushBack(&idempotentRecord{
+		key:              key,
+		IdempotentRecord: shipping.IdempotentRecord{Fingerprint: fingerprint},
+		expiresAt:        now.Add(ttl),
+	})
+	return shipping.IdempotentRecord{}, true, nil
+}
+
+func (is *idempotencyStore) Extend(_ context.Context, key string, ttl time.Duration) error {
+	is.mtx.Lock()
+	defer is.mtx.Unlock()
+	if el, ok := is.records[key]; ok {
+		if r := el.Value.(*idempotentRecord); !r.Completed {
+			r.expiresAt = time.Now().Add(ttl)
+		}
+	}
+	return nil
+}
+
+func (is *idempotencyStore) Complete(_ context.Context, key string, record shipping.IdempotentRecord, ttl time.Duration) error {
+	is.mtx.Lock()
+	defer is.mtx.Unlock()
+	if el, ok := is.records[key]; ok {
+		is.remove(el)
+	}
+	now := time.Now()
+	if !is.makeRoom(now) {
+		return shipping.ErrIdempotencyStoreFull
+	}
+	is.records[key] = is.order.PushBack(&idempotentRecord{
+		key:              key,
+		IdempotentRecord: cloneIdempotentRecord(record),
+		expiresAt:        now.Add(ttl),
+	})
+	return nil
+}
+
+func (is *idempotencyStore) Release(_ context.Context, key string) error {
+	is.mtx.Lock()
+	defer is.mtx.Unlock()
+	if el, ok := is.records[key]; ok {
+		is.remove(el)
+	}
+	return nil
+}
+
+func (is *idempotencyStore) sweep(now time.Time) {
+	for el := is.order.Front(); el != nil; {
+		next := el.Next()
+		if r := el.Value.(*idempotentRecord); !now.Before(r.expiresAt) {
+			is.remove(el)
+		}
+		el = next
+	}
+}
+
+// makeRoom evicts the oldest expired or completed record when the store is full, telling whether a record
+// can be added
+func (is *idempotencyStore) makeRoom(now time.Time) bool {
+	if is.order.Len() < is.maxEntries {
+		return true
+	}
+	for el := is.order.Front(); el != nil; el = el.Next() {
+		if r := el.Value.(*idempotentRecord); r.Completed || !now.Before(r.expiresAt) {
+			is.remove(el)
+			return true
+		}
+	}
+	return false
+}
+
+func (is *idempotencyStore) remove(el *list.Element) {
+	is.order.Remove(el)
+	delete(is.records, el.Value.(*idempotentRecord).key)
+}
+
+func cloneIdempotentRecord(r shipping.IdempotentRecord) shipping.IdempotentRecord {
+	header := make(map[string][]string, len(r.Header))
+	for k, v := range r.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	r.Header = header
+	r.Body = append([]byte(nil), r.Body...)
+	return r
+}
@@ -0,0 +1,112 @@
+package inmem_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/inmem"
+)
+
+func TestIdempotencyStore(t *testing.T) {
+	store := inmem.NewIdempotencyStore(10)
+	ctx := context.Background()
+
+	_, reserved, err := store.Reserve(ctx, "key-1", "fp-1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	record, reserved, err := store.Reserve(ctx, "key-1", "fp-1", time.Minute)
+	require.NoError(t, err)
+	require.False(t, reserved)
+	require.Equal(t, shipping.IdempotentRecord{Fingerprint: "fp-1", Header: map[string][]string{}}, record)
+
+	completed := shipping.IdempotentRecord{
+		Fingerprint: "fp-1",
+		Completed:   true,
+		Status:      201,
+		Header:      map[string][]string{"Content-Type": {"application/json"}},
+		Body:        []byte(`{"id":1}`),
+	}
+	require.NoError(t, store.Complete(ctx, "key-1", completed, time.Minute))
+	record, reserved, err = store.Reserve(ctx, "key-1", "fp-2", time.Minute)
+	require.NoError(t, err)
+	require.False(t, reserved)
+	require.Equal(t, completed, record)
+
+	require.NoError(t, store.Release(ctx, "key-1"))
+	_, reserved, err = store.Reserve(ctx, "key-1", "fp-2", time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	// expired records are forgotten
+	time.Sleep(5 * time.Millisecond)
+	_, reserved, err = store.Reserve(ctx, "key-1", "fp-3", time.Minute)
+	require.NoError(t, err)
+	require.True(t, reserved)
+}
+
+func TestIdempotencyStore_Extend(t *testing.T) {
+	store := inmem.NewIdempotencyStore(10)
+	ctx := context.Background()
+
+	_, reserved, err := store.Reserve(ctx, "key-1", "fp-1", time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	require.NoError(t, store.Extend(ctx, "key-1", time.Minute))
+
+	// the request is still in progress once the reservation would have expired
+	time.Sleep(5 * time.Millisecond)
+	record, reserved, err := store.Reserve(ctx, "key-1", "fp-1", time.Minute)
+	require.NoError(t, err)
+	require.False(t, reserved)
+	require.False(t, record.Completed)
+
+	// completed records keep their TTL
+	require.NoError(t, store.Complete(ctx, "key-1", shipping.IdempotentRecord{Fingerprint: "fp-1", Completed: true}, time.Millisecond))
+	require.NoError(t, store.Extend(ctx, "key-1", time.Minute))
+	time.Sleep(5 * time.Millisecond)
+	_, reserved, err = store.Reserve(ctx, "key-1", "fp-2", time.Minute)
+	require.NoError(t, err)
+	require.True(t, reserved)
+}
+
+func TestIdempotencyStore_MaxEntries(t *testing.T) {
+	store := inmem.NewIdempotencyStore(2)
+	ctx := context.Background()
+
+	for _, key := range []string{"key-1", "key-2"} {
+		_, reserved, err := store.Reserve(ctx, key, "fp", time.Minute)
+		require.NoError(t, err)
+		require.True(t, reserved)
+	}
+	// records in progress are never evicted, their retries would be processed again
+	_, _, err := store.Reserve(ctx, "key-3", "fp", time.Minute)
+	require.ErrorIs(t, err, shipping.ErrIdempotencyStoreFull)
+
+	// the completed record is evicted to make room for the third key, the one in progress is kept
+	require.NoError(t, store.Complete(ctx, "key-1", shipping.IdempotentRecord{Fingerprint: "fp", Completed: true}, time.Hour))
+	_, reserved, err := store.Reserve(ctx, "key-3", "fp", time.Minute)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	record, reserved, err := store.Reserve(ctx, "key-2", "fp", time.Minute)
+	require.NoError(t, err)
+	require.False(t, reserved)
+	require.False(t, record.Completed)
+
+	// expired reservations are evicted too
+	require.NoError(t, store.Release(ctx, "key-3"))
+	_, reserved, err = store.Reserve(ctx, "key-4", "fp", time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	time.Sleep(5 * time.Millisecond)
+	_, reserved, err = store.Reserve(ctx, "key-5", "fp", time.Minute)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	_, reserved, err = store.Reserve(ctx, "key-2", "fp", time.Minute)
+	require.NoError(t, err)
+	require.False(t, reserved)
+}
@@ -0,0 +1,105 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// NewInventoryRepository creates a repository keeping the stock of every tenant apart
+func NewInventoryRepository() shipping.InventoryRepository {
+	return &inventoryRepository{
+		tenants: make(map[string]map[string]map[uint64]int64),
+	}
+}
+
+type inventoryRepository struct {
+	mtx sync.RWMutex
+	// tenants holds the stock of the warehouses of every tenant
+	tenants map[string]map[string]map[uint64]int64
+}
+
+func (ir *inventoryRepository) GetStock(ctx context.Context, warehouse string) (map[uint64]int64, error) {
+	ir.mtx.RLock()
+	defer ir.mtx.RUnlock()
+	stock, err := ir.warehouseStock(ctx, warehouse)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[uint64]int64, len(stock))
+	for size, count := range stock {
+		res[size] = count
+	}
+	return res, nil
+}
+
+func (ir *inventoryRepository) UpdateStock(ctx context.Context, warehouse string, stock map[uint64]int64) error {
+	ir.mtx.Lock()
+	defer ir.mtx.Unlock()
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return shipping.ErrMissingTenant
+	}
+	warehouses, ok := ir.tenants[tenant]
+	if !ok {
+		warehouses = make(map[string]map[uint64]int64)
+		ir.tenants[tenant] = warehouses
+	}
+	if _, ok := warehouses[warehouse]; !ok {
+		warehouses[warehouse] = make(map[uint64]int64, len(stock))
+	}
+	for size, count := range stock {
+		warehouses[warehouse][size] = count
+	}
+	return nil
+}
+
+func (ir *inventoryRepository) Reserve(ctx context.Context, warehouse string, packs []shipping.PackConfig) error {
+	ir.mtx.Lock()
+	defer ir.mtx.Unlock()
+	stock, err := ir.warehouseStock(ctx, warehouse)
+	if err != nil {
+		return err
+	}
+	needed := make(map[uint64]int64, len(packs))
+	for _, p := range packs {
+		needed[p.Size] += p.Count
+	}
+	// check all the packs before taking any of them
+	for size, count := range needed {
+		if stock[size] < count {
+			return shipping.ErrInsufficientStock
+		}
+	}
+	for size, count := range needed {
+		stock[size] -= count
+	}
+	return nil
+}
+
+func (ir *inventoryRepository) Release(ctx context.Context, warehouse string, packs []shipping.PackConfig) error {
+	ir.mtx.Lock()
+	defer ir.mtx.Unlock()
+	stock, err := ir.warehouseStock(ctx, warehouse)
+	if err != nil {
+		return err
+	}
+	for _, p := range packs {
+		stock[p.Size] += p.Count
+	}
+	return nil
+}
+
+// warehouseStock returns the stock of the warehouse of the tenant ctx is scoped to, callers must hold the lock
+func (ir *inventoryRepository) warehouseStock(ctx context.Context, warehouse string) (map[uint64]int64, error) {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return nil, shipping.ErrMissingTenant
+	}
+	stock, ok := ir.tenants[tenant][warehouse]
+	if !ok {
+		return nil, shipping.ErrNotFound
+	}
+	return stock, nil
+}
@@ -0,0 +1,144 @@
+package inmem_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/inmem"
+)
+
+func TestInventoryRepository_Reserve(t *testing.T) {
+	tests := map[string]struct {
+		packs         []shipping.PackConfig
+		expectedStock map[uint64]int64
+		expectedErr   error
+	}{
+		"enoughStock_reserved": {
+			packs:         []shipping.PackConfig{{Count: 2, Size: 500}, {Count: 1, Size: 250}},
+			expectedStock: map[uint64]int64{250: 4, 500: 0},
+		},
+		"sameSizeTwice_reservedOnce": {
+			packs:         []shipping.PackConfig{{Count: 1, Size: 500}, {Count: 1, Size: 500}},
+			expectedStock: map[uint64]int64{250: 5, 500: 0},
+		},
+		"notEnoughOfOneSize_nothingReserved": {
+			packs:         []shipping.PackConfig{{Count: 1, Size: 250}, {Count: 3, Size: 500}},
+			expectedStock: map[uint64]int64{250: 5, 500: 2},
+			expectedErr:   shipping.ErrInsufficientStock,
+		},
+		"unknownSize_nothingReserved": {
+			packs:         []shipping.PackConfig{{Count: 1, Size: 1000}},
+			expectedStock: map[uint64]int64{250: 5, 500: 2},
+			expectedErr:   shipping.ErrInsufficientStock,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := shipping.WithTenant(context.Background(), "tenant-1")
+			repo := inmem.NewInventoryRepository()
+			require.NoError(t, repo.UpdateStock(ctx, "w1", map[uint64]int64{250: 5, 500: 2}))
+			err := repo.Reserve(ctx, "w1", tc.packs)
+			require.Equal(t, tc.expectedErr, err, "errors must match")
+			stock, err := repo.GetStock(ctx, "w1")
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStock, stock, "stock must match")
+		})
+	}
+}
+
+func TestInventoryRepository_ReserveConcurrently(t *testing.T) {
+	ctx := shipping.WithTenant(context.Background(), "tenant-1")
+	repo := inmem.NewInventoryRepository()
+	require.NoError(t, repo.UpdateStock(ctx, "w1", map[uint64]int64{250: 100, 500: 50}))
+
+	var reserved, rejected int64
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := repo.Reserve(ctx, "w1", []shipping.PackConfig{{Count: 1, Size: 500}, {Count: 2, Size: 250}})
+			switch {
+			case err == nil:
+				atomic.AddInt64(&reserved, 1)
+			case errors.Is(err, shipping.ErrInsufficientStock):
+				atomic.AddInt64(&rejected, 1)
+			default:
+				t.Error("unexpected error:", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int64(50), reserved)
+	require.Equal(t, int64(150), rejected)
+	stock, err := repo.GetStock(ctx, "w1")
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]int64{250: 0, 500: 0}, stock, "stock must never go negative")
+}
+
+func TestInventoryRepository_ReserveAndReleaseConcurrently(t *testing.T) {
+	ctx := shipping.WithTenant(context.Background(), "tenant-1")
+	repo := inmem.NewInventoryRepository()
+	require.NoError(t, repo.UpdateStock(ctx, "w1", map[uint64]int64{250: 10}))
+
+	packs := []shipping.PackConfig{{Count: 3, Size: 250}}
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := repo.Reserve(ctx, "w1", packs); err != nil {
+				return
+			}
+			if err := repo.Release(ctx, "w1", packs); err != nil {
+				t.Error("failed to release packs:", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stock, err := repo.GetStock(ctx, "w1")
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]int64{250: 10}, stock, "all reservations must be released")
+}
+
+func TestInventoryRepository_TenantIsolation(t *testing.T) {
+	ctx := context.Background()
+	tenantA := shipping.WithTenant(ctx, "tenant-a")
+	tenantB := shipping.WithTenant(ctx, "tenant-b")
+	repo := inmem.NewInventoryRepository()
+	packs := []shipping.PackConfig{{Count: 1, Size: 250}}
+
+	require.NoError(t, repo.UpdateStock(tenantA, "w1", map[uint64]int64{250: 5}))
+	_, err := repo.GetStock(tenantB, "w1")
+	require.Equal(t, shipping.ErrNotFound, err, "stock of another tenant must not be visible")
+	require.Equal(t, shipping.ErrNotFound, repo.Reserve(tenantB, "w1", packs))
+	require.Equal(t, shipping.ErrNotFound, repo.Release(tenantB, "w1", packs))
+
+	require.NoError(t, repo.UpdateStock(tenantB, "w1", map[uint64]int64{250: 1}))
+	stock, err := repo.GetStock(tenantA, "w1")
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]int64{250: 5}, stock, "tenants must keep their own stock")
+	stock, err = repo.GetStock(tenantB, "w1")
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]int64{250: 1}, stock)
+}
+
+func TestInventoryRepository_TenantMissing(t *testing.T) {
+	ctx := context.Background()
+	repo := inmem.NewInventoryRepository()
+	packs := []shipping.PackConfig{{Count: 1, Size: 250}}
+
+	require.Equal(t, shipping.ErrMissingTenant, repo.UpdateStock(ctx, "w1", map[uint64]int64{250: 5}))
+	_, err := repo.GetStock(ctx, "w1")
+	require.Equal(t, shipping.ErrMissingTenant, err)
+	require.Equal(t, shipping.ErrMissingTenant, repo.Reserve(ctx, "w1", packs))
+	require.Equal(t, shipping.ErrMissingTenant, repo.Release(ctx, "w1", packs))
+}
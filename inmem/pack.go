@@ -7,31 +7,137 @@ import (
 	"github.com/silvan-talos/shipping"
 )
 
-func NewPackRepository() shipping.PackRepository {
+// NewPackRepository creates a repository keeping the configurations of every tenant apart.
+// Tenants start with the same default configuration, which they can then change.
+func NewPackRepository(defaultConfig []uint64) shipping.PackRepository {
 	return &packRepository{
-		configs: make(map[uint64][]uint64),
+		defaultConfig: defaultConfig,
+		tenants:       make(map[string]*tenantConfigs),
 	}
 }
 
+type warehouseKey struct {
+	warehouse string
+	productID uint64
+}
+
+type tenantConfigs struct {
+	defaultConfig    []uint64
+	configs          map[uint64][]uint64
+	warehouseConfigs map[warehouseKey][]uint64
+}
+
 type packRepository struct {
-	mtx     sync.RWMutex
-	configs map[uint64][]uint64
+	mtx           sync.RWMutex
+	defaultConfig []uint64
+	tenants       map[string]*tenantConfigs
 }
 
-func (pr *packRepository) GetByProductID(_ context.Context, productID uint64) ([]uint64, error) {
+func (pr *packRepository) GetByProductID(ctx context.Context, productID uint64) ([]uint64, error) {
 	pr.mtx.RLock()
 	defer pr.mtx.RUnlock()
-	config, ok := pr.configs[productID]
+	tc, err := pr.tenantConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config, ok := tc.configs[productID]
 	if !ok {
-		// return default configuration if none exists
-		return []uint64{250, 500, 1000, 2000, 5000}, nil
+		return nil, shipping.ErrNotFound
 	}
 	return config, nil
 }
 
-func (pr *packRepository) UpdateConfig(_ context.Context, productID uint64, config []uint64) error {
+func (pr *packRepository) GetByWarehouse(ctx context.Context, warehouse string, productID uint64) ([]uint64, error) {
+	pr.mtx.RLock()
+	defer pr.mtx.RUnlock()
+	tc, err := pr.tenantConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config, ok := tc.warehouseConfigs[warehouseKey{warehouse: warehouse, productID: productID}]
+	if !ok {
+		return nil, shipping.ErrNotFound
+	}
+	return config, nil
+}
+
+func (pr *packRepository) GetDefault(ctx context.Context) ([]uint64, error) {
+	pr.mtx.RLock()
+	defer pr.mtx.RUnlock()
+	tc, err := pr.tenantConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tc.defaultConfig, nil
+}
+
+func (pr *packRepository) UpdateConfig(ctx context.Context, productID uint64, config []uint64) ([]uint64, error) {
 	pr.mtx.Lock()
 	defer pr.mtx.Unlock()
-	pr.configs[productID] = config
-	return nil
+	tc, err := pr.tenantConfigsForUpdate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	previous := tc.configs[productID]
+	tc.configs[productID] = config
+	return previous, nil
+}
+
+func (pr *packRepository) UpdateWarehouseConfig(ctx context.Context, warehouse string, productID uint64, config []uint64) ([]uint64, error) {
+	pr.mtx.Lock()
+	defer pr.mtx.Unlock()
+	tc, err := pr.tenantConfigsForUpdate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := warehouseKey{warehouse: warehouse, productID: productID}
+	previous := tc.warehouseConfigs[key]
+	tc.warehouseConfigs[key] = config
+	return previous, nil
+}
+
+func (pr *packRepository) UpdateDefault(ctx context.Context, config []uint64) ([]uint64, error) {
+	pr.mtx.Lock()
+	defer pr.mtx.Unlock()
+	tc, err := pr.tenantConfigsForUpdate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	previous := tc.defaultConfig
+	tc.defaultConfig = config
+	return previous, nil
+}
+
+// tenantConfigs returns the configurations of the tenant ctx is scoped to, callers must hold the read lock
+func (pr *packRepository) tenantConfigs(ctx context.Context) (*tenantConfigs, error) {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return nil, shipping.ErrMissingTenant
+	}
+	tc, ok := pr.tenants[tenant]
+	if !ok {
+		return &tenantConfigs{
+			defaultConfig: pr.defaultConfig,
+		}, nil
+	}
+	return tc, nil
+}
+
+// tenantConfigsForUpdate returns the configurations of the tenant ctx is scoped to, creating them
+// if needed, callers must hold the write lock
+func (pr *packRepository) tenantConfigsForUpdate(ctx context.Context) (*tenantConfigs, error) {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return nil, shipping.ErrMissingTenant
+	}
+	tc, ok := pr.tenants[tenant]
+	if !ok {
+		tc = &tenantConfigs{
+			defaultConfig:    pr.defaultConfig,
+			configs:          make(map[uint64][]uint64),
+			warehouseConfigs: make(map[warehouseKey][]uint64),
+		}
+		pr.tenants[tenant] = tc
+	}
+	return tc, nil
 }
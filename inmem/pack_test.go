@@ -0,0 +1,76 @@
+package inmem_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/inmem"
+)
+
+func TestPackRepository_TenantIsolation(t *testing.T) {
+	ctx := context.Background()
+	tenantA := shipping.WithTenant(ctx, "tenant-a")
+	tenantB := shipping.WithTenant(ctx, "tenant-b")
+	repo := inmem.NewPackRepository(shipping.DefaultPackSizes)
+
+	_, err := repo.UpdateConfig(tenantA, 1, []uint64{100, 200})
+	require.NoError(t, err)
+	_, err = repo.UpdateWarehouseConfig(tenantA, "w1", 1, []uint64{300})
+	require.NoError(t, err)
+	_, err = repo.UpdateDefault(tenantA, []uint64{42})
+	require.NoError(t, err)
+
+	config, err := repo.GetByProductID(tenantA, 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{100, 200}, config)
+	_, err = repo.GetByProductID(tenantB, 1)
+	require.Equal(t, shipping.ErrNotFound, err, "product configured by another tenant must not be visible")
+	_, err = repo.GetByWarehouse(tenantB, "w1", 1)
+	require.Equal(t, shipping.ErrNotFound, err, "warehouse configured by another tenant must not be visible")
+
+	config, err = repo.GetDefault(tenantA)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, config)
+	config, err = repo.GetDefault(tenantB)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{250, 500, 1000, 2000, 5000}, config, "tenants must keep their own defaults")
+}
+
+func TestPackRepository_TenantMissing(t *testing.T) {
+	ctx := context.Background()
+	repo := inmem.NewPackRepository(shipping.DefaultPackSizes)
+
+	_, err := repo.GetByProductID(ctx, 1)
+	require.Equal(t, shipping.ErrMissingTenant, err)
+	_, err = repo.GetDefault(ctx)
+	require.Equal(t, shipping.ErrMissingTenant, err)
+	_, err = repo.UpdateConfig(ctx, 1, []uint64{100})
+	require.Equal(t, shipping.ErrMissingTenant, err)
+}
+
+func TestPackRepository_UpdatePrevious(t *testing.T) {
+	tenantCtx := shipping.WithTenant(context.Background(), "tenant-a")
+	repo := inmem.NewPackRepository([]uint64{250, 500})
+
+	// updates return the configuration they replace, nil when there was none
+	previous, err := repo.UpdateConfig(tenantCtx, 1, []uint64{100})
+	require.NoError(t, err)
+	require.Nil(t, previous)
+	previous, err = repo.UpdateConfig(tenantCtx, 1, []uint64{200})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{100}, previous)
+
+	previous, err = repo.UpdateWarehouseConfig(tenantCtx, "w1", 1, []uint64{300})
+	require.NoError(t, err)
+	require.Nil(t, previous)
+	previous, err = repo.UpdateWarehouseConfig(tenantCtx, "w1", 1, []uint64{400})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{300}, previous)
+
+	previous, err = repo.UpdateDefault(tenantCtx, []uint64{42})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{250, 500}, previous, "the default replaced is the one the tenant used")
+}
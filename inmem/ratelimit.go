@@ -0,0 +1,104 @@
+package inmem
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// sweepInterval is the number of takes between two removals of the idle buckets
+const sweepInterval = 1024
+
+// NewRateLimitStore keeps the buckets in memory, so the limits are enforced per instance.
+func NewRateLimitStore() shipping.RateLimitStore {
+	return &rateLimitStore{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+type rateLimitStore struct {
+	mtx     sync.Mutex
+	buckets map[string]*bucket
+	takes   int
+}
+
+type bucket struct {
+	limit  shipping.RateLimit
+	tokens float64
+	last   time.Time
+	// day is the UTC date the used requests are counted for
+	day  string
+	used int64
+}
+
+func (rs *rateLimitStore) Take(_ context.Context, key string, limit shipping.RateLimit, now time.Time) (shipping.RateDecision, error) {
+	rs.mtx.Lock()
+	defer rs.mtx.Unlock()
+	rs.takes++
+	if rs.takes%sweepInterval == 0 {
+		rs.sweep(now)
+	}
+	b, ok := rs.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens: float64(limit.Burst),
+			last:   now,
+		}
+		rs.buckets[key] = b
+	}
+	b.limit = limit
+	b.refill(now)
+	if day := now.UTC().Format(time.DateOnly); b.day != day {
+		b.day = day
+		b.used = 0
+	}
+	if limit.Quota > 0 && b.used >= limit.Quota {
+		midnight := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+		return shipping.RateDecision{
+			RetryAfter: midnight.Sub(now),
+		}, nil
+	}
+	if b.tokens < 1 {
+		wait := (1 - b.tokens) / limit.Rate
+		return shipping.RateDecision{
+			RetryAfter: time.Duration(math.Ceil(wait * float64(time.Second))),
+			Remaining:  remaining(limit, b.used),
+		}, nil
+	}
+	b.tokens--
+	b.used++
+	return shipping.RateDecision{
+		Allowed:   true,
+		Remaining: remaining(limit, b.used),
+	}, nil
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+		b.last = now
+	}
+}
+
+// sweep removes the buckets that are full again and hold no quota usage of the current day,
+// as a new bucket would behave the same
+func (rs *rateLimitStore) sweep(now time.Time) {
+	today := now.UTC().Format(time.DateOnly)
+	for key, b := range rs.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) && (b.limit.Quota == 0 || b.day != today) {
+			delete(rs.buckets, key)
+		}
+	}
+}
+
+func remaining(limit shipping.RateLimit, used int64) int64 {
+	if limit.Quota == 0 {
+		return -1
+	}
+	return limit.Quota - used
+}
@@ -0,0 +1,66 @@
+package inmem_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/inmem"
+)
+
+func TestRateLimitStore_Take(t *testing.T) {
+	store := inmem.NewRateLimitStore()
+	limit := shipping.RateLimit{Rate: 2, Burst: 3}
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	// the burst is allowed at once, then the bucket refills at rate
+	for i := 0; i < 3; i++ {
+		d, err := store.Take(context.Background(), "client-1", limit, now)
+		require.NoError(t, err)
+		require.True(t, d.Allowed)
+		require.Equal(t, int64(-1), d.Remaining)
+	}
+	d, err := store.Take(context.Background(), "client-1", limit, now)
+	require.NoError(t, err)
+	require.False(t, d.Allowed)
+	require.Equal(t, 500*time.Millisecond, d.RetryAfter)
+
+	// other clients have their own bucket
+	d, err = store.Take(context.Background(), "client-2", limit, now)
+	require.NoError(t, err)
+	require.True(t, d.Allowed)
+
+	d, err = store.Take(context.Background(), "client-1", limit, now.Add(500*time.Millisecond))
+	require.NoError(t, err)
+	require.True(t, d.Allowed)
+	d, err = store.Take(context.Background(), "client-1", limit, now.Add(600*time.Millisecond))
+	require.NoError(t, err)
+	require.False(t, d.Allowed)
+	require.Equal(t, 400*time.Millisecond, d.RetryAfter)
+}
+
+func TestRateLimitStore_Quota(t *testing.T) {
+	store := inmem.NewRateLimitStore()
+	limit := shipping.RateLimit{Rate: 100, Burst: 100, Quota: 2}
+	now := time.Date(2023, 5, 1, 22, 0, 0, 0, time.UTC)
+
+	for i := int64(1); i <= 2; i++ {
+		d, err := store.Take(context.Background(), "client-1", limit, now)
+		require.NoError(t, err)
+		require.True(t, d.Allowed)
+		require.Equal(t, 2-i, d.Remaining)
+	}
+	d, err := store.Take(context.Background(), "client-1", limit, now)
+	require.NoError(t, err)
+	require.False(t, d.Allowed)
+	require.Equal(t, 2*time.Hour, d.RetryAfter)
+
+	// the quota is reset at midnight UTC
+	d, err = store.Take(context.Background(), "client-1", limit, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.True(t, d.Allowed)
+	require.Equal(t, int64(1), d.Remaining)
+}
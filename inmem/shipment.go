@@ -0,0 +1,73 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+
+	"github.com/silvan-talos/shipping"
+)
+
+func NewShipmentRepository() shipping.ShipmentRepository {
+	return &shipmentRepository{
+		shipments: make(map[uint64]shipping.Shipment),
+	}
+}
+
+type shipmentRepository struct {
+	mtx       sync.RWMutex
+	lastID    uint64
+	shipments map[uint64]shipping.Shipment
+}
+
+func (sr *shipmentRepository) Store(_ context.Context, shipment shipping.Shipment) (uint64, error) {
+	sr.mtx.Lock()
+	defer sr.mtx.Unlock()
+	sr.lastID++
+	shipment.ID = sr.lastID
+	sr.shipments[shipment.ID] = cloneShipment(shipment)
+	return shipment.ID, nil
+}
+
+func (sr *shipmentRepository) GetByID(_ context.Context, id uint64) (shipping.Shipment, error) {
+	sr.mtx.RLock()
+	defer sr.mtx.RUnlock()
+	shipment, ok := sr.shipments[id]
+	if !ok {
+		return shipping.Shipment{}, shipping.ErrNotFound
+	}
+	return cloneShipment(shipment), nil
+}
+
+func (sr *shipmentRepository) FindByOrderID(_ context.Context, orderID string) ([]shipping.Shipment, error) {
+	sr.mtx.RLock()
+	defer sr.mtx.RUnlock()
+	shipments := make([]shipping.Shipment, 0)
+	for id := uint64(1); id <= sr.lastID; id++ {
+		if shipment, ok := sr.shipments[id]; ok && shipment.OrderID == orderID {
+			shipments = append(shipments, cloneShipment(shipment))
+		}
+	}
+	return shipments, nil
+}
+
+func (sr *shipmentRepository) Update(_ context.Context, shipment shipping.Shipment, expected shipping.ShipmentStatus) error {
+	sr.mtx.Lock()
+	defer sr.mtx.Unlock()
+	current, ok := sr.shipments[shipment.ID]
+	if !ok {
+		return shipping.ErrNotFound
+	}
+	if current.Status != expected {
+		return shipping.ErrStatusChanged
+	}
+	sr.shipments[shipment.ID] = cloneShipment(shipment)
+	return nil
+}
+
+// cloneShipment copies the slices of the shipment, so stored shipments can't be changed by callers
+func cloneShipment(s shipping.Shipment) shipping.Shipment {
+	s.Packing.PackSizes = append([]uint64(nil), s.Packing.PackSizes...)
+	s.Packing.Packs = append([]shipping.PackConfig(nil), s.Packing.Packs...)
+	s.History = append([]shipping.StatusChange(nil), s.History...)
+	return s
+}
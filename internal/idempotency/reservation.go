@@ -0,0 +1,33 @@
+// Package idempotency holds the helpers shared by the transports making requests idempotent.
+package idempotency
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// ExtendReservation extends the in-progress record of the key every half of ttl, so requests processed for
+// longer are not processed again by their retries, until the returned function is called.
+func ExtendReservation(ctx context.Context, store shipping.IdempotencyStore, key string, ttl time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(ttl / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := store.Extend(ctx, key, ttl); err != nil {
+					slog.ErrorContext(ctx, "failed to extend idempotency key reservation", "err", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
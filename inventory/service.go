@@ -0,0 +1,68 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+
+	"github.com/silvan-talos/shipping"
+)
+
+var (
+	ErrInvalidStock = errors.New("invalid stock: pack sizes must be positive and counts cannot be negative")
+)
+
+type Service interface {
+	GetStock(ctx context.Context, warehouse string) (map[uint64]int64, error)
+	UpdateStock(ctx context.Context, warehouse string, stock map[uint64]int64) error
+}
+
+type service struct {
+	inventory shipping.InventoryRepository
+}
+
+func NewService(args ServiceArgs) Service {
+	err := shipping.Validate.Struct(args)
+	if err != nil {
+		log.Fatal("failed to create inventory service, err:", err)
+	}
+
+	return &service{
+		inventory: args.Inventory,
+	}
+}
+
+type ServiceArgs struct {
+	Inventory shipping.InventoryRepository `validate:"required"`
+}
+
+func (s *service) GetStock(ctx context.Context, warehouse string) (map[uint64]int64, error) {
+	stock, err := s.inventory.GetStock(ctx, warehouse)
+	if err != nil {
+		if errors.Is(err, shipping.ErrNotFound) {
+			slog.InfoContext(ctx, "no stock found for warehouse", "warehouse", warehouse)
+			return nil, err
+		}
+		slog.ErrorContext(ctx, "failed to get stock", "warehouse", warehouse, "err", err)
+		return nil, shipping.InternalServerErr
+	}
+	return stock, nil
+}
+
+func (s *service) UpdateStock(ctx context.Context, warehouse string, stock map[uint64]int64) error {
+	if len(stock) == 0 {
+		return ErrInvalidStock
+	}
+	for size, count := range stock {
+		if size == 0 || count < 0 {
+			return ErrInvalidStock
+		}
+	}
+	err := s.inventory.UpdateStock(ctx, warehouse, stock)
+	if err != nil {
+		slog.ErrorContext(ctx, "error updating stock", "warehouse", warehouse, "err", err)
+		return shipping.InternalServerErr
+	}
+	return nil
+}
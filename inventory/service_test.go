@@ -0,0 +1,137 @@
+package inventory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/inmem"
+	"github.com/silvan-talos/shipping/inventory"
+	"github.com/silvan-talos/shipping/mock"
+)
+
+var tenantCtx = shipping.WithTenant(context.Background(), "tenant-1")
+
+func TestService_GetStock(t *testing.T) {
+	tests := map[string]struct {
+		inventory   shipping.InventoryRepository
+		expectedRes map[uint64]int64
+		expectedErr error
+	}{
+		"stockFound": {
+			inventory: &mock.InventoryRepository{
+				GetStockFn: func(ctx context.Context, warehouse string) (map[uint64]int64, error) {
+					return map[uint64]int64{250: 10, 500: 0}, nil
+				},
+			},
+			expectedRes: map[uint64]int64{250: 10, 500: 0},
+		},
+		"warehouseNotFound_returnErrNotFound": {
+			inventory: &mock.InventoryRepository{
+				GetStockFn: func(ctx context.Context, warehouse string) (map[uint64]int64, error) {
+					return nil, shipping.ErrNotFound
+				},
+			},
+			expectedErr: shipping.ErrNotFound,
+		},
+		"failedToGetStock_internalError": {
+			inventory: &mock.InventoryRepository{
+				GetStockFn: func(ctx context.Context, warehouse string) (map[uint64]int64, error) {
+					return nil, errors.New("failed to get stock")
+				},
+			},
+			expectedErr: shipping.InternalServerErr,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := inventory.NewService(inventory.ServiceArgs{
+				Inventory: tc.inventory,
+			})
+			res, err := s.GetStock(tenantCtx, "w1")
+			require.Equal(t, tc.expectedErr, err, "errors must match")
+			require.Equal(t, tc.expectedRes, res, "results must match")
+		})
+	}
+}
+
+func TestService_UpdateStock(t *testing.T) {
+	tests := map[string]struct {
+		stock       map[uint64]int64
+		inventory   shipping.InventoryRepository
+		expectedErr error
+	}{
+		"validStock": {
+			stock: map[uint64]int64{250: 10, 500: 5},
+		},
+		"zeroCount_outOfStock": {
+			stock: map[uint64]int64{250: 0},
+		},
+		"emptyStock_returnErrInvalidStock": {
+			stock:       map[uint64]int64{},
+			expectedErr: inventory.ErrInvalidStock,
+		},
+		"zeroPackSize_returnErrInvalidStock": {
+			stock:       map[uint64]int64{0: 10},
+			expectedErr: inventory.ErrInvalidStock,
+		},
+		"negativeCount_returnErrInvalidStock": {
+			stock:       map[uint64]int64{250: 10, 500: -1},
+			expectedErr: inventory.ErrInvalidStock,
+		},
+		"failedToUpdateStock_internalError": {
+			stock: map[uint64]int64{250: 10},
+			inventory: &mock.InventoryRepository{
+				UpdateStockFn: func(ctx context.Context, warehouse string, stock map[uint64]int64) error {
+					return errors.New("failed to update stock")
+				},
+			},
+			expectedErr: shipping.InternalServerErr,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			repo := tc.inventory
+			if repo == nil {
+				repo = inmem.NewInventoryRepository()
+			}
+			s := inventory.NewService(inventory.ServiceArgs{
+				Inventory: repo,
+			})
+			err := s.UpdateStock(tenantCtx, "w1", tc.stock)
+			require.Equal(t, tc.expectedErr, err, "errors must match")
+			if tc.expectedErr != nil || tc.inventory != nil {
+				return
+			}
+			stock, err := s.GetStock(tenantCtx, "w1")
+			require.NoError(t, err)
+			require.Equal(t, tc.stock, stock)
+		})
+	}
+}
+
+func TestService_StockReservedByShipments(t *testing.T) {
+	repo := inmem.NewInventoryRepository()
+	s := inventory.NewService(inventory.ServiceArgs{
+		Inventory: repo,
+	})
+	require.NoError(t, s.UpdateStock(tenantCtx, "w1", map[uint64]int64{250: 2, 500: 1}))
+
+	// the packs reserved by a planned shipment are taken from the stock until released
+	packs := []shipping.PackConfig{{Count: 1, Size: 500}, {Count: 1, Size: 250}}
+	require.NoError(t, repo.Reserve(tenantCtx, "w1", packs))
+	stock, err := s.GetStock(tenantCtx, "w1")
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]int64{250: 1, 500: 0}, stock)
+	require.ErrorIs(t, repo.Reserve(tenantCtx, "w1", packs), shipping.ErrInsufficientStock)
+
+	// restocking sets the counts of the sizes given, the reserved packs being released on top of them
+	require.NoError(t, s.UpdateStock(tenantCtx, "w1", map[uint64]int64{500: 3}))
+	require.NoError(t, repo.Release(tenantCtx, "w1", packs))
+	stock, err = s.GetStock(tenantCtx, "w1")
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]int64{250: 2, 500: 4}, stock)
+}
@@ -0,0 +1,27 @@
+package shipping
+
+import (
+	"fmt"
+)
+
+type Address struct {
+	Name       string `json:"name" validate:"required"`
+	Street     string `json:"street" validate:"required"`
+	City       string `json:"city" validate:"required"`
+	PostalCode string `json:"postal_code" validate:"required"`
+	Country    string `json:"country" validate:"required"`
+}
+
+func (a Address) String() string {
+	return fmt.Sprintf("%s, %s, %s %s, %s", a.Name, a.Street, a.PostalCode, a.City, a.Country)
+}
+
+// Label is printed on a single physical pack.
+type Label struct {
+	ProductID uint64
+	Index     int64
+	Total     int64
+	PackSize  uint64
+	From      Address
+	To        Address
+}
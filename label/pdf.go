@@ -0,0 +1,92 @@
+package label
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// page size of a 4x6 inch label in points
+const (
+	pdfPageWidth  = 288
+	pdfPageHeight = 432
+)
+
+// renderPDF renders the labels as a PDF document having one 4x6 inch page per label
+func renderPDF(labels []shipping.Label) []byte {
+	// objects 1 and 2 are the catalog and the page tree, 3 is the font,
+	// then every label has a page object followed by its content stream
+	objects := make([]string, 3, 3+2*len(labels))
+	kids := make([]string, 0, len(labels))
+	for i, l := range labels {
+		pageID := 4 + 2*i
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+		content := pdfLabelContent(l)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, pageID+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		)
+	}
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(labels))
+	objects[2] = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>"
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func pdfLabelContent(l shipping.Label) string {
+	var buf bytes.Buffer
+	writePDFText(&buf, 20, 400, 9, "FROM: "+l.From.Name)
+	writePDFText(&buf, 20, 388, 9, l.From.Street)
+	writePDFText(&buf, 20, 376, 9, l.From.PostalCode+" "+l.From.City+", "+l.From.Country)
+	buf.WriteString("20 360 m 268 360 l S\n")
+	writePDFText(&buf, 20, 340, 11, "TO:")
+	writePDFText(&buf, 20, 318, 16, l.To.Name)
+	writePDFText(&buf, 20, 296, 16, l.To.Street)
+	writePDFText(&buf, 20, 274, 16, l.To.PostalCode+" "+l.To.City)
+	writePDFText(&buf, 20, 252, 16, l.To.Country)
+	buf.WriteString("20 230 m 268 230 l S\n")
+	writePDFText(&buf, 20, 200, 12, fmt.Sprintf("PRODUCT: %d", l.ProductID))
+	writePDFText(&buf, 20, 180, 12, fmt.Sprintf("PACK SIZE: %d", l.PackSize))
+	writePDFText(&buf, 20, 110, 32, fmt.Sprintf("PACK %d OF %d", l.Index, l.Total))
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func writePDFText(buf *bytes.Buffer, x, y, size int, text string) {
+	fmt.Fprintf(buf, "BT /F1 %d Tf %d %d Td (%s) Tj ET\n", size, x, y, escapePDF(text))
+}
+
+// escapePDF escapes a string literal, characters outside of latin-1 are replaced with '?'
+func escapePDF(s string) string {
+	var buf strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r < 0x20 || (r > 0x7e && r < 0xa0) || r > 0xff:
+			buf.WriteByte('?')
+		case r > 0x7e:
+			fmt.Fprintf(&buf, "\\%03o", r)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,114 @@
+package label
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// MaxLabels limits the amount of labels rendered in a single request.
+const MaxLabels = 1000
+
+var (
+	ErrInvalidFormat     = errors.New("invalid request: format must be zpl or pdf")
+	ErrNoPacks           = errors.New("invalid request: packs cannot be empty")
+	ErrInvalidPack       = errors.New("invalid request: pack count and size must be positive")
+	ErrInsufficientPacks = errors.New("invalid request: packs do not cover the ordered quantity")
+	ErrTooManyLabels     = fmt.Errorf("invalid request: more than %d labels requested", MaxLabels)
+)
+
+type Format string
+
+const (
+	FormatZPL Format = "zpl"
+	FormatPDF Format = "pdf"
+)
+
+func (f Format) ContentType() string {
+	if f == FormatPDF {
+		return "application/pdf"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+type Request struct {
+	ProductID uint64
+	Quantity  uint64
+	Packs     []shipping.PackConfig
+	From      shipping.Address
+	To        shipping.Address
+	Format    Format
+}
+
+type Service interface {
+	GenerateLabels(ctx context.Context, req Request) ([]byte, error)
+}
+
+type service struct{}
+
+func NewService() Service {
+	return &service{}
+}
+
+func (s *service) GenerateLabels(_ context.Context, req Request) ([]byte, error) {
+	if req.Format != FormatZPL && req.Format != FormatPDF {
+		return nil, ErrInvalidFormat
+	}
+	if err := shipping.Validate.Struct(req.From); err != nil {
+		return nil, fmt.Errorf("invalid request: sender address: %w", err)
+	}
+	if err := shipping.Validate.Struct(req.To); err != nil {
+		return nil, fmt.Errorf("invalid request: recipient address: %w", err)
+	}
+	labels, err := expandLabels(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Format == FormatPDF {
+		return renderPDF(labels), nil
+	}
+	return renderZPL(labels), nil
+}
+
+// expandLabels creates one label per physical pack, keeping the order of the packs
+func expandLabels(req Request) ([]shipping.Label, error) {
+	if len(req.Packs) == 0 {
+		return nil, ErrNoPacks
+	}
+	var total int64 = 0
+	// remaining is the quantity not covered by the packs yet, tracked instead of the capacity so it cannot overflow
+	remaining := req.Quantity
+	for _, p := range req.Packs {
+		if p.Count <= 0 || p.Size == 0 {
+			return nil, ErrInvalidPack
+		}
+		total += p.Count
+		if total > MaxLabels {
+			return nil, ErrTooManyLabels
+		}
+		if remaining/p.Size < uint64(p.Count) {
+			remaining = 0
+		} else {
+			remaining -= uint64(p.Count) * p.Size
+		}
+	}
+	if remaining > 0 {
+		return nil, ErrInsufficientPacks
+	}
+	labels := make([]shipping.Label, 0, total)
+	for _, p := range req.Packs {
+		for i := int64(0); i < p.Count; i++ {
+			labels = append(labels, shipping.Label{
+				ProductID: req.ProductID,
+				Index:     int64(len(labels)) + 1,
+				Total:     total,
+				PackSize:  p.Size,
+				From:      req.From,
+				To:        req.To,
+			})
+		}
+	}
+	return labels, nil
+}
@@ -0,0 +1,114 @@
+package label_test
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/label"
+)
+
+func TestService_GenerateLabels(t *testing.T) {
+	from := shipping.Address{Name: "Warehouse 1", Street: "Main St 1", City: "Cluj", PostalCode: "400000", Country: "RO"}
+	to := shipping.Address{Name: "Jane (Shop)", Street: "High St 2", City: "Dublin", PostalCode: "D02", Country: "IE"}
+	tests := map[string]struct {
+		req           label.Request
+		expectedParts []string
+		expectedCount int
+		expectedErr   error
+	}{
+		"zpl_oneLabelPerPack": {
+			req: label.Request{
+				ProductID: 1,
+				Quantity:  12001,
+				Packs:     []shipping.PackConfig{{Count: 2, Size: 5000}, {Count: 1, Size: 2000}, {Count: 1, Size: 250}},
+				From:      from,
+				To:        to,
+				Format:    label.FormatZPL,
+			},
+			expectedParts: []string{"PACK 1 OF 4", "PACK 2 OF 4", "PACK 4 OF 4", "PACK SIZE: 250", "^XA"},
+			expectedCount: 4,
+		},
+		"pdf_onePagePerPack": {
+			req: label.Request{
+				ProductID: 1,
+				Quantity:  501,
+				Packs:     []shipping.PackConfig{{Count: 1, Size: 500}, {Count: 1, Size: 250}},
+				From:      from,
+				To:        to,
+				Format:    label.FormatPDF,
+			},
+			expectedParts: []string{"%PDF-1.4", "(PACK 2 OF 2)", "(Jane \\(Shop\\))", "/Count 2"},
+			expectedCount: 2,
+		},
+		"unknownFormat_invalidRequest": {
+			req: label.Request{
+				Packs:  []shipping.PackConfig{{Count: 1, Size: 500}},
+				From:   from,
+				To:     to,
+				Format: "png",
+			},
+			expectedErr: label.ErrInvalidFormat,
+		},
+		"packsMissing_invalidRequest": {
+			req: label.Request{
+				From:   from,
+				To:     to,
+				Format: label.FormatZPL,
+			},
+			expectedErr: label.ErrNoPacks,
+		},
+		"packsBelowQuantity_invalidRequest": {
+			req: label.Request{
+				Quantity: 1000,
+				Packs:    []shipping.PackConfig{{Count: 1, Size: 500}},
+				From:     from,
+				To:       to,
+				Format:   label.FormatZPL,
+			},
+			expectedErr: label.ErrInsufficientPacks,
+		},
+		"packsCapacityAboveUint64_successful": {
+			req: label.Request{
+				Quantity: math.MaxUint64,
+				Packs:    []shipping.PackConfig{{Count: 2, Size: math.MaxUint64 / 2}, {Count: 1, Size: math.MaxUint64}},
+				From:     from,
+				To:       to,
+				Format:   label.FormatZPL,
+			},
+			expectedParts: []string{"PACK 3 OF 3"},
+			expectedCount: 3,
+		},
+		"tooManyPacks_invalidRequest": {
+			req: label.Request{
+				Packs:  []shipping.PackConfig{{Count: label.MaxLabels + 1, Size: 500}},
+				From:   from,
+				To:     to,
+				Format: label.FormatZPL,
+			},
+			expectedErr: label.ErrTooManyLabels,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := label.NewService()
+			res, err := s.GenerateLabels(context.Background(), tc.req)
+			require.Equal(t, tc.expectedErr, err, "errors must match")
+			if err != nil {
+				return
+			}
+			for _, part := range tc.expectedParts {
+				require.Contains(t, string(res), part)
+			}
+			if tc.req.Format == label.FormatZPL {
+				require.Equal(t, tc.expectedCount, bytes.Count(res, []byte("^XZ")))
+			} else {
+				require.Equal(t, tc.expectedCount, bytes.Count(res, []byte("/Type /Page ")))
+			}
+		})
+	}
+}
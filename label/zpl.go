@@ -0,0 +1,43 @@
+package label
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// renderZPL renders the labels for 4x6 inch thermal printers at 203 dpi
+func renderZPL(labels []shipping.Label) []byte {
+	var buf bytes.Buffer
+	for _, l := range labels {
+		buf.WriteString("^XA\n^CI28\n")
+		writeZPLField(&buf, 40, 40, 25, "FROM: "+l.From.Name)
+		writeZPLField(&buf, 40, 75, 25, l.From.Street)
+		writeZPLField(&buf, 40, 110, 25, l.From.PostalCode+" "+l.From.City+", "+l.From.Country)
+		buf.WriteString("^FO40,160^GB732,3,3^FS\n")
+		writeZPLField(&buf, 40, 190, 30, "TO:")
+		writeZPLField(&buf, 40, 240, 50, l.To.Name)
+		writeZPLField(&buf, 40, 300, 50, l.To.Street)
+		writeZPLField(&buf, 40, 360, 50, l.To.PostalCode+" "+l.To.City)
+		writeZPLField(&buf, 40, 420, 50, l.To.Country)
+		buf.WriteString("^FO40,500^GB732,3,3^FS\n")
+		writeZPLField(&buf, 40, 540, 35, fmt.Sprintf("PRODUCT: %d", l.ProductID))
+		writeZPLField(&buf, 40, 600, 35, fmt.Sprintf("PACK SIZE: %d", l.PackSize))
+		writeZPLField(&buf, 40, 700, 90, fmt.Sprintf("PACK %d OF %d", l.Index, l.Total))
+		buf.WriteString("^XZ\n")
+	}
+	return buf.Bytes()
+}
+
+func writeZPLField(buf *bytes.Buffer, x, y, height int, data string) {
+	fmt.Fprintf(buf, "^FO%d,%d^A0N,%d,%d^FH_^FD%s^FS\n", x, y, height, height, escapeZPL(data))
+}
+
+// zplEscaper hex encodes the characters having a special meaning in field data
+var zplEscaper = strings.NewReplacer("_", "_5F", "^", "_5E", "~", "_7E")
+
+func escapeZPL(s string) string {
+	return zplEscaper.Replace(s)
+}
@@ -0,0 +1,85 @@
+// Package logging configures the structured logger of the shipping services.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/silvan-talos/shipping"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type Options struct {
+	// Level is one of debug, info, warn or error, defaults to info
+	Level string `json:"level"`
+	// Format is json or text, defaults to json
+	Format string `json:"format"`
+}
+
+// Validate tells whether a logger can be created with the options.
+func (o Options) Validate() error {
+	_, err := o.level()
+	if err != nil {
+		return err
+	}
+	if o.Format != "" && o.Format != FormatJSON && o.Format != FormatText {
+		return fmt.Errorf("invalid log format %q, expected %s or %s", o.Format, FormatJSON, FormatText)
+	}
+	return nil
+}
+
+func (o Options) level() (slog.Level, error) {
+	var level slog.Level
+	if o.Level != "" {
+		if err := level.UnmarshalText([]byte(o.Level)); err != nil {
+			return 0, fmt.Errorf("invalid log level %q", o.Level)
+		}
+	}
+	return level, nil
+}
+
+// New creates a logger writing to w, adding to every record the ID of the request and of the trace
+// its context belongs to.
+func New(w io.Writer, opts Options) (*slog.Logger, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	level, _ := opts.level()
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler = slog.NewJSONHandler(w, handlerOpts)
+	if opts.Format == FormatText {
+		h = slog.NewTextHandler(w, handlerOpts)
+	}
+	return slog.New(contextHandler{h}), nil
+}
+
+// contextHandler adds the request and trace IDs of the context to the records
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id, ok := shipping.RequestIDFromContext(ctx); ok {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,72 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/logging"
+)
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		opts        logging.Options
+		expectedErr string
+	}{
+		"defaults": {},
+		"textDebug": {
+			opts: logging.Options{Level: "debug", Format: "text"},
+		},
+		"upperCaseLevel": {
+			opts: logging.Options{Level: "WARN", Format: "json"},
+		},
+		"invalidLevel": {
+			opts:        logging.Options{Level: "verbose"},
+			expectedErr: `invalid log level "verbose"`,
+		},
+		"invalidFormat": {
+			opts:        logging.Options{Format: "xml"},
+			expectedErr: `invalid log format "xml", expected json or text`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger, err := logging.New(&bytes.Buffer{}, tc.opts)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, logger)
+		})
+	}
+}
+
+func TestNew_RequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.Options{Level: "info"})
+	require.NoError(t, err)
+
+	ctx := shipping.WithRequestID(context.Background(), "req-1")
+	logger.With("component", "test").InfoContext(ctx, "packed", "overhead", 3)
+	logger.DebugContext(ctx, "not logged below the level")
+	logger.Info("without request")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(lines[0], &record))
+	require.Equal(t, "packed", record[slog.MessageKey])
+	require.Equal(t, "req-1", record["request_id"])
+	require.Equal(t, "test", record["component"])
+	require.Equal(t, float64(3), record["overhead"])
+
+	record = nil
+	require.NoError(t, json.Unmarshal(lines[1], &record))
+	require.NotContains(t, record, "request_id")
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/silvan-talos/shipping/cache"
+)
+
+// RegisterCacheStats exposes the counters of a cache, labelled by name, reading them on every scrape.
+func RegisterCacheStats(reg prometheus.Registerer, name string, stats func() cache.Stats) error {
+	labels := prometheus.Labels{"cache": name}
+	collectors := []prometheus.Collector{
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "cache_hits_total",
+			Help:        "Reads served from the cache.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(stats().Hits) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "cache_misses_total",
+			Help:        "Reads forwarded to the next repository.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(stats().Misses) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "cache_evictions_total",
+			Help:        "Entries evicted to keep the cache within its bound.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(stats().Evictions) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "cache_entries",
+			Help:        "Entries currently cached.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(stats().Entries) }),
+	}
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
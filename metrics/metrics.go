@@ -0,0 +1,27 @@
+// Package metrics provides decorators recording Prometheus metrics of the services and repositories
+// of the shipping package.
+package metrics
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "shipping"
+
+// register registers the collector, returning the one already registered when several decorators
+// record the same metric, e.g. two repositories labelled differently
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) C {
+	err := reg.Register(c)
+	if err == nil {
+		return c
+	}
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing
+		}
+	}
+	panic(err)
+}
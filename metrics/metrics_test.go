@@ -0,0 +1,158 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/cache"
+	"github.com/silvan-talos/shipping/metrics"
+	"github.com/silvan-talos/shipping/mock"
+	"github.com/silvan-talos/shipping/product"
+)
+
+var tenantCtx = shipping.WithTenant(context.Background(), "tenant-1")
+
+// find returns the series of the metric with the labels, or nil when it was never recorded
+func find(t *testing.T, reg *prometheus.Registry, name string, labels map[string]string) *dto.Metric {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	series:
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if labels[l.GetName()] != l.GetValue() {
+					continue series
+				}
+			}
+			return m
+		}
+	}
+	return nil
+}
+
+func TestPackRepository(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	next := &mock.PackRepository{
+		GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+			if productID == 2 {
+				return nil, shipping.ErrNotFound
+			}
+			return []uint64{250, 500}, nil
+		},
+		UpdateConfigFn: func(ctx context.Context, productID uint64, config []uint64) ([]uint64, error) {
+			return nil, errors.New("disk full")
+		},
+	}
+	pr := metrics.NewPackRepository(metrics.PackRepositoryArgs{
+		Next:       next,
+		Registerer: reg,
+		Name:       "inmem",
+	})
+	// decorators of other repositories share the metrics
+	other := metrics.NewPackRepository(metrics.PackRepositoryArgs{
+		Next:       next,
+		Registerer: reg,
+		Name:       "cache",
+	})
+
+	config, err := pr.GetByProductID(tenantCtx, 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{250, 500}, config)
+	_, err = pr.GetByProductID(tenantCtx, 2)
+	require.ErrorIs(t, err, shipping.ErrNotFound)
+	_, err = pr.UpdateConfig(tenantCtx, 1, []uint64{250})
+	require.EqualError(t, err, "disk full")
+	_, err = other.GetByProductID(tenantCtx, 1)
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(2), find(t, reg, "shipping_repository_call_duration_seconds",
+		map[string]string{"repository": "inmem", "method": "GetByProductID"}).GetHistogram().GetSampleCount())
+	require.Equal(t, uint64(1), find(t, reg, "shipping_repository_call_duration_seconds",
+		map[string]string{"repository": "cache", "method": "GetByProductID"}).GetHistogram().GetSampleCount())
+	// missing configurations are not errors
+	require.Equal(t, 1, testutil.CollectAndCount(reg, "shipping_repository_call_errors_total"))
+	require.Equal(t, float64(1), find(t, reg, "shipping_repository_call_errors_total",
+		map[string]string{"repository": "inmem", "method": "UpdateConfig"}).GetCounter().GetValue())
+}
+
+func TestProductService(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	ps := metrics.NewProductService(metrics.ProductServiceArgs{
+		Next: product.NewService(product.ServiceArgs{
+			Packs: &mock.PackRepository{
+				GetByWarehouseFn: func(ctx context.Context, warehouse string, productID uint64) ([]uint64, error) {
+					return nil, shipping.ErrNotFound
+				},
+				GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+					return []uint64{250, 500}, nil
+				},
+			},
+		}),
+		Registerer: reg,
+	})
+
+	packing, err := ps.CalculatePacking(tenantCtx, 1, 251, "")
+	require.NoError(t, err)
+	require.Equal(t, int64(249), packing.Overhead)
+	_, err = ps.CalculatePacksConfiguration(tenantCtx, 1, 500)
+	require.NoError(t, err)
+	_, err = ps.CalculatePacking(tenantCtx, 1, shipping.MaxQuantity+1, "")
+	require.ErrorIs(t, err, shipping.ErrOutOfRange)
+
+	require.Equal(t, uint64(2), find(t, reg, "shipping_product_service_duration_seconds",
+		map[string]string{"algorithm": "min_overhead", "outcome": "ok"}).GetHistogram().GetSampleCount())
+	require.Equal(t, uint64(1), find(t, reg, "shipping_product_service_duration_seconds",
+		map[string]string{"algorithm": "min_overhead", "outcome": "error"}).GetHistogram().GetSampleCount())
+	require.Equal(t, uint64(1), find(t, reg, "shipping_packing_overhead_items",
+		map[string]string{"algorithm": "min_overhead", "source": "product"}).GetHistogram().GetSampleCount())
+}
+
+func TestRegisterCacheStats(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c := cache.NewPackRepository(cache.PackRepositoryArgs{
+		Next: &mock.PackRepository{
+			GetDefaultFn: func(ctx context.Context) ([]uint64, error) {
+				return []uint64{250}, nil
+			},
+		},
+		TTL:        time.Minute,
+		MaxEntries: 10,
+	})
+	err := metrics.RegisterCacheStats(reg, "packs", c.Stats)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := c.GetDefault(tenantCtx)
+		require.NoError(t, err)
+	}
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	values := make(map[string]float64)
+	for _, f := range families {
+		m := f.GetMetric()[0]
+		values[f.GetName()] = m.GetCounter().GetValue() + m.GetGauge().GetValue()
+	}
+	require.Equal(t, map[string]float64{
+		"shipping_cache_hits_total":      2,
+		"shipping_cache_misses_total":    1,
+		"shipping_cache_evictions_total": 0,
+		"shipping_cache_entries":         1,
+	}, values)
+
+	// the same cache cannot be registered twice under the same name
+	err = metrics.RegisterCacheStats(reg, "packs", c.Stats)
+	require.Error(t, err)
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/silvan-talos/shipping"
+)
+
+type PackRepositoryArgs struct {
+	Next       shipping.PackRepository `validate:"required"`
+	Registerer prometheus.Registerer   `validate:"required"`
+	// Name labels the metrics of the repository, e.g. inmem or cache
+	Name string `validate:"required"`
+}
+
+// PackRepository records the latency and the errors of every call to the next repository. Missing
+// configurations are not counted as errors.
+type PackRepository struct {
+	next     shipping.PackRepository
+	name     string
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+func NewPackRepository(args PackRepositoryArgs) *PackRepository {
+	err := shipping.Validate.Struct(args)
+	if err != nil {
+		log.Fatal("failed to create instrumented pack repository, err:", err)
+	}
+
+	return &PackRepository{
+		next: args.Next,
+		name: args.Name,
+		duration: register(args.Registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_call_duration_seconds",
+			Help:      "Duration of the calls to the pack repositories.",
+			Buckets:   []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+		}, []string{"repository", "method"})),
+		errors: register(args.Registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "repository_call_errors_total",
+			Help:      "Calls to the pack repositories that failed.",
+		}, []string{"repository", "method"})),
+	}
+}
+
+func (pr *PackRepository) GetByProductID(ctx context.Context, productID uint64) ([]uint64, error) {
+	defer pr.observe("GetByProductID", time.Now())
+	config, err := pr.next.GetByProductID(ctx, productID)
+	pr.count("GetByProductID", err)
+	return config, err
+}
+
+func (pr *PackRepository) GetByWarehouse(ctx context.Context, warehouse string, productID uint64) ([]uint64, error) {
+	defer pr.observe("GetByWarehouse", time.Now())
+	config, err := pr.next.GetByWarehouse(ctx, warehouse, productID)
+	pr.count("GetByWarehouse", err)
+	return config, err
+}
+
+func (pr *PackRepository) GetDefault(ctx context.Context) ([]uint64, error) {
+	defer pr.observe("GetDefault", time.Now())
+	config, err := pr.next.GetDefault(ctx)
+	pr.count("GetDefault", err)
+	return config, err
+}
+
+func (pr *PackRepository) UpdateConfig(ctx context.Context, productID uint64, config []uint64) ([]uint64, error) {
+	defer pr.observe("UpdateConfig", time.Now())
+	previous, err := pr.next.UpdateConfig(ctx, productID, config)
+	pr.count("UpdateConfig", err)
+	return previous, err
+}
+
+func (pr *PackRepository) UpdateWarehouseConfig(ctx context.Context, warehouse string, productID uint64, config []uint64) ([]uint64, error) {
+	defer pr.observe("UpdateWarehouseConfig", time.Now())
+	previous, err := pr.next.UpdateWarehouseConfig(ctx, warehouse, productID, config)
+	pr.count("UpdateWarehouseConfig", err)
+	return previous, err
+}
+
+func (pr *PackRepository) UpdateDefault(ctx context.Context, config []uint64) ([]uint64, error) {
+	defer pr.observe("UpdateDefault", time.Now())
+	previous, err := pr.next.UpdateDefault(ctx, config)
+	pr.count("UpdateDefault", err)
+	return previous, err
+}
+
+func (pr *PackRepository) observe(method string, start time.Time) {
+	pr.duration.WithLabelValues(pr.name, method).Observe(time.Since(start).Seconds())
+}
+
+func (pr *PackRepository) count(method string, err error) {
+	if err != nil && !errors.Is(err, shipping.ErrNotFound) {
+		pr.errors.WithLabelValues(pr.name, method).Inc()
+	}
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/product"
+)
+
+// algorithms solving the packing, used to label the product service metrics
+const (
+	algorithmMinOverhead    = "min_overhead"
+	algorithmCostOptimal    = "cost_optimal"
+	algorithmSimulation     = "simulation"
+	algorithmRecommendation = "recommendation"
+)
+
+type ProductServiceArgs struct {
+	Next       product.Service       `validate:"required"`
+	Registerer prometheus.Registerer `validate:"required"`
+}
+
+// ProductService records the duration of the packing calculations per algorithm and outcome, from reading the
+// configuration to solving it, and the distribution of the overhead they result in per configuration level.
+// The solvers alone are timed by their spans. Reading and updating configurations is forwarded as is, the
+// pack repository is instrumented for it.
+type ProductService struct {
+	product.Service
+	duration *prometheus.HistogramVec
+	overhead *prometheus.HistogramVec
+}
+
+func NewProductService(args ProductServiceArgs) *ProductService {
+	err := shipping.Validate.Struct(args)
+	if err != nil {
+		log.Fatal("failed to create instrumented product service, err:", err)
+	}
+
+	return &ProductService{
+		Service: args.Next,
+		duration: register(args.Registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "product_service_duration_seconds",
+			Help:      "Duration of the packing calculations of the product service, including the configuration lookups.",
+			Buckets:   []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1, 5, 10},
+		}, []string{"algorithm", "outcome"})),
+		overhead: register(args.Registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "packing_overhead_items",
+			Help:      "Items shipped above the ordered quantity.",
+			Buckets:   []float64{0, 1, 5, 10, 50, 100, 500, 1000, 5000},
+		}, []string{"algorithm", "source"})),
+	}
+}
+
+func (ps *ProductService) CalculatePacksConfiguration(ctx context.Context, id, qty uint64) ([]shipping.PackConfig, error) {
+	start := time.Now()
+	packs, err := ps.Service.CalculatePacksConfiguration(ctx, id, qty)
+	ps.observe(algorithmMinOverhead, start, err)
+	return packs, err
+}
+
+func (ps *ProductService) CalculatePacking(ctx context.Context, id, qty uint64, warehouse string) (shipping.Packing, error) {
+	start := time.Now()
+	packing, err := ps.Service.CalculatePacking(ctx, id, qty, warehouse)
+	ps.observe(algorithmMinOverhead, start, err)
+	if err == nil {
+		ps.overhead.WithLabelValues(algorithmMinOverhead, string(packing.Source)).Observe(float64(packing.Overhead))
+	}
+	return packing, err
+}
+
+func (ps *ProductService) CalculateCostOptimalPacks(ctx context.Context, id, qty uint64, warehouse, carrier string) (shipping.CostOptimalPacking, error) {
+	start := time.Now()
+	packing, err := ps.Service.CalculateCostOptimalPacks(ctx, id, qty, warehouse, carrier)
+	ps.observe(algorithmCostOptimal, start, err)
+	if err == nil {
+		ps.overhead.WithLabelValues(algorithmCostOptimal, string(packing.Source)).Observe(float64(packing.Overhead))
+	}
+	return packing, err
+}
+
+func (ps *ProductService) SimulatePackSizes(ctx context.Context, id uint64, warehouse string, candidate, quantities []uint64, carrier string) (shipping.Simulation, error) {
+	start := time.Now()
+	sim, err := ps.Service.SimulatePackSizes(ctx, id, warehouse, candidate, quantities, carrier)
+	ps.observe(algorithmSimulation, start, err)
+	return sim, err
+}
+
+func (ps *ProductService) RecommendPackSizes(ctx context.Context, req product.RecommendRequest) (shipping.Recommendation, error) {
+	start := time.Now()
+	rec, err := ps.Service.RecommendPackSizes(ctx, req)
+	ps.observe(algorithmRecommendation, start, err)
+	return rec, err
+}
+
+// observe records the duration of the call, labelled with its outcome: ok or error
+func (ps *ProductService) observe(algorithm string, start time.Time, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	ps.duration.WithLabelValues(algorithm, outcome).Observe(time.Since(start).Seconds())
+}
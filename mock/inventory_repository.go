@@ -0,0 +1,42 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/silvan-talos/shipping"
+)
+
+type InventoryRepository struct {
+	GetStockFn    func(ctx context.Context, warehouse string) (map[uint64]int64, error)
+	UpdateStockFn func(ctx context.Context, warehouse string, stock map[uint64]int64) error
+	ReserveFn     func(ctx context.Context, warehouse string, packs []shipping.PackConfig) error
+	ReleaseFn     func(ctx context.Context, warehouse string, packs []shipping.PackConfig) error
+}
+
+func (ir *InventoryRepository) GetStock(ctx context.Context, warehouse string) (map[uint64]int64, error) {
+	if ir.GetStockFn != nil {
+		return ir.GetStockFn(ctx, warehouse)
+	}
+	return map[uint64]int64{250: 100, 500: 100, 1000: 100, 2000: 100, 5000: 100}, nil
+}
+
+func (ir *InventoryRepository) UpdateStock(ctx context.Context, warehouse string, stock map[uint64]int64) error {
+	if ir.UpdateStockFn != nil {
+		return ir.UpdateStockFn(ctx, warehouse, stock)
+	}
+	return nil
+}
+
+func (ir *InventoryRepository) Reserve(ctx context.Context, warehouse string, packs []shipping.PackConfig) error {
+	if ir.ReserveFn != nil {
+		return ir.ReserveFn(ctx, warehouse, packs)
+	}
+	return nil
+}
+
+func (ir *InventoryRepository) Release(ctx context.Context, warehouse string, packs []shipping.PackConfig) error {
+	if ir.ReleaseFn != nil {
+		return ir.ReleaseFn(ctx, warehouse, packs)
+	}
+	return nil
+}
@@ -2,11 +2,17 @@ package mock
 
 import (
 	"context"
+
+	"github.com/silvan-talos/shipping"
 )
 
 type PackRepository struct {
-	GetByProductIDFn func(ctx context.Context, productID uint64) ([]uint64, error)
-	UpdateConfigFn   func(ctx context.Context, productID uint64, config []uint64) error
+	GetByProductIDFn        func(ctx context.Context, productID uint64) ([]uint64, error)
+	GetByWarehouseFn        func(ctx context.Context, warehouse string, productID uint64) ([]uint64, error)
+	GetDefaultFn            func(ctx context.Context) ([]uint64, error)
+	UpdateConfigFn          func(ctx context.Context, productID uint64, config []uint64) ([]uint64, error)
+	UpdateWarehouseConfigFn func(ctx context.Context, warehouse string, productID uint64, config []uint64) ([]uint64, error)
+	UpdateDefaultFn         func(ctx context.Context, config []uint64) ([]uint64, error)
 }
 
 func (pr *PackRepository) GetByProductID(ctx context.Context, productID uint64) ([]uint64, error) {
@@ -16,9 +22,37 @@ func (pr *PackRepository) GetByProductID(ctx context.Context, productID uint64)
 	return []uint64{250, 500, 1000, 2000, 5000}, nil
 }
 
-func (pr *PackRepository) UpdateConfig(ctx context.Context, productID uint64, config []uint64) error {
+func (pr *PackRepository) GetByWarehouse(ctx context.Context, warehouse string, productID uint64) ([]uint64, error) {
+	if pr.GetByWarehouseFn != nil {
+		return pr.GetByWarehouseFn(ctx, warehouse, productID)
+	}
+	return nil, shipping.ErrNotFound
+}
+
+func (pr *PackRepository) GetDefault(ctx context.Context) ([]uint64, error) {
+	if pr.GetDefaultFn != nil {
+		return pr.GetDefaultFn(ctx)
+	}
+	return []uint64{250, 500, 1000, 2000, 5000}, nil
+}
+
+func (pr *PackRepository) UpdateConfig(ctx context.Context, productID uint64, config []uint64) ([]uint64, error) {
 	if pr.UpdateConfigFn != nil {
 		return pr.UpdateConfigFn(ctx, productID, config)
 	}
-	return nil
+	return nil, nil
+}
+
+func (pr *PackRepository) UpdateWarehouseConfig(ctx context.Context, warehouse string, productID uint64, config []uint64) ([]uint64, error) {
+	if pr.UpdateWarehouseConfigFn != nil {
+		return pr.UpdateWarehouseConfigFn(ctx, warehouse, productID, config)
+	}
+	return nil, nil
+}
+
+func (pr *PackRepository) UpdateDefault(ctx context.Context, config []uint64) ([]uint64, error) {
+	if pr.UpdateDefaultFn != nil {
+		return pr.UpdateDefaultFn(ctx, config)
+	}
+	return nil, nil
 }
@@ -0,0 +1,26 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/silvan-talos/shipping"
+)
+
+type Policy struct {
+	AuthorizeProductFn func(ctx context.Context, action shipping.Action, productID uint64) error
+	AuthorizeDefaultFn func(ctx context.Context, action shipping.Action) error
+}
+
+func (p *Policy) AuthorizeProduct(ctx context.Context, action shipping.Action, productID uint64) error {
+	if p.AuthorizeProductFn != nil {
+		return p.AuthorizeProductFn(ctx, action, productID)
+	}
+	return nil
+}
+
+func (p *Policy) AuthorizeDefault(ctx context.Context, action shipping.Action) error {
+	if p.AuthorizeDefaultFn != nil {
+		return p.AuthorizeDefaultFn(ctx, action)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/silvan-talos/shipping"
+)
+
+type RateRepository struct {
+	GetByCarrierFn func(ctx context.Context, carrier string) (shipping.RateTable, error)
+}
+
+func (rr *RateRepository) GetByCarrier(ctx context.Context, carrier string) (shipping.RateTable, error) {
+	if rr.GetByCarrierFn != nil {
+		return rr.GetByCarrierFn(ctx, carrier)
+	}
+	return shipping.RateTable{
+		Carrier:      carrier,
+		Currency:     "EUR",
+		DimDivisor:   5000,
+		PerParcelFee: 50,
+		WeightBands: []shipping.WeightBand{
+			{MaxWeight: 2, Price: 400},
+			{MaxWeight: 10, Price: 700},
+			{MaxWeight: 30, Price: 1200},
+		},
+		Parcels: []shipping.Parcel{
+			{PackSize: 250, Weight: 1, Length: 20, Width: 15, Height: 10},
+			{PackSize: 500, Weight: 2, Length: 30, Width: 20, Height: 15},
+			{PackSize: 1000, Weight: 4, Length: 40, Width: 30, Height: 20},
+			{PackSize: 2000, Weight: 8, Length: 60, Width: 40, Height: 25},
+			{PackSize: 5000, Weight: 20, Length: 70, Width: 50, Height: 40},
+		},
+	}, nil
+}
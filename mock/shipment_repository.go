@@ -0,0 +1,54 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/silvan-talos/shipping"
+)
+
+type ShipmentRepository struct {
+	StoreFn         func(ctx context.Context, shipment shipping.Shipment) (uint64, error)
+	GetByIDFn       func(ctx context.Context, id uint64) (shipping.Shipment, error)
+	FindByOrderIDFn func(ctx context.Context, orderID string) ([]shipping.Shipment, error)
+	UpdateFn        func(ctx context.Context, shipment shipping.Shipment, expected shipping.ShipmentStatus) error
+}
+
+func (sr *ShipmentRepository) Store(ctx context.Context, shipment shipping.Shipment) (uint64, error) {
+	if sr.StoreFn != nil {
+		return sr.StoreFn(ctx, shipment)
+	}
+	return 1, nil
+}
+
+func (sr *ShipmentRepository) GetByID(ctx context.Context, id uint64) (shipping.Shipment, error) {
+	if sr.GetByIDFn != nil {
+		return sr.GetByIDFn(ctx, id)
+	}
+	return shipping.Shipment{
+		ID:        id,
+		Tenant:    "tenant-1",
+		OrderID:   "order-1",
+		ProductID: 1,
+		Quantity:  251,
+		Packing: shipping.Packing{
+			PackSizes: []uint64{250, 500, 1000, 2000, 5000},
+			Packs:     []shipping.PackConfig{{Count: 1, Size: 500}},
+			Overhead:  249,
+		},
+		Status: shipping.StatusPlanned,
+	}, nil
+}
+
+func (sr *ShipmentRepository) FindByOrderID(ctx context.Context, orderID string) ([]shipping.Shipment, error) {
+	if sr.FindByOrderIDFn != nil {
+		return sr.FindByOrderIDFn(ctx, orderID)
+	}
+	return []shipping.Shipment{}, nil
+}
+
+func (sr *ShipmentRepository) Update(ctx context.Context, shipment shipping.Shipment, expected shipping.ShipmentStatus) error {
+	if sr.UpdateFn != nil {
+		return sr.UpdateFn(ctx, shipment, expected)
+	}
+	return nil
+}
@@ -9,15 +9,46 @@ import (
 )
 
 var (
-	ErrNotFound       = errors.New("not found")
-	InternalServerErr = errors.New("internal server error")
+	ErrNotFound          = errors.New("not found")
+	ErrInsufficientStock = errors.New("insufficient packaging stock")
+	InternalServerErr    = errors.New("internal server error")
 
 	Validate = validator.New()
+
+	// DefaultPackSizes are the pack sizes of tenants that did not configure their own default ones
+	DefaultPackSizes = []uint64{250, 500, 1000, 2000, 5000}
+)
+
+// ConfigLevel is the level of the fallback chain, warehouse → product → default, that supplied the pack sizes.
+type ConfigLevel string
+
+const (
+	LevelWarehouse ConfigLevel = "warehouse"
+	LevelProduct   ConfigLevel = "product"
+	LevelDefault   ConfigLevel = "default"
 )
 
+// PackRepository keeps the pack configurations of the tenant the context is scoped to,
+// implementations must return ErrMissingTenant for contexts without a tenant. The updates return
+// the configuration they replaced, as read in the same operation, nil when there was none.
 type PackRepository interface {
 	GetByProductID(ctx context.Context, productID uint64) ([]uint64, error)
-	UpdateConfig(ctx context.Context, productID uint64, config []uint64) error
+	GetByWarehouse(ctx context.Context, warehouse string, productID uint64) ([]uint64, error)
+	GetDefault(ctx context.Context) ([]uint64, error)
+	UpdateConfig(ctx context.Context, productID uint64, config []uint64) (previous []uint64, err error)
+	UpdateWarehouseConfig(ctx context.Context, warehouse string, productID uint64, config []uint64) (previous []uint64, err error)
+	UpdateDefault(ctx context.Context, config []uint64) (previous []uint64, err error)
+}
+
+// InventoryRepository keeps the stock of packaging materials, as pack size to count, per warehouse of the
+// tenant the context is scoped to, implementations must return ErrMissingTenant for contexts without a tenant.
+type InventoryRepository interface {
+	GetStock(ctx context.Context, warehouse string) (map[uint64]int64, error)
+	UpdateStock(ctx context.Context, warehouse string, stock map[uint64]int64) error
+	// Reserve takes either all the packs from the warehouse stock or none of them,
+	// returning ErrInsufficientStock in the latter case.
+	Reserve(ctx context.Context, warehouse string, packs []PackConfig) error
+	Release(ctx context.Context, warehouse string, packs []PackConfig) error
 }
 
 type PackConfig struct {
@@ -28,3 +59,31 @@ type PackConfig struct {
 func (pc PackConfig) String() string {
 	return fmt.Sprintf("%d x %d", pc.Count, pc.Size)
 }
+
+// CostOptimalPacking is the configuration minimising the delivered cost with a carrier.
+// Tradeoff compares it to the configuration minimising overhead.
+type CostOptimalPacking struct {
+	Source   ConfigLevel   `json:"source"`
+	Packs    []PackConfig  `json:"packs"`
+	Overhead int64         `json:"overhead"`
+	Estimate *CostEstimate `json:"estimate,omitempty"`
+	Tradeoff *Tradeoff     `json:"tradeoff,omitempty"`
+	// Approximate tells the pack sizes were too large to minimise the cost exactly, the packs being the
+	// cheaper of the min overhead packing and the cheapest single pack holding the order
+	Approximate bool `json:"approximate,omitempty"`
+}
+
+type Tradeoff struct {
+	ExtraItems int64  `json:"extra_items"`
+	ExtraPacks int64  `json:"extra_packs"`
+	Savings    int64  `json:"savings"`
+	Summary    string `json:"summary"`
+}
+
+// Packing is a calculated configuration together with the pack sizes it was calculated from.
+type Packing struct {
+	PackSizes []uint64     `json:"pack_sizes"`
+	Source    ConfigLevel  `json:"source"`
+	Packs     []PackConfig `json:"packs"`
+	Overhead  int64        `json:"overhead"`
+}
@@ -0,0 +1,44 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Action is an operation a Policy authorizes on a product.
+type Action string
+
+const (
+	ActionRead  Action = "read"
+	ActionWrite Action = "write"
+)
+
+// ErrForbidden is matched by every ForbiddenError.
+var ErrForbidden = errors.New("forbidden")
+
+// ForbiddenError reports a principal not allowed to perform an action on a product category.
+type ForbiddenError struct {
+	Principal string
+	Action    Action
+	Category  string
+}
+
+func (e *ForbiddenError) Error() string {
+	if e.Category == "" {
+		return fmt.Sprintf("forbidden: %s may not %s uncategorised products", e.Principal, e.Action)
+	}
+	return fmt.Sprintf("forbidden: %s may not %s products of category %s", e.Principal, e.Action, e.Category)
+}
+
+func (e *ForbiddenError) Is(target error) bool {
+	return target == ErrForbidden
+}
+
+// Policy decides whether the principal of the context may perform an action, so the same rules are
+// enforced by the services whatever the transport. Implementations return a ForbiddenError when not.
+type Policy interface {
+	AuthorizeProduct(ctx context.Context, action Action, productID uint64) error
+	// AuthorizeDefault authorizes the action on the default configuration, used by products of every category.
+	AuthorizeDefault(ctx context.Context, action Action) error
+}
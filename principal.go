@@ -0,0 +1,41 @@
+package shipping
+
+import (
+	"context"
+)
+
+// ScopeAll grants every scope, it is given to the anonymous principal when authentication is disabled.
+const ScopeAll = "*"
+
+// Principal is the authenticated caller of the API.
+type Principal struct {
+	ID string `json:"id"`
+	// Tenant the principal belongs to, empty when the principal may act for any tenant
+	Tenant string   `json:"tenant,omitempty"`
+	Scopes []string `json:"scopes"`
+	// Roles are the groups the principal belongs to, e.g. a team, matched by the rules of a Policy
+	Roles []string `json:"roles,omitempty"`
+}
+
+// HasScope tells whether the principal was granted the scope.
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAll {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal authenticated for ctx.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
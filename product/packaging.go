@@ -2,11 +2,69 @@ package product
 
 import (
 	"math"
+	"math/bits"
 	"sort"
 
 	"github.com/silvan-talos/shipping"
 )
 
+// CalculatePacks returns the configuration for qty items that minimises overhead and then pack count,
+// along with its overhead, without looking up the product configuration.
+func CalculatePacks(qty uint64, packSizes []uint64) ([]shipping.PackConfig, int64, error) {
+	if err := shipping.CheckQuantity(qty); err != nil {
+		return nil, 0, err
+	}
+	if len(packSizes) == 0 {
+		return nil, 0, ErrInvalidConfig
+	}
+	for _, size := range packSizes {
+		if size == 0 {
+			return nil, 0, ErrInvalidSizes
+		}
+	}
+	if err := shipping.CheckPackSizes(packSizes); err != nil {
+		return nil, 0, err
+	}
+	packs, overhead := calculatePacks(int64(qty), packSizes)
+	return packs, overhead, nil
+}
+
+// calculatePacks returns the best configuration of the overhead and division algorithms, sorted by pack size desc.
+// qty and the sizes must be within shipping.MaxQuantity and shipping.MaxPackSize, so no calculation overflows.
+func calculatePacks(qty int64, packSizes []uint64) ([]shipping.PackConfig, int64) {
+	sizes := make([]uint64, len(packSizes))
+	copy(sizes, packSizes)
+	conf, minOverhead := overheadAlgorithm(qty, sizes)
+	packConf, overhead := divisionAlgorithm(qty, sizes)
+	// choose better solution based on configuration accuracy
+	if overhead > minOverhead {
+		packConf = map[uint64]int64{conf.Size: conf.Count}
+		overhead = minOverhead
+	}
+	return toPackConfigs(packConf), overhead
+}
+
+// toPackConfigs converts a pack size to count mapping to a list of packs sorted by pack size desc
+func toPackConfigs(packConf map[uint64]int64) []shipping.PackConfig {
+	keys := make([]uint64, 0, len(packConf))
+	for k := range packConf {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] > keys[j]
+	})
+	packs := make([]shipping.PackConfig, 0, len(packConf))
+	for _, k := range keys {
+		if packConf[k] > 0 {
+			packs = append(packs, shipping.PackConfig{
+				Count: packConf[k],
+				Size:  k,
+			})
+		}
+	}
+	return packs
+}
+
 // overheadAlgorithm returns a configuration based on min items to send in min pack count
 func overheadAlgorithm(qty int64, packSizes []uint64) (shipping.PackConfig, int64) {
 	// sort packSizes asc
@@ -16,7 +74,12 @@ func overheadAlgorithm(qty int64, packSizes []uint64) (shipping.PackConfig, int6
 	overheads := make(map[uint64]int64)
 	packQuantities := make(map[uint64]int64)
 	for _, packSize := range packSizes {
-		packQuantities[packSize] = int64(math.Ceil(float64(qty) / float64(packSize)))
+		// integer division, as float64 loses precision above 2^53
+		amt := qty / int64(packSize)
+		if qty%int64(packSize) != 0 {
+			amt++
+		}
+		packQuantities[packSize] = amt
 	}
 	var minOh int64 = math.MaxInt64
 	for size, amt := range packQuantities {
@@ -103,3 +166,147 @@ func zeroSubsequent(qty int64, packSizes []uint64, packs map[uint64]int64) {
 		}
 	}
 }
+
+// costLimit bounds the quantity solved exactly by costAlgorithm, the rest is filled with the
+// pack size having the lowest cost per item
+const costLimit = 100000
+
+// maxCostRange bounds the totals solveCost keeps a solution for, which it allocates memory for. Quantities
+// up to costLimit, or filled down to it with sizes up to costLimit, stay below it, while larger sizes are
+// packed approximately.
+const maxCostRange = 4 * costLimit
+
+// costAlgorithm creates a configuration with the lowest total cost for at least qty items,
+// preferring lower overhead and then fewer packs when costs are equal. The configuration is approximate
+// when the sizes are too large to solve the rest of the quantity exactly.
+func costAlgorithm(qty int64, packCosts map[uint64]int64) (packConf map[uint64]int64, overhead int64, approximate bool) {
+	sizes := make([]uint64, 0, len(packCosts))
+	for size := range packCosts {
+		sizes = append(sizes, size)
+	}
+	// sort sizes asc
+	sort.Slice(sizes, func(i, j int) bool {
+		return sizes[i] < sizes[j]
+	})
+	packConf = make(map[uint64]int64)
+	rest := qty
+	if small := sizes[:countBelow(sizes, qty)]; qty > costLimit && len(small) > 0 {
+		cheapest := small[0]
+		for _, size := range small[1:] {
+			if lessCostPerItem(packCosts[size], size, packCosts[cheapest], cheapest) {
+				cheapest = size
+			}
+		}
+		count := (qty - costLimit) / int64(cheapest)
+		packConf[cheapest] = count
+		rest -= count * int64(cheapest)
+	}
+	solved, approximate := solveCost(rest, sizes, packCosts)
+	for size, count := range solved {
+		packConf[size] += count
+	}
+	var s int64 = 0
+	for size, count := range packConf {
+		s += int64(size) * count
+	}
+	return packConf, s - qty, approximate
+}
+
+// solveCost creates the configuration with the lowest total cost for at least qty items. A pack holding
+// all of them is never combined with others, which would only add to its cost, so only the sizes below
+// qty are combined and the range solved is below twice qty, however large the sizes are. Ranges above
+// maxCostRange are not solved, the cheaper of the min overhead packing and the cheapest pack holding all
+// the items being returned as an approximation.
+func solveCost(qty int64, sizes []uint64, packCosts map[uint64]int64) (packConf map[uint64]int64, approximate bool) {
+	if qty <= 0 {
+		return map[uint64]int64{}, false
+	}
+	split := countBelow(sizes, qty)
+	small := sizes[:split]
+	// cover is the cheapest size holding qty items alone, the smallest one on equal cost
+	var cover uint64
+	for _, size := range sizes[split:] {
+		if cover == 0 || packCosts[size] < packCosts[cover] {
+			cover = size
+		}
+	}
+	if len(small) == 0 {
+		return map[uint64]int64{cover: 1}, false
+	}
+	// costs[t] holds the min cost of packing exactly t items, -1 when t cannot be reached
+	limit := qty + int64(small[len(small)-1])
+	if limit > maxCostRange {
+		return approximateCost(qty, small, cover, packCosts), true
+	}
+	costs := make([]int64, limit+1)
+	counts := make([]int64, limit+1)
+	lastPack := make([]uint64, limit+1)
+	for t := int64(1); t <= limit; t++ {
+		costs[t] = -1
+		for _, size := range small {
+			prev := t - int64(size)
+			if prev < 0 || costs[prev] < 0 {
+				continue
+			}
+			cost := costs[prev] + packCosts[size]
+			count := counts[prev] + 1
+			if costs[t] < 0 || cost < costs[t] || (cost == costs[t] && count < counts[t]) {
+				costs[t] = cost
+				counts[t] = count
+				lastPack[t] = size
+			}
+		}
+	}
+	// totals are checked asc, so on equal cost the lowest overhead wins
+	best := int64(-1)
+	for t := qty; t <= limit; t++ {
+		if costs[t] < 0 {
+			continue
+		}
+		if best < 0 || costs[t] < costs[best] {
+			best = t
+		}
+	}
+	if cover != 0 {
+		coverCost := packCosts[cover]
+		if coverCost < costs[best] || (coverCost == costs[best] && (int64(cover) < best || (int64(cover) == best && counts[best] > 1))) {
+			return map[uint64]int64{cover: 1}, false
+		}
+	}
+	packConf = make(map[uint64]int64)
+	for t := best; t > 0; t -= int64(lastPack[t]) {
+		packConf[lastPack[t]]++
+	}
+	return packConf, false
+}
+
+// approximateCost returns the min overhead packing of qty items with the small sizes, or the cover when it
+// costs no more. cover is 0 when no size holds all the items.
+func approximateCost(qty int64, small []uint64, cover uint64, packCosts map[uint64]int64) map[uint64]int64 {
+	packs, _ := calculatePacks(qty, small)
+	packConf := make(map[uint64]int64, len(packs))
+	var cost int64
+	for _, p := range packs {
+		packConf[p.Size] = p.Count
+		cost += p.Count * packCosts[p.Size]
+	}
+	if cover != 0 && packCosts[cover] <= cost {
+		return map[uint64]int64{cover: 1}
+	}
+	return packConf
+}
+
+// countBelow returns the number of the sizes, sorted asc, below qty
+func countBelow(sizes []uint64, qty int64) int {
+	return sort.Search(len(sizes), func(i int) bool {
+		return sizes[i] >= uint64(qty)
+	})
+}
+
+// lessCostPerItem tells whether cost1/size1 < cost2/size2, comparing the 128-bit cross products
+// as costs multiplied by pack sizes may not fit in 64 bits
+func lessCostPerItem(cost1 int64, size1 uint64, cost2 int64, size2 uint64) bool {
+	hi1, lo1 := bits.Mul64(uint64(cost1), size2)
+	hi2, lo2 := bits.Mul64(uint64(cost2), size1)
+	return hi1 < hi2 || (hi1 == hi2 && lo1 < lo2)
+}
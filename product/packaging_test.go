@@ -0,0 +1,66 @@
+package product_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/product"
+)
+
+func TestCalculatePacks(t *testing.T) {
+	tests := map[string]struct {
+		qty              uint64
+		packSizes        []uint64
+		expectedRes      []shipping.PackConfig
+		expectedOverhead int64
+		expectedErr      error
+	}{
+		"noSizes_invalidConfig": {
+			qty:         1,
+			expectedErr: product.ErrInvalidConfig,
+		},
+		"zeroSize_invalidSizes": {
+			qty:         1,
+			packSizes:   []uint64{0, 250},
+			expectedErr: product.ErrInvalidSizes,
+		},
+		"quantityAboveMax_returnRangeError": {
+			qty:         shipping.MaxQuantity + 1,
+			packSizes:   []uint64{250},
+			expectedErr: &shipping.RangeError{Field: "quantity", Max: shipping.MaxQuantity},
+		},
+		"quantityAboveInt64_returnRangeError": {
+			qty:         math.MaxUint64,
+			packSizes:   []uint64{250},
+			expectedErr: &shipping.RangeError{Field: "quantity", Max: shipping.MaxQuantity},
+		},
+		"sizeAboveMax_returnRangeError": {
+			qty:         1,
+			packSizes:   []uint64{shipping.MaxPackSize + 1},
+			expectedErr: &shipping.RangeError{Field: "pack size", Max: shipping.MaxPackSize},
+		},
+		"quantityAboveFloatPrecision_exactOverhead": {
+			qty:              1<<53 + 1,
+			packSizes:        []uint64{2},
+			expectedRes:      []shipping.PackConfig{{Count: 1<<52 + 1, Size: 2}},
+			expectedOverhead: 1,
+		},
+		"maxQuantityAndMaxSize_noOverflow": {
+			qty:              shipping.MaxQuantity,
+			packSizes:        []uint64{shipping.MaxPackSize - 1},
+			expectedRes:      []shipping.PackConfig{{Count: 2, Size: shipping.MaxPackSize - 1}},
+			expectedOverhead: int64(shipping.MaxPackSize - 2),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			res, overhead, err := product.CalculatePacks(tc.qty, tc.packSizes)
+			require.Equal(t, tc.expectedErr, err)
+			require.Equal(t, tc.expectedRes, res)
+			require.Equal(t, tc.expectedOverhead, overhead)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package product
+
+import (
+	"errors"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/silvan-talos/shipping"
+)
+
+// MaxRecommendedSizes limits the size of a recommended pack size set.
+const MaxRecommendedSizes = 10
+
+const (
+	// demandSample bounds the orders the sets are evaluated on, bigger demands are sampled
+	demandSample = 500
+	// candidatePool bounds the pack sizes considered by the search
+	candidatePool = 100
+	// searchIterations is the number of swaps tried after the greedy selection
+	searchIterations = 300
+)
+
+var (
+	ErrInvalidMaxSizes   = errors.New("invalid request: max sizes must be between 1 and 10")
+	ErrInvalidPackWeight = errors.New("invalid request: pack weight cannot be negative")
+)
+
+type RecommendRequest struct {
+	// Quantities are the historical order quantities
+	Quantities []uint64
+	MaxSizes   int
+	// PackWeight is the amount of overhead items one extra pack is worth, defaults to 1
+	PackWeight float64
+	// Seed makes the search reproducible, a random one is used when 0
+	Seed int64
+}
+
+// Recommend searches the pack size set of at most req.MaxSizes sizes that minimises the expected overhead
+// and pack count of the demand. The sets are built greedily from sizes derived from the demand and then
+// improved by random swaps, so the result depends on the seed.
+func Recommend(req RecommendRequest) (shipping.Recommendation, error) {
+	if req.MaxSizes < 1 || req.MaxSizes > MaxRecommendedSizes {
+		return shipping.Recommendation{}, ErrInvalidMaxSizes
+	}
+	if req.PackWeight < 0 {
+		return shipping.Recommendation{}, ErrInvalidPackWeight
+	}
+	if err := validateSimulation(nil, nil, req.Quantities); err != nil {
+		return shipping.Recommendation{}, err
+	}
+	if req.PackWeight == 0 {
+		req.PackWeight = 1
+	}
+	if req.Seed == 0 {
+		req.Seed = time.Now().UnixNano()
+	}
+	rnd := rand.New(rand.NewSource(req.Seed))
+	d := newDemand(req.Quantities, rnd)
+	pool := candidateSizes(d, rnd)
+
+	best := greedySizes(d, pool, req.MaxSizes, req.PackWeight)
+	bestScore := d.score(best, req.PackWeight)
+	for i := 0; i < searchIterations; i++ {
+		sizes := swapSize(best, pool, rnd)
+		if sizes == nil {
+			break
+		}
+		if score := d.score(sizes, req.PackWeight); score < bestScore {
+			best, bestScore = sizes, score
+		}
+	}
+
+	overhead, packs := d.evaluate(best)
+	sort.Slice(best, func(i, j int) bool {
+		return best[i] < best[j]
+	})
+	return shipping.Recommendation{
+		PackSizes:        best,
+		ExpectedOverhead: overhead,
+		ExpectedPacks:    packs,
+		Orders:           len(req.Quantities),
+		Seed:             req.Seed,
+	}, nil
+}
+
+// demand holds the distinct quantities of the (sampled) orders and how often they were ordered
+type demand struct {
+	quantities []uint64
+	weights    []float64
+}
+
+func newDemand(quantities []uint64, rnd *rand.Rand) demand {
+	orders := quantities
+	if len(orders) > demandSample {
+		orders = make([]uint64, demandSample)
+		for i := range orders {
+			orders[i] = quantities[rnd.Intn(len(quantities))]
+		}
+	}
+	counts := make(map[uint64]int)
+	for _, qty := range orders {
+		counts[qty]++
+	}
+	d := demand{
+		quantities: make([]uint64, 0, len(counts)),
+		weights:    make([]float64, 0, len(counts)),
+	}
+	for qty := range counts {
+		d.quantities = append(d.quantities, qty)
+	}
+	sort.Slice(d.quantities, func(i, j int) bool {
+		return d.quantities[i] < d.quantities[j]
+	})
+	for _, qty := range d.quantities {
+		d.weights = append(d.weights, float64(counts[qty])/float64(len(orders)))
+	}
+	return d
+}
+
+// evaluate returns the expected overhead and pack count of an order packed with the sizes
+func (d demand) evaluate(sizes []uint64) (float64, float64) {
+	var overhead, packs float64
+	for i, qty := range d.quantities {
+		conf, oh := calculatePacks(int64(qty), sizes)
+		overhead += d.weights[i] * float64(oh)
+		packs += d.weights[i] * float64(countPacks(conf))
+	}
+	return overhead, packs
+}
+
+func (d demand) score(sizes []uint64, packWeight float64) float64 {
+	overhead, packs := d.evaluate(sizes)
+	return overhead + packWeight*packs
+}
+
+// candidateSizes derives the pack sizes worth considering from the demand: the quantities themselves and
+// their halves, thirds and quarters, so that a few packs add up to the ordered quantities
+func candidateSizes(d demand, rnd *rand.Rand) []uint64 {
+	seen := make(map[uint64]bool)
+	var pool []uint64
+	for _, qty := range d.quantities {
+		for k := uint64(1); k <= 4; k++ {
+			size := (qty + k - 1) / k
+			if !seen[size] {
+				seen[size] = true
+				pool = append(pool, size)
+			}
+		}
+	}
+	sort.Slice(pool, func(i, j int) bool {
+		return pool[i] < pool[j]
+	})
+	if len(pool) > candidatePool {
+		rnd.Shuffle(len(pool), func(i, j int) {
+			pool[i], pool[j] = pool[j], pool[i]
+		})
+		pool = pool[:candidatePool]
+	}
+	return pool
+}
+
+// greedySizes adds the candidate improving the score the most, until maxSizes are chosen or no candidate helps
+func greedySizes(d demand, pool []uint64, maxSizes int, packWeight float64) []uint64 {
+	var sizes []uint64
+	bestScore := -1.0
+	for len(sizes) < maxSizes {
+		scores := make([]float64, len(pool))
+		var wg sync.WaitGroup
+		workers := runtime.GOMAXPROCS(0)
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := w; i < len(pool); i += workers {
+					scores[i] = -1
+					if contains(sizes, pool[i]) {
+						continue
+					}
+					scores[i] = d.score(append(append([]uint64{}, sizes...), pool[i]), packWeight)
+				}
+			}(w)
+		}
+		wg.Wait()
+		// ties go to the first candidate, so the result does not depend on the scheduling of the workers
+		best := -1
+		for i, score := range scores {
+			if score >= 0 && (best < 0 || score < scores[best]) {
+				best = i
+			}
+		}
+		if best < 0 || (bestScore >= 0 && scores[best] >= bestScore) {
+			break
+		}
+		sizes = append(sizes, pool[best])
+		bestScore = scores[best]
+	}
+	return sizes
+}
+
+// swapSize replaces a random size of the set with a random candidate not in the set
+func swapSize(sizes, pool []uint64, rnd *rand.Rand) []uint64 {
+	if len(pool) <= len(sizes) {
+		return nil
+	}
+	candidate := pool[rnd.Intn(len(pool))]
+	for contains(sizes, candidate) {
+		candidate = pool[rnd.Intn(len(pool))]
+	}
+	res := append([]uint64{}, sizes...)
+	res[rnd.Intn(len(res))] = candidate
+	return res
+}
+
+func contains(sizes []uint64, size uint64) bool {
+	for _, s := range sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
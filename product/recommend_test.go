@@ -0,0 +1,83 @@
+package product_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/product"
+)
+
+func TestRecommend(t *testing.T) {
+	tests := map[string]struct {
+		req         product.RecommendRequest
+		expected    shipping.Recommendation
+		expectedErr error
+	}{
+		"maxSizesZero_invalidRequest": {
+			req:         product.RecommendRequest{Quantities: []uint64{10}},
+			expectedErr: product.ErrInvalidMaxSizes,
+		},
+		"maxSizesTooBig_invalidRequest": {
+			req:         product.RecommendRequest{Quantities: []uint64{10}, MaxSizes: 11},
+			expectedErr: product.ErrInvalidMaxSizes,
+		},
+		"noQuantities_invalidRequest": {
+			req:         product.RecommendRequest{MaxSizes: 2},
+			expectedErr: product.ErrNoOrders,
+		},
+		"negativePackWeight_invalidRequest": {
+			req:         product.RecommendRequest{Quantities: []uint64{10}, MaxSizes: 2, PackWeight: -1},
+			expectedErr: product.ErrInvalidPackWeight,
+		},
+		"singleQuantity_recommendQuantity": {
+			req: product.RecommendRequest{Quantities: []uint64{750, 750, 750}, MaxSizes: 3, Seed: 1},
+			expected: shipping.Recommendation{
+				PackSizes:     []uint64{750},
+				ExpectedPacks: 1,
+				Orders:        3,
+				Seed:          1,
+			},
+		},
+		"multiplesOfSize_recommendCommonSize": {
+			req: product.RecommendRequest{Quantities: []uint64{250, 500, 750, 1000}, MaxSizes: 1, Seed: 7},
+			expected: shipping.Recommendation{
+				PackSizes:     []uint64{250},
+				ExpectedPacks: 2.5,
+				Orders:        4,
+				Seed:          7,
+			},
+		},
+		"twoClusters_recommendBoth": {
+			req: product.RecommendRequest{Quantities: []uint64{100, 100, 100, 5000, 5000}, MaxSizes: 2, Seed: 42},
+			expected: shipping.Recommendation{
+				PackSizes:     []uint64{100, 5000},
+				ExpectedPacks: 1,
+				Orders:        5,
+				Seed:          42,
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			res, err := product.Recommend(tc.req)
+			require.Equal(t, tc.expectedErr, err)
+			require.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestRecommend_SameSeedSameRecommendation(t *testing.T) {
+	quantities := make([]uint64, 0, 2000)
+	for i := uint64(1); i <= 2000; i++ {
+		quantities = append(quantities, (i*7919)%3000+1)
+	}
+	req := product.RecommendRequest{Quantities: quantities, MaxSizes: 4, Seed: 99}
+	first, err := product.Recommend(req)
+	require.NoError(t, err)
+	second, err := product.Recommend(req)
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+	require.Len(t, first.PackSizes, 4)
+}
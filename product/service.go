@@ -3,23 +3,47 @@ package product
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"log/slog"
 	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/carrier"
 )
 
+// tracer records the spans of the solver, it is a no-op until a tracer provider is set globally
+var tracer = otel.Tracer("github.com/silvan-talos/shipping/product")
+
 var (
-	ErrInvalidConfig = errors.New("invalid config: config cannot be empty")
+	ErrInvalidConfig    = errors.New("invalid config: config cannot be empty")
+	ErrMissingWarehouse = errors.New("invalid request: warehouse cannot be empty")
 )
 
 type Service interface {
 	CalculatePacksConfiguration(ctx context.Context, id, qty uint64) ([]shipping.PackConfig, error)
+	CalculatePacking(ctx context.Context, id, qty uint64, warehouse string) (shipping.Packing, error)
+	CalculateCostOptimalPacks(ctx context.Context, id, qty uint64, warehouse, carrier string) (shipping.CostOptimalPacking, error)
+	SimulatePackSizes(ctx context.Context, id uint64, warehouse string, candidate, quantities []uint64, carrier string) (shipping.Simulation, error)
+	RecommendPackSizes(ctx context.Context, req RecommendRequest) (shipping.Recommendation, error)
+	GetPacksConfiguration(ctx context.Context, id uint64, warehouse string) ([]uint64, shipping.ConfigLevel, error)
 	UpdatePacksConfiguration(ctx context.Context, id uint64, config []uint64) error
+	UpdateWarehousePacksConfiguration(ctx context.Context, warehouse string, id uint64, config []uint64) error
+	GetDefaultPacksConfiguration(ctx context.Context) ([]uint64, error)
+	UpdateDefaultPacksConfiguration(ctx context.Context, config []uint64) error
 }
 
 type service struct {
-	packs shipping.PackRepository
+	packs     shipping.PackRepository
+	rates     shipping.RateRepository
+	tables    *solutionTables
+	policy    shipping.Policy
+	auditSink shipping.AuditSink
 }
 
 func NewService(args ServiceArgs) Service {
@@ -28,64 +52,472 @@ func NewService(args ServiceArgs) Service {
 		log.Fatal("failed to create product service, err:", err)
 	}
 
-	return &service{
-		packs: args.Packs,
+	s := &service{
+		packs:     args.Packs,
+		rates:     args.Rates,
+		policy:    args.Policy,
+		auditSink: args.Audit,
 	}
+	if args.TableBound > 0 {
+		s.tables = newSolutionTables(args.TableBound)
+	}
+	return s
 }
 
 type ServiceArgs struct {
 	Packs shipping.PackRepository `validate:"required"`
+	// Rates are optional, without them cost optimal packing falls back to minimising overhead
+	Rates shipping.RateRepository
+	// TableBound is the quantity up to which the packings of frequently used pack sizes are precomputed,
+	// at most MaxTableBound, 0 disables precomputing. The higher the bound, the fewer tables are kept.
+	TableBound uint64 `validate:"lte=100000"`
+	// Policy is optional, without it every principal may read and write the configuration of every product
+	Policy shipping.Policy
+	// Audit is optional, every attempt to change a configuration is recorded to it
+	Audit shipping.AuditSink
 }
 
 func (s *service) CalculatePacksConfiguration(ctx context.Context, id, quantity uint64) ([]shipping.PackConfig, error) {
-	packSizes, err := s.packs.GetByProductID(ctx, id)
+	packing, err := s.CalculatePacking(ctx, id, quantity, "")
+	if err != nil {
+		return nil, err
+	}
+	return packing.Packs, nil
+}
+
+// CalculatePacking calculates the packs using the sizes configured for the warehouse, the product or
+// the default ones, whichever is found first.
+func (s *service) CalculatePacking(ctx context.Context, id, quantity uint64, warehouse string) (shipping.Packing, error) {
+	if err := shipping.CheckQuantity(quantity); err != nil {
+		return shipping.Packing{}, err
+	}
+	packSizes, source, err := s.getPackSizes(ctx, id, warehouse)
+	if err != nil {
+		return shipping.Packing{}, err
+	}
+	// keep a copy of the sizes, so the packing is not affected by later configuration changes
+	sizes := make([]uint64, len(packSizes))
+	copy(sizes, packSizes)
+	sort.Slice(sizes, func(i, j int) bool {
+		return sizes[i] < sizes[j]
+	})
+	span := startSolver(ctx, "min_overhead", quantity, sizes)
+	var packs []shipping.PackConfig
+	var overhead int64
+	if s.tables != nil {
+		var lookedUp bool
+		packs, overhead, lookedUp = s.tables.calculatePacks(quantity, sizes)
+		span.SetAttributes(attribute.Bool("shipping.table_lookup", lookedUp))
+	} else {
+		packs, overhead = calculatePacks(int64(quantity), sizes)
+	}
+	endSolver(span, packs, overhead)
+	return shipping.Packing{
+		PackSizes: sizes,
+		Source:    source,
+		Packs:     packs,
+		Overhead:  overhead,
+	}, nil
+}
+
+func (s *service) CalculateCostOptimalPacks(ctx context.Context, id, quantity uint64, warehouse, carrierName string) (shipping.CostOptimalPacking, error) {
+	if err := shipping.CheckQuantity(quantity); err != nil {
+		return shipping.CostOptimalPacking{}, err
+	}
+	packSizes, source, err := s.getPackSizes(ctx, id, warehouse)
+	if err != nil {
+		return shipping.CostOptimalPacking{}, err
+	}
+	span := startSolver(ctx, "min_overhead", quantity, packSizes)
+	packs, overhead := calculatePacks(int64(quantity), packSizes)
+	endSolver(span, packs, overhead)
+	res := shipping.CostOptimalPacking{
+		Source:   source,
+		Packs:    packs,
+		Overhead: overhead,
+	}
+	// without rates configured there is no cost to optimise, while a carrier without rates is a wrong request
+	if s.rates == nil {
+		return res, nil
+	}
+	table, err := s.rates.GetByCarrier(ctx, carrierName)
 	if err != nil {
 		if errors.Is(err, shipping.ErrNotFound) {
-			log.Println("no config found for product id:", id)
-			return nil, err
+			slog.InfoContext(ctx, "no rate table found for carrier", "carrier", carrierName)
+			return shipping.CostOptimalPacking{}, err
 		}
-		log.Println("failed to get packs config, err:", err)
-		return nil, shipping.InternalServerErr
+		slog.ErrorContext(ctx, "failed to get rate table", "carrier", carrierName, "err", err)
+		return shipping.CostOptimalPacking{}, shipping.InternalServerErr
+	}
+	packCosts := make(map[uint64]int64)
+	for _, size := range packSizes {
+		cost, err := carrier.PackCost(table, size)
+		if err != nil {
+			slog.WarnContext(ctx, "pack size excluded from cost optimisation", "size", size, "err", err)
+			continue
+		}
+		packCosts[size] = cost
 	}
-	conf, minOverhead := overheadAlgorithm(int64(quantity), packSizes)
-	packConf, overhead := divisionAlgorithm(int64(quantity), packSizes)
-	// choose better solution based on configuration accuracy
-	if overhead > minOverhead {
-		packConf = map[uint64]int64{conf.Size: conf.Count}
+	if len(packCosts) == 0 {
+		return res, nil
 	}
-	// sort by pack size desc
-	keys := make([]uint64, 0, len(packConf))
-	for k := range packConf {
-		keys = append(keys, k)
+	span = startSolver(ctx, "cost_optimal", quantity, packSizes)
+	conf, costOverhead, approximate := costAlgorithm(int64(quantity), packCosts)
+	costPacks := toPackConfigs(conf)
+	span.SetAttributes(attribute.Bool("shipping.cost_approximate", approximate))
+	endSolver(span, costPacks, costOverhead)
+	if approximate {
+		slog.InfoContext(ctx, "pack sizes too large to minimise the cost exactly, packing approximated", "quantity", quantity, "carrier", carrierName)
 	}
-	sort.Slice(keys, func(i, j int) bool {
-		return keys[i] > keys[j]
-	})
-	// convert packs to meaningful struct
-	packs := make([]shipping.PackConfig, 0, len(packConf))
-	for _, k := range keys {
-		if packConf[k] > 0 {
-			packs = append(packs, shipping.PackConfig{
-				Count: packConf[k],
-				Size:  k,
-			})
+	estimate, err := carrier.Estimate(table, costPacks)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to estimate cost optimal packs", "err", err)
+		return shipping.CostOptimalPacking{}, shipping.InternalServerErr
+	}
+	defaultEstimate, err := carrier.Estimate(table, packs)
+	if err != nil {
+		// the min overhead configuration cannot be shipped with this carrier, there is nothing to compare with
+		return shipping.CostOptimalPacking{
+			Source:      source,
+			Packs:       costPacks,
+			Overhead:    costOverhead,
+			Estimate:    &estimate,
+			Approximate: approximate,
+		}, nil
+	}
+	if defaultEstimate.Total <= estimate.Total {
+		res.Estimate = &defaultEstimate
+		res.Approximate = approximate
+		res.Tradeoff = &shipping.Tradeoff{
+			Summary: "min overhead configuration is also the cheapest",
 		}
+		return res, nil
 	}
-	return packs, nil
+	tradeoff := shipping.Tradeoff{
+		ExtraItems: costOverhead - overhead,
+		ExtraPacks: countPacks(costPacks) - countPacks(packs),
+		Savings:    defaultEstimate.Total - estimate.Total,
+	}
+	tradeoff.Summary = summarizeTradeoff(tradeoff, estimate.Currency)
+	return shipping.CostOptimalPacking{
+		Source:      source,
+		Packs:       costPacks,
+		Overhead:    costOverhead,
+		Estimate:    &estimate,
+		Tradeoff:    &tradeoff,
+		Approximate: approximate,
+	}, nil
 }
 
+// SimulatePackSizes compares the packing of historical order quantities with the pack sizes currently used for
+// the product and with the candidate ones. The packings are priced when a carrier is given.
+func (s *service) SimulatePackSizes(ctx context.Context, id uint64, warehouse string, candidate, quantities []uint64, carrierName string) (shipping.Simulation, error) {
+	if len(candidate) == 0 {
+		return shipping.Simulation{}, ErrEmptyCandidate
+	}
+	current, _, err := s.GetPacksConfiguration(ctx, id, warehouse)
+	if err != nil {
+		return shipping.Simulation{}, err
+	}
+	var table *shipping.RateTable
+	if carrierName != "" {
+		if s.rates == nil {
+			slog.WarnContext(ctx, "no rates configured, cannot price simulation", "carrier", carrierName)
+			return shipping.Simulation{}, shipping.ErrNotFound
+		}
+		t, err := s.rates.GetByCarrier(ctx, carrierName)
+		if err != nil {
+			if errors.Is(err, shipping.ErrNotFound) {
+				slog.WarnContext(ctx, "no rate table found for carrier", "carrier", carrierName)
+				return shipping.Simulation{}, err
+			}
+			slog.ErrorContext(ctx, "failed to get rate table", "carrier", carrierName, "err", err)
+			return shipping.Simulation{}, shipping.InternalServerErr
+		}
+		table = &t
+	}
+	_, span := tracer.Start(ctx, "solver.simulation", trace.WithAttributes(
+		attribute.Int("shipping.simulated_orders", len(quantities)),
+	))
+	defer span.End()
+	return Simulate(current, candidate, quantities, table)
+}
+
+// RecommendPackSizes recommends the pack sizes minimising the expected overhead and pack count of the demand.
+func (s *service) RecommendPackSizes(ctx context.Context, req RecommendRequest) (shipping.Recommendation, error) {
+	_, span := tracer.Start(ctx, "solver.recommendation", trace.WithAttributes(
+		attribute.Int("shipping.simulated_orders", len(req.Quantities)),
+	))
+	defer span.End()
+	return Recommend(req)
+}
+
+// GetPacksConfiguration returns the pack sizes used for the product in the warehouse and the level they come from.
+func (s *service) GetPacksConfiguration(ctx context.Context, id uint64, warehouse string) ([]uint64, shipping.ConfigLevel, error) {
+	packSizes, source, err := s.getPackSizes(ctx, id, warehouse)
+	if err != nil {
+		return nil, "", err
+	}
+	sizes := make([]uint64, len(packSizes))
+	copy(sizes, packSizes)
+	return sizes, source, nil
+}
+
+// UpdatePacksConfiguration updates the pack sizes of the product, every attempt is audited.
 func (s *service) UpdatePacksConfiguration(ctx context.Context, id uint64, config []uint64) error {
-	if len(config) == 0 {
-		return ErrInvalidConfig
+	before, err := s.updatePacksConfiguration(ctx, id, config)
+	s.audit(ctx, shipping.AuditEntry{
+		Action:    shipping.AuditUpdatePacks,
+		ProductID: id,
+		Before:    before,
+		After:     config,
+	}, err)
+	return err
+}
+
+// updatePacksConfiguration returns the configuration replaced, once authorized, for auditing
+func (s *service) updatePacksConfiguration(ctx context.Context, id uint64, config []uint64) ([]uint64, error) {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return nil, shipping.ErrMissingTenant
+	}
+	if err := validateConfig(config); err != nil {
+		return nil, err
 	}
-	err := s.packs.UpdateConfig(ctx, id, config)
+	if err := s.authorizeProduct(ctx, shipping.ActionWrite, id); err != nil {
+		return nil, err
+	}
+	previous, err := s.packs.UpdateConfig(ctx, id, config)
 	if err != nil {
 		if errors.Is(err, shipping.ErrNotFound) {
-			log.Println("no product found for the specified ID, id:", id)
-			return shipping.ErrNotFound
+			slog.InfoContext(ctx, "no product found for the specified ID", "product_id", id, "tenant", tenant)
+			return nil, shipping.ErrNotFound
 		}
-		log.Println("error updating configuration, err:", err)
-		return shipping.InternalServerErr
+		slog.ErrorContext(ctx, "error updating configuration", "product_id", id, "err", err)
+		return nil, shipping.InternalServerErr
+	}
+	return previous, nil
+}
+
+// UpdateWarehousePacksConfiguration updates the pack sizes of the product in the warehouse, every attempt is audited.
+func (s *service) UpdateWarehousePacksConfiguration(ctx context.Context, warehouse string, id uint64, config []uint64) error {
+	before, err := s.updateWarehousePacksConfiguration(ctx, warehouse, id, config)
+	s.audit(ctx, shipping.AuditEntry{
+		Action:    shipping.AuditUpdateWarehousePacks,
+		ProductID: id,
+		Warehouse: warehouse,
+		Before:    before,
+		After:     config,
+	}, err)
+	return err
+}
+
+func (s *service) updateWarehousePacksConfiguration(ctx context.Context, warehouse string, id uint64, config []uint64) ([]uint64, error) {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return nil, shipping.ErrMissingTenant
+	}
+	if warehouse == "" {
+		return nil, ErrMissingWarehouse
+	}
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeProduct(ctx, shipping.ActionWrite, id); err != nil {
+		return nil, err
+	}
+	previous, err := s.packs.UpdateWarehouseConfig(ctx, warehouse, id, config)
+	if err != nil {
+		if errors.Is(err, shipping.ErrNotFound) {
+			slog.InfoContext(ctx, "no product found for the specified ID", "product_id", id, "warehouse", warehouse, "tenant", tenant)
+			return nil, shipping.ErrNotFound
+		}
+		slog.ErrorContext(ctx, "error updating warehouse configuration", "product_id", id, "warehouse", warehouse, "err", err)
+		return nil, shipping.InternalServerErr
+	}
+	return previous, nil
+}
+
+// GetDefaultPacksConfiguration returns the pack sizes used by the tenant for products without configuration.
+func (s *service) GetDefaultPacksConfiguration(ctx context.Context) ([]uint64, error) {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return nil, shipping.ErrMissingTenant
+	}
+	if err := s.authorizeDefault(ctx, shipping.ActionRead); err != nil {
+		return nil, err
+	}
+	config, err := s.packs.GetDefault(ctx)
+	if err != nil {
+		if errors.Is(err, shipping.ErrNotFound) {
+			slog.InfoContext(ctx, "no default config found", "tenant", tenant)
+			return nil, shipping.ErrNotFound
+		}
+		slog.ErrorContext(ctx, "failed to get default packs config", "err", err)
+		return nil, shipping.InternalServerErr
+	}
+	return config, nil
+}
+
+// UpdateDefaultPacksConfiguration updates the default pack sizes of the tenant, every attempt is audited.
+func (s *service) UpdateDefaultPacksConfiguration(ctx context.Context, config []uint64) error {
+	before, err := s.updateDefaultPacksConfiguration(ctx, config)
+	s.audit(ctx, shipping.AuditEntry{
+		Action: shipping.AuditUpdateDefaultPacks,
+		Before: before,
+		After:  config,
+	}, err)
+	return err
+}
+
+func (s *service) updateDefaultPacksConfiguration(ctx context.Context, config []uint64) ([]uint64, error) {
+	if _, ok := shipping.TenantFromContext(ctx); !ok {
+		return nil, shipping.ErrMissingTenant
+	}
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeDefault(ctx, shipping.ActionWrite); err != nil {
+		return nil, err
+	}
+	previous, err := s.packs.UpdateDefault(ctx, config)
+	if err != nil {
+		slog.ErrorContext(ctx, "error updating default configuration", "err", err)
+		return nil, shipping.InternalServerErr
+	}
+	return previous, nil
+}
+
+// audit records the attempted change along with who made it and its outcome. Attempts without a tenant
+// are not recorded, as they cannot change any configuration.
+func (s *service) audit(ctx context.Context, entry shipping.AuditEntry, err error) {
+	if s.auditSink == nil {
+		return
+	}
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return
+	}
+	entry.Tenant = tenant
+	entry.Principal = "unknown"
+	if principal, ok := shipping.PrincipalFromContext(ctx); ok {
+		entry.Principal = principal.ID
+	}
+	entry.Client, _ = shipping.ClientFromContext(ctx)
+	entry.After = append([]uint64(nil), entry.After...)
+	entry.Success = err == nil
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	entry.At = time.Now().UTC()
+	if err := s.auditSink.Record(ctx, entry); err != nil {
+		slog.ErrorContext(ctx, "failed to audit configuration change", "action", entry.Action, "err", err)
+	}
+}
+
+// authorizeProduct checks the policy allows the principal of ctx to perform the action on the product
+func (s *service) authorizeProduct(ctx context.Context, action shipping.Action, id uint64) error {
+	if s.policy == nil {
+		return nil
+	}
+	return s.policy.AuthorizeProduct(ctx, action, id)
+}
+
+// authorizeDefault checks the policy allows the principal of ctx to perform the action on the default configuration
+func (s *service) authorizeDefault(ctx context.Context, action shipping.Action) error {
+	if s.policy == nil {
+		return nil
+	}
+	return s.policy.AuthorizeDefault(ctx, action)
+}
+
+// getPackSizes resolves the pack sizes of the tenant through the fallback chain: warehouse → product → default
+func (s *service) getPackSizes(ctx context.Context, id uint64, warehouse string) ([]uint64, shipping.ConfigLevel, error) {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return nil, "", shipping.ErrMissingTenant
+	}
+	if err := s.authorizeProduct(ctx, shipping.ActionRead, id); err != nil {
+		return nil, "", err
+	}
+	if warehouse != "" {
+		packSizes, err := s.packs.GetByWarehouse(ctx, warehouse, id)
+		if err == nil && len(packSizes) > 0 {
+			return packSizes, shipping.LevelWarehouse, nil
+		}
+		if err != nil && !errors.Is(err, shipping.ErrNotFound) {
+			slog.ErrorContext(ctx, "failed to get warehouse packs config", "product_id", id, "warehouse", warehouse, "err", err)
+			return nil, "", shipping.InternalServerErr
+		}
+	}
+	packSizes, err := s.packs.GetByProductID(ctx, id)
+	if err == nil && len(packSizes) > 0 {
+		return packSizes, shipping.LevelProduct, nil
+	}
+	if err != nil && !errors.Is(err, shipping.ErrNotFound) {
+		slog.ErrorContext(ctx, "failed to get packs config", "product_id", id, "err", err)
+		return nil, "", shipping.InternalServerErr
+	}
+	packSizes, err = s.packs.GetDefault(ctx)
+	if err != nil && !errors.Is(err, shipping.ErrNotFound) {
+		slog.ErrorContext(ctx, "failed to get default packs config", "err", err)
+		return nil, "", shipping.InternalServerErr
+	}
+	if len(packSizes) == 0 {
+		slog.InfoContext(ctx, "no config found for product", "product_id", id, "tenant", tenant)
+		return nil, "", shipping.ErrNotFound
+	}
+	return packSizes, shipping.LevelDefault, nil
+}
+
+// validateConfig checks the pack sizes can be used by the calculations
+func validateConfig(config []uint64) error {
+	if len(config) == 0 {
+		return ErrInvalidConfig
+	}
+	for _, size := range config {
+		if size == 0 {
+			return ErrInvalidSizes
+		}
+	}
+	return shipping.CheckPackSizes(config)
+}
+
+// startSolver starts the span of a solver run, to be ended by endSolver once the packing is found
+func startSolver(ctx context.Context, algorithm string, quantity uint64, sizes []uint64) trace.Span {
+	_, span := tracer.Start(ctx, "solver."+algorithm, trace.WithAttributes(
+		attribute.Int64("shipping.quantity", int64(quantity)),
+		attribute.Int("shipping.pack_sizes", len(sizes)),
+	))
+	return span
+}
+
+// endSolver records the packing found on the span of the solver run and ends it
+func endSolver(span trace.Span, packs []shipping.PackConfig, overhead int64) {
+	span.SetAttributes(
+		attribute.Int64("shipping.pack_count", countPacks(packs)),
+		attribute.Int64("shipping.overhead", overhead),
+	)
+	span.End()
+}
+
+func countPacks(packs []shipping.PackConfig) int64 {
+	var count int64 = 0
+	for _, p := range packs {
+		count += p.Count
+	}
+	return count
+}
+
+// summarizeTradeoff describes the tradeoff in a human readable form, e.g. "2 extra items but 4.00 EUR cheaper"
+func summarizeTradeoff(t shipping.Tradeoff, currency string) string {
+	savings := fmt.Sprintf("%d.%02d %s", t.Savings/100, t.Savings%100, currency)
+	switch {
+	case t.ExtraItems > 0:
+		return fmt.Sprintf("%d extra items but %s cheaper", t.ExtraItems, savings)
+	case t.ExtraItems < 0:
+		return fmt.Sprintf("%d fewer items and %s cheaper", -t.ExtraItems, savings)
+	default:
+		return fmt.Sprintf("same items, %s cheaper", savings)
 	}
-	return nil
 }
@@ -4,14 +4,20 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/audit"
+	"github.com/silvan-talos/shipping/inmem"
 	"github.com/silvan-talos/shipping/mock"
 	"github.com/silvan-talos/shipping/product"
 )
 
+// tenantCtx is the context of a caller authenticated as tenant-1
+var tenantCtx = shipping.WithTenant(context.Background(), "tenant-1")
+
 func TestService_CalculatePacksConfiguration(t *testing.T) {
 	tests := map[string]struct {
 		qty         uint64
@@ -165,12 +171,33 @@ func TestService_CalculatePacksConfiguration(t *testing.T) {
 				},
 			},
 		},
+		"productConfigurationNotFound_fallbackToDefault": {
+			qty: 251,
+			packs: &mock.PackRepository{
+				GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+					return nil, shipping.ErrNotFound
+				},
+				GetDefaultFn: func(ctx context.Context) ([]uint64, error) {
+					return []uint64{300}, nil
+				},
+			},
+			expectedRes: []shipping.PackConfig{
+				{
+					Count: 1,
+					Size:  300,
+				},
+			},
+		},
 		"configurationNotFound_returnErrNotFound": {
 			qty: 1,
 			packs: &mock.PackRepository{
 				GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
 					return nil, shipping.ErrNotFound
-				}},
+				},
+				GetDefaultFn: func(ctx context.Context) ([]uint64, error) {
+					return nil, shipping.ErrNotFound
+				},
+			},
 			expectedErr: shipping.ErrNotFound,
 		},
 		"failedToGetConfiguration_internalError": {
@@ -188,7 +215,103 @@ func TestService_CalculatePacksConfiguration(t *testing.T) {
 				Packs: tc.packs,
 			}
 			s := product.NewService(args)
-			res, err := s.CalculatePacksConfiguration(context.Background(), 1, tc.qty)
+			res, err := s.CalculatePacksConfiguration(tenantCtx, 1, tc.qty)
+			require.Equal(t, tc.expectedErr, err, "errors must match")
+			if err == nil {
+				require.Equal(t, tc.expectedRes, res, "results must match")
+			}
+		})
+	}
+}
+
+func TestService_CalculatePacking(t *testing.T) {
+	tests := map[string]struct {
+		warehouse   string
+		packs       shipping.PackRepository
+		expectedRes shipping.Packing
+		expectedErr error
+	}{
+		"warehouseConfigured_useWarehouseSizes": {
+			warehouse: "w1",
+			packs: &mock.PackRepository{
+				GetByWarehouseFn: func(ctx context.Context, warehouse string, productID uint64) ([]uint64, error) {
+					return []uint64{1000, 300}, nil
+				},
+			},
+			expectedRes: shipping.Packing{
+				PackSizes: []uint64{300, 1000},
+				Source:    shipping.LevelWarehouse,
+				Packs:     []shipping.PackConfig{{Count: 1, Size: 300}},
+				Overhead:  51,
+			},
+		},
+		"warehouseNotConfigured_fallbackToProduct": {
+			warehouse: "w1",
+			packs:     &mock.PackRepository{},
+			expectedRes: shipping.Packing{
+				PackSizes: []uint64{250, 500, 1000, 2000, 5000},
+				Source:    shipping.LevelProduct,
+				Packs:     []shipping.PackConfig{{Count: 1, Size: 250}},
+				Overhead:  1,
+			},
+		},
+		"noWarehouse_warehouseConfigIgnored": {
+			packs: &mock.PackRepository{
+				GetByWarehouseFn: func(ctx context.Context, warehouse string, productID uint64) ([]uint64, error) {
+					return []uint64{300, 100}, nil
+				},
+			},
+			expectedRes: shipping.Packing{
+				PackSizes: []uint64{250, 500, 1000, 2000, 5000},
+				Source:    shipping.LevelProduct,
+				Packs:     []shipping.PackConfig{{Count: 1, Size: 250}},
+				Overhead:  1,
+			},
+		},
+		"productNotConfigured_fallbackToDefault": {
+			warehouse: "w1",
+			packs: &mock.PackRepository{
+				GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+					return nil, shipping.ErrNotFound
+				},
+				GetDefaultFn: func(ctx context.Context) ([]uint64, error) {
+					return []uint64{1000}, nil
+				},
+			},
+			expectedRes: shipping.Packing{
+				PackSizes: []uint64{1000},
+				Source:    shipping.LevelDefault,
+				Packs:     []shipping.PackConfig{{Count: 1, Size: 1000}},
+				Overhead:  751,
+			},
+		},
+		"failedToGetWarehouseConfiguration_internalError": {
+			warehouse: "w1",
+			packs: &mock.PackRepository{
+				GetByWarehouseFn: func(ctx context.Context, warehouse string, productID uint64) ([]uint64, error) {
+					return nil, errors.New("failed to get config")
+				},
+			},
+			expectedErr: shipping.InternalServerErr,
+		},
+		"failedToGetDefaultConfiguration_internalError": {
+			packs: &mock.PackRepository{
+				GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+					return nil, shipping.ErrNotFound
+				},
+				GetDefaultFn: func(ctx context.Context) ([]uint64, error) {
+					return nil, errors.New("failed to get config")
+				},
+			},
+			expectedErr: shipping.InternalServerErr,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := product.NewService(product.ServiceArgs{
+				Packs: tc.packs,
+			})
+			res, err := s.CalculatePacking(tenantCtx, 1, 249, tc.warehouse)
 			require.Equal(t, tc.expectedErr, err, "errors must match")
 			if err == nil {
 				require.Equal(t, tc.expectedRes, res, "results must match")
@@ -211,8 +334,8 @@ func TestService_UpdatePacksConfiguration(t *testing.T) {
 		"productIdNotFound_returnErrNotFound": {
 			config: []uint64{100, 200},
 			packs: &mock.PackRepository{
-				UpdateConfigFn: func(ctx context.Context, productID uint64, config []uint64) error {
-					return shipping.ErrNotFound
+				UpdateConfigFn: func(ctx context.Context, productID uint64, config []uint64) ([]uint64, error) {
+					return nil, shipping.ErrNotFound
 				},
 			},
 			expectedErr: shipping.ErrNotFound,
@@ -220,8 +343,8 @@ func TestService_UpdatePacksConfiguration(t *testing.T) {
 		"failedToUpdateConfiguration_returnInternalError": {
 			config: []uint64{100, 200},
 			packs: &mock.PackRepository{
-				UpdateConfigFn: func(ctx context.Context, productID uint64, config []uint64) error {
-					return errors.New("failed to update config")
+				UpdateConfigFn: func(ctx context.Context, productID uint64, config []uint64) ([]uint64, error) {
+					return nil, errors.New("failed to update config")
 				},
 			},
 			expectedErr: shipping.InternalServerErr,
@@ -238,8 +361,363 @@ func TestService_UpdatePacksConfiguration(t *testing.T) {
 				Packs: tc.packs,
 			}
 			s := product.NewService(args)
-			err := s.UpdatePacksConfiguration(context.Background(), 1, tc.config)
+			err := s.UpdatePacksConfiguration(tenantCtx, 1, tc.config)
+			require.Equal(t, tc.expectedErr, err)
+		})
+	}
+}
+
+func TestService_CalculateCostOptimalPacks(t *testing.T) {
+	tests := map[string]struct {
+		qty         uint64
+		packs       shipping.PackRepository
+		rates       shipping.RateRepository
+		expectedRes shipping.CostOptimalPacking
+		expectedErr error
+	}{
+		"noRatesConfigured_fallbackToMinOverhead": {
+			qty: 4001,
+			expectedRes: shipping.CostOptimalPacking{
+				Source: shipping.LevelProduct,
+				Packs: []shipping.PackConfig{
+					{Count: 2, Size: 2000},
+					{Count: 1, Size: 250},
+				},
+				Overhead: 249,
+			},
+		},
+		"carrierNotFound": {
+			qty: 4001,
+			rates: &mock.RateRepository{
+				GetByCarrierFn: func(ctx context.Context, carrier string) (shipping.RateTable, error) {
+					return shipping.RateTable{}, shipping.ErrNotFound
+				},
+			},
+			expectedErr: shipping.ErrNotFound,
+		},
+		"minOverheadIsCheapest": {
+			qty:   1001,
+			rates: &mock.RateRepository{},
+			expectedRes: shipping.CostOptimalPacking{
+				Source: shipping.LevelProduct,
+				Packs: []shipping.PackConfig{
+					{Count: 1, Size: 1000},
+					{Count: 1, Size: 250},
+				},
+				Overhead: 249,
+				Estimate: &shipping.CostEstimate{
+					Carrier:  "express",
+					Currency: "EUR",
+					Packs: []shipping.PackCost{
+						{Count: 1, Size: 1000, UnitCost: 750, Cost: 750},
+						{Count: 1, Size: 250, UnitCost: 450, Cost: 450},
+					},
+					Total: 1200,
+				},
+				Tradeoff: &shipping.Tradeoff{
+					Summary: "min overhead configuration is also the cheapest",
+				},
+			},
+		},
+		"extraItemsCheaper": {
+			qty:   4001,
+			rates: &mock.RateRepository{},
+			expectedRes: shipping.CostOptimalPacking{
+				Source: shipping.LevelProduct,
+				Packs: []shipping.PackConfig{
+					{Count: 1, Size: 5000},
+				},
+				Overhead: 999,
+				Estimate: &shipping.CostEstimate{
+					Carrier:  "express",
+					Currency: "EUR",
+					Packs: []shipping.PackCost{
+						{Count: 1, Size: 5000, UnitCost: 1250, Cost: 1250},
+					},
+					Total: 1250,
+				},
+				Tradeoff: &shipping.Tradeoff{
+					ExtraItems: 750,
+					ExtraPacks: -2,
+					Savings:    1700,
+					Summary:    "750 extra items but 17.00 EUR cheaper",
+				},
+			},
+		},
+		"hugeRatedSize_solvedWithoutAllocatingIt": {
+			qty: 501,
+			packs: &mock.PackRepository{
+				GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+					return []uint64{250, 500, shipping.MaxPackSize}, nil
+				},
+			},
+			rates: &mock.RateRepository{
+				GetByCarrierFn: func(ctx context.Context, carrier string) (shipping.RateTable, error) {
+					return shipping.RateTable{
+						Carrier:      carrier,
+						Currency:     "EUR",
+						PerParcelFee: 50,
+						WeightBands:  []shipping.WeightBand{{MaxWeight: 2, Price: 400}, {MaxWeight: 30, Price: 1200}},
+						Parcels: []shipping.Parcel{
+							{PackSize: 250, Weight: 1},
+							{PackSize: 500, Weight: 2},
+							{PackSize: shipping.MaxPackSize, Weight: 30},
+						},
+					}, nil
+				},
+			},
+			expectedRes: shipping.CostOptimalPacking{
+				Source: shipping.LevelProduct,
+				Packs: []shipping.PackConfig{
+					{Count: 1, Size: 500},
+					{Count: 1, Size: 250},
+				},
+				Overhead: 249,
+				Estimate: &shipping.CostEstimate{
+					Carrier:  "express",
+					Currency: "EUR",
+					Packs: []shipping.PackCost{
+						{Count: 1, Size: 500, UnitCost: 450, Cost: 450},
+						{Count: 1, Size: 250, UnitCost: 450, Cost: 450},
+					},
+					Total: 900,
+				},
+				Tradeoff: &shipping.Tradeoff{
+					Summary: "min overhead configuration is also the cheapest",
+				},
+			},
+		},
+		"sizesTooLargeToSolve_approximated": {
+			// the rest left after filling with the cheapest size per item is above the smallest size
+			qty: 600650000,
+			packs: &mock.PackRepository{
+				GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+					return []uint64{600000, 1000000}, nil
+				},
+			},
+			rates: &mock.RateRepository{
+				GetByCarrierFn: func(ctx context.Context, carrier string) (shipping.RateTable, error) {
+					return shipping.RateTable{
+						Carrier:     carrier,
+						Currency:    "EUR",
+						WeightBands: []shipping.WeightBand{{MaxWeight: 2, Price: 400}, {MaxWeight: 30, Price: 1200}},
+						Parcels: []shipping.Parcel{
+							{PackSize: 600000, Weight: 1},
+							{PackSize: 1000000, Weight: 3},
+						},
+					}, nil
+				},
+			},
+			expectedRes: shipping.CostOptimalPacking{
+				Source: shipping.LevelProduct,
+				Packs: []shipping.PackConfig{
+					{Count: 1002, Size: 600000},
+				},
+				Overhead: 550000,
+				Estimate: &shipping.CostEstimate{
+					Carrier:  "express",
+					Currency: "EUR",
+					Packs: []shipping.PackCost{
+						{Count: 1002, Size: 600000, UnitCost: 400, Cost: 400800},
+					},
+					Total: 400800,
+				},
+				Tradeoff: &shipping.Tradeoff{
+					ExtraItems: 200000,
+					ExtraPacks: 401,
+					Savings:    320400,
+					Summary:    "200000 extra items but 3204.00 EUR cheaper",
+				},
+				Approximate: true,
+			},
+		},
+		"failedToGetRates_internalError": {
+			qty: 1,
+			rates: &mock.RateRepository{
+				GetByCarrierFn: func(ctx context.Context, carrier string) (shipping.RateTable, error) {
+					return shipping.RateTable{}, errors.New("failed to get rates")
+				},
+			},
+			expectedErr: shipping.InternalServerErr,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			packs := tc.packs
+			if packs == nil {
+				packs = &mock.PackRepository{}
+			}
+			args := product.ServiceArgs{
+				Packs: packs,
+				Rates: tc.rates,
+			}
+			s := product.NewService(args)
+			res, err := s.CalculateCostOptimalPacks(tenantCtx, 1, tc.qty, "", "express")
+			require.Equal(t, tc.expectedErr, err, "errors must match")
+			if err == nil {
+				require.Equal(t, tc.expectedRes, res, "results must match")
+			}
+		})
+	}
+}
+
+func TestService_QuantityOutOfRange(t *testing.T) {
+	s := product.NewService(product.ServiceArgs{
+		Packs: &mock.PackRepository{},
+	})
+	_, err := s.CalculatePacking(tenantCtx, 1, shipping.MaxQuantity+1, "")
+	require.ErrorIs(t, err, shipping.ErrOutOfRange)
+	_, err = s.CalculateCostOptimalPacks(tenantCtx, 1, shipping.MaxQuantity+1, "", "express")
+	require.ErrorIs(t, err, shipping.ErrOutOfRange)
+	err = s.UpdatePacksConfiguration(tenantCtx, 1, []uint64{250, shipping.MaxPackSize + 1})
+	require.ErrorIs(t, err, shipping.ErrOutOfRange)
+	err = s.UpdatePacksConfiguration(tenantCtx, 1, []uint64{0})
+	require.Equal(t, product.ErrInvalidSizes, err)
+}
+
+func TestService_TenantMissing(t *testing.T) {
+	s := product.NewService(product.ServiceArgs{
+		Packs: &mock.PackRepository{},
+	})
+	_, err := s.CalculatePacksConfiguration(context.Background(), 1, 1)
+	require.Equal(t, shipping.ErrMissingTenant, err)
+	err = s.UpdatePacksConfiguration(context.Background(), 1, []uint64{250})
+	require.Equal(t, shipping.ErrMissingTenant, err)
+	err = s.UpdateDefaultPacksConfiguration(context.Background(), []uint64{250})
+	require.Equal(t, shipping.ErrMissingTenant, err)
+}
+
+func TestService_UpdateWarehousePacksConfiguration(t *testing.T) {
+	tests := map[string]struct {
+		warehouse   string
+		config      []uint64
+		packs       shipping.PackRepository
+		expectedErr error
+	}{
+		"warehouseEmpty_invalidRequest": {
+			config:      []uint64{250},
+			packs:       &mock.PackRepository{},
+			expectedErr: product.ErrMissingWarehouse,
+		},
+		"configEmpty_invalidRequest": {
+			warehouse:   "w1",
+			config:      []uint64{},
+			packs:       &mock.PackRepository{},
+			expectedErr: product.ErrInvalidConfig,
+		},
+		"failedToUpdateConfiguration_returnInternalError": {
+			warehouse: "w1",
+			config:    []uint64{100, 200},
+			packs: &mock.PackRepository{
+				UpdateWarehouseConfigFn: func(ctx context.Context, warehouse string, productID uint64, config []uint64) ([]uint64, error) {
+					return nil, errors.New("failed to update config")
+				},
+			},
+			expectedErr: shipping.InternalServerErr,
+		},
+		"updateConfig_successful": {
+			warehouse: "w1",
+			config:    []uint64{250},
+			packs:     &mock.PackRepository{},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := product.NewService(product.ServiceArgs{
+				Packs: tc.packs,
+			})
+			err := s.UpdateWarehousePacksConfiguration(tenantCtx, tc.warehouse, 1, tc.config)
 			require.Equal(t, tc.expectedErr, err)
 		})
 	}
 }
+
+func TestService_Policy(t *testing.T) {
+	var updated bool
+	forbidden := &shipping.ForbiddenError{Principal: "user-1", Action: shipping.ActionWrite, Category: "fragile"}
+	s := product.NewService(product.ServiceArgs{
+		Packs: &mock.PackRepository{
+			UpdateConfigFn: func(ctx context.Context, productID uint64, config []uint64) ([]uint64, error) {
+				updated = true
+				return nil, nil
+			},
+		},
+		Policy: &mock.Policy{
+			AuthorizeProductFn: func(ctx context.Context, action shipping.Action, productID uint64) error {
+				if action == shipping.ActionWrite && productID == 1 {
+					return forbidden
+				}
+				return nil
+			},
+			AuthorizeDefaultFn: func(ctx context.Context, action shipping.Action) error {
+				return forbidden
+			},
+		},
+	})
+
+	err := s.UpdatePacksConfiguration(tenantCtx, 1, []uint64{250})
+	require.ErrorIs(t, err, shipping.ErrForbidden)
+	require.False(t, updated)
+	err = s.UpdateWarehousePacksConfiguration(tenantCtx, "w1", 1, []uint64{250})
+	require.ErrorIs(t, err, shipping.ErrForbidden)
+	err = s.UpdateDefaultPacksConfiguration(tenantCtx, []uint64{250})
+	require.ErrorIs(t, err, shipping.ErrForbidden)
+	_, err = s.GetDefaultPacksConfiguration(tenantCtx)
+	require.ErrorIs(t, err, shipping.ErrForbidden)
+
+	_, err = s.CalculatePacking(tenantCtx, 1, 250, "")
+	require.NoError(t, err)
+	err = s.UpdatePacksConfiguration(tenantCtx, 2, []uint64{250})
+	require.NoError(t, err)
+	require.True(t, updated)
+}
+
+func TestService_Audit(t *testing.T) {
+	entries := inmem.NewAuditRepository()
+	auditService := audit.NewService(audit.ServiceArgs{
+		Entries: entries,
+	})
+	packs := inmem.NewPackRepository(shipping.DefaultPackSizes)
+	_, err := packs.UpdateConfig(tenantCtx, 2, []uint64{42})
+	require.NoError(t, err)
+	s := product.NewService(product.ServiceArgs{
+		Packs: packs,
+		Policy: &mock.Policy{
+			AuthorizeProductFn: func(ctx context.Context, action shipping.Action, productID uint64) error {
+				if productID == 2 {
+					return &shipping.ForbiddenError{Principal: "user-1", Action: action, Category: "fragile"}
+				}
+				return nil
+			},
+		},
+		Audit: auditService,
+	})
+	ctx := shipping.WithClient(shipping.WithPrincipal(tenantCtx, shipping.Principal{ID: "user-1"}), "10.0.0.1 shipctl")
+
+	require.NoError(t, s.UpdatePacksConfiguration(ctx, 1, []uint64{250, 500}))
+	require.NoError(t, s.UpdatePacksConfiguration(ctx, 1, []uint64{300}))
+	require.Equal(t, product.ErrInvalidConfig, s.UpdatePacksConfiguration(ctx, 1, []uint64{}))
+	require.ErrorIs(t, s.UpdatePacksConfiguration(ctx, 2, []uint64{250}), shipping.ErrForbidden)
+	require.NoError(t, s.UpdateWarehousePacksConfiguration(ctx, "w1", 1, []uint64{100}))
+	require.NoError(t, s.UpdateDefaultPacksConfiguration(ctx, []uint64{1000}))
+	require.Equal(t, shipping.ErrMissingTenant, s.UpdatePacksConfiguration(context.Background(), 1, []uint64{250}))
+
+	res, err := entries.Find(ctx, shipping.AuditQuery{Tenant: "tenant-1"})
+	require.NoError(t, err)
+	require.Len(t, res, 6)
+	for i := range res {
+		require.Equal(t, "user-1", res[i].Principal)
+		require.Equal(t, "10.0.0.1 shipctl", res[i].Client)
+		require.False(t, res[i].At.IsZero())
+		res[i].Tenant, res[i].Principal, res[i].Client, res[i].At = "", "", "", time.Time{}
+	}
+	require.Equal(t, []shipping.AuditEntry{
+		{ID: 6, Action: shipping.AuditUpdateDefaultPacks, Before: []uint64{250, 500, 1000, 2000, 5000}, After: []uint64{1000}, Success: true},
+		{ID: 5, Action: shipping.AuditUpdateWarehousePacks, ProductID: 1, Warehouse: "w1", After: []uint64{100}, Success: true},
+		// the configuration replaced is only known, and disclosed, once written
+		{ID: 4, Action: shipping.AuditUpdatePacks, ProductID: 2, After: []uint64{250}, Error: "forbidden: user-1 may not write products of category fragile"},
+		{ID: 3, Action: shipping.AuditUpdatePacks, ProductID: 1, Error: product.ErrInvalidConfig.Error()},
+		{ID: 2, Action: shipping.AuditUpdatePacks, ProductID: 1, Before: []uint64{250, 500}, After: []uint64{300}, Success: true},
+		{ID: 1, Action: shipping.AuditUpdatePacks, ProductID: 1, After: []uint64{250, 500}, Success: true},
+	}, res)
+}
@@ -0,0 +1,159 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/carrier"
+)
+
+// MaxSimulationOrders limits the amount of orders simulated at once.
+const MaxSimulationOrders = 100000
+
+var (
+	ErrNoOrders       = errors.New("invalid request: orders cannot be empty")
+	ErrTooManyOrders  = fmt.Errorf("invalid request: more than %d orders to simulate", MaxSimulationOrders)
+	ErrInvalidOrder   = errors.New("invalid request: order quantities must be positive")
+	ErrInvalidSizes   = errors.New("invalid request: pack sizes must be positive")
+	ErrEmptyCandidate = errors.New("invalid request: candidate pack sizes cannot be empty")
+)
+
+// Simulate packs every order quantity with both the current and the candidate pack sizes and aggregates
+// the results. When a rate table is given, the packings are also priced with it. Orders are packed in parallel.
+func Simulate(current, candidate, quantities []uint64, table *shipping.RateTable) (shipping.Simulation, error) {
+	if len(candidate) == 0 {
+		return shipping.Simulation{}, ErrEmptyCandidate
+	}
+	if len(current) == 0 {
+		return shipping.Simulation{}, ErrInvalidConfig
+	}
+	if err := validateSimulation(current, candidate, quantities); err != nil {
+		return shipping.Simulation{}, err
+	}
+	cur, cand, err := simulate(current, candidate, quantities, table)
+	if err != nil {
+		return shipping.Simulation{}, err
+	}
+	res := shipping.Simulation{
+		Orders:    len(quantities),
+		Current:   cur,
+		Candidate: cand,
+	}
+	if table != nil {
+		res.Currency = table.Currency
+	}
+	res.Delta = shipping.SimulationResult{
+		Overhead: res.Candidate.Overhead - res.Current.Overhead,
+		Packs:    res.Candidate.Packs - res.Current.Packs,
+		Cost:     res.Candidate.Cost - res.Current.Cost,
+		Unpriced: res.Candidate.Unpriced - res.Current.Unpriced,
+	}
+	return res, nil
+}
+
+func validateSimulation(current, candidate, quantities []uint64) error {
+	if len(quantities) == 0 {
+		return ErrNoOrders
+	}
+	if len(quantities) > MaxSimulationOrders {
+		return ErrTooManyOrders
+	}
+	for _, qty := range quantities {
+		if qty == 0 {
+			return ErrInvalidOrder
+		}
+		if err := shipping.CheckQuantity(qty); err != nil {
+			return err
+		}
+	}
+	for _, sizes := range [][]uint64{current, candidate} {
+		for _, size := range sizes {
+			if size == 0 {
+				return ErrInvalidSizes
+			}
+		}
+		if err := shipping.CheckPackSizes(sizes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// simulate packs the orders with both pack size sets, splitting them between one worker per CPU. It fails
+// with a RangeError when the totals do not fit in an int64.
+func simulate(current, candidate, quantities []uint64, table *shipping.RateTable) (shipping.SimulationResult, shipping.SimulationResult, error) {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(quantities) {
+		workers = len(quantities)
+	}
+	type workerResult struct {
+		totals   [2]shipping.SimulationResult
+		overflow bool
+	}
+	results := make([]workerResult, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			res := &results[w]
+			for i := w; i < len(quantities) && !res.overflow; i += workers {
+				var costs [2]int64
+				priced := true
+				for j, packSizes := range [][]uint64{current, candidate} {
+					packs, overhead := calculatePacks(int64(quantities[i]), packSizes)
+					res.overflow = res.overflow ||
+						!addChecked(&res.totals[j].Overhead, overhead) ||
+						!addChecked(&res.totals[j].Packs, countPacks(packs))
+					if table == nil {
+						continue
+					}
+					estimate, err := carrier.Estimate(*table, packs)
+					if err != nil {
+						res.totals[j].Unpriced++
+						priced = false
+						continue
+					}
+					costs[j] = estimate.Total
+				}
+				// costs are only added up for orders priced with both sets, so they can be compared
+				if table != nil && priced {
+					res.overflow = res.overflow ||
+						!addChecked(&res.totals[0].Cost, costs[0]) ||
+						!addChecked(&res.totals[1].Cost, costs[1])
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	cur := shipping.SimulationResult{PackSizes: current}
+	cand := shipping.SimulationResult{PackSizes: candidate}
+	for _, res := range results {
+		if res.overflow {
+			return cur, cand, &shipping.RangeError{Field: "simulation totals", Max: math.MaxInt64}
+		}
+		for j, total := range []*shipping.SimulationResult{&cur, &cand} {
+			if !addChecked(&total.Overhead, res.totals[j].Overhead) ||
+				!addChecked(&total.Packs, res.totals[j].Packs) ||
+				!addChecked(&total.Cost, res.totals[j].Cost) {
+				return cur, cand, &shipping.RangeError{Field: "simulation totals", Max: math.MaxInt64}
+			}
+			total.Unpriced += res.totals[j].Unpriced
+		}
+	}
+	return cur, cand, nil
+}
+
+// addChecked adds the non-negative v to total, unless the sum overflows
+func addChecked(total *int64, v int64) bool {
+	if v > math.MaxInt64-*total {
+		return false
+	}
+	*total += v
+	return true
+}
@@ -0,0 +1,126 @@
+package product_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/mock"
+	"github.com/silvan-talos/shipping/product"
+)
+
+func TestSimulate(t *testing.T) {
+	table, _ := (&mock.RateRepository{}).GetByCarrier(context.Background(), "express")
+	tests := map[string]struct {
+		current     []uint64
+		candidate   []uint64
+		quantities  []uint64
+		table       *shipping.RateTable
+		expected    shipping.Simulation
+		expectedErr error
+	}{
+		"noOrders_invalidRequest": {
+			current:     []uint64{250},
+			candidate:   []uint64{500},
+			expectedErr: product.ErrNoOrders,
+		},
+		"zeroQuantity_invalidRequest": {
+			current:     []uint64{250},
+			candidate:   []uint64{500},
+			quantities:  []uint64{10, 0},
+			expectedErr: product.ErrInvalidOrder,
+		},
+		"emptyCandidate_invalidRequest": {
+			current:     []uint64{250},
+			quantities:  []uint64{10},
+			expectedErr: product.ErrEmptyCandidate,
+		},
+		"withoutCarrier_compareOverheadAndPacks": {
+			current:    []uint64{250, 500},
+			candidate:  []uint64{300},
+			quantities: []uint64{1000, 1},
+			expected: shipping.Simulation{
+				Orders:    2,
+				Current:   shipping.SimulationResult{PackSizes: []uint64{250, 500}, Overhead: 249, Packs: 3},
+				Candidate: shipping.SimulationResult{PackSizes: []uint64{300}, Overhead: 499, Packs: 5},
+				Delta:     shipping.SimulationResult{Overhead: 250, Packs: 2},
+			},
+		},
+		"withCarrier_compareCost": {
+			current:    []uint64{250, 500},
+			candidate:  []uint64{250, 500, 1000},
+			quantities: []uint64{1000, 1, 750, 2000},
+			table:      &table,
+			expected: shipping.Simulation{
+				Orders:    4,
+				Currency:  "EUR",
+				Current:   shipping.SimulationResult{PackSizes: []uint64{250, 500}, Overhead: 249, Packs: 9, Cost: 4050},
+				Candidate: shipping.SimulationResult{PackSizes: []uint64{250, 500, 1000}, Overhead: 249, Packs: 6, Cost: 3600},
+				Delta:     shipping.SimulationResult{Packs: -3, Cost: -450},
+			},
+		},
+		"unknownParcel_countedAsUnpriced": {
+			current:    []uint64{250},
+			candidate:  []uint64{300},
+			quantities: []uint64{250, 300},
+			table:      &table,
+			expected: shipping.Simulation{
+				Orders:    2,
+				Currency:  "EUR",
+				Current:   shipping.SimulationResult{PackSizes: []uint64{250}, Overhead: 200, Packs: 3},
+				Candidate: shipping.SimulationResult{PackSizes: []uint64{300}, Overhead: 50, Packs: 2, Unpriced: 2},
+				Delta:     shipping.SimulationResult{Overhead: -150, Packs: -1, Unpriced: 2},
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			res, err := product.Simulate(tc.current, tc.candidate, tc.quantities, tc.table)
+			require.Equal(t, tc.expectedErr, err)
+			require.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestService_SimulatePackSizes(t *testing.T) {
+	tests := map[string]struct {
+		carrier     string
+		rates       shipping.RateRepository
+		expectedErr error
+	}{
+		"carrierWithoutRates_returnErrNotFound": {
+			carrier:     "express",
+			expectedErr: shipping.ErrNotFound,
+		},
+		"unknownCarrier_returnErrNotFound": {
+			carrier: "express",
+			rates: &mock.RateRepository{
+				GetByCarrierFn: func(ctx context.Context, carrier string) (shipping.RateTable, error) {
+					return shipping.RateTable{}, shipping.ErrNotFound
+				},
+			},
+			expectedErr: shipping.ErrNotFound,
+		},
+		"withCarrier_successful": {
+			carrier: "express",
+			rates:   &mock.RateRepository{},
+		},
+		"withoutCarrier_successful": {},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := product.NewService(product.ServiceArgs{
+				Packs: &mock.PackRepository{},
+				Rates: tc.rates,
+			})
+			res, err := s.SimulatePackSizes(tenantCtx, 1, "", []uint64{250, 500}, []uint64{1000, 12001}, tc.carrier)
+			require.Equal(t, tc.expectedErr, err)
+			if err == nil {
+				require.Equal(t, 2, res.Orders)
+				require.Equal(t, []uint64{250, 500, 1000, 2000, 5000}, res.Current.PackSizes)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package product
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/silvan-talos/shipping"
+)
+
+const (
+	// MaxTableBound bounds the quantity up to which packings are precomputed, every table holding a packing
+	// per quantity
+	MaxTableBound = 100000
+	// hotThreshold is the number of calculations with the same pack sizes after which their solutions are precomputed
+	hotThreshold = 100
+	// maxTableEntries bounds the packings held by the precomputed tables, the least recently used tables being
+	// dropped first. A packing takes around 50 to 100 bytes, its pack counts included, so the tables hold 16 to 32MB
+	// at most: around 32 tables with the default bound of 10000, down to 3 with MaxTableBound.
+	maxTableEntries = 320000
+	// maxCounted bounds the pack size sets whose calculations are counted, the least recently used ones are forgotten
+	maxCounted = 4096
+)
+
+// solutionTables holds the precomputed packings of the pack size sets calculated often, so calculating
+// their packings becomes a lookup. Tables only depend on the pack sizes, so they are shared by the tenants
+// and never go stale: once a configuration changes, its packings are looked up in the table of the new
+// sizes, while the table of the former ones is dropped when no longer used.
+type solutionTables struct {
+	bound uint64
+	// maxTables bounds the tables built, so they hold at most maxTableEntries packings
+	maxTables int
+
+	mtx     sync.Mutex
+	entries map[string]*list.Element
+	// lru holds the entries, the most recently used first
+	lru *list.List
+	// built is the number of entries holding a table
+	built int
+}
+
+type solutionTable struct {
+	key       string
+	calls     int
+	building  bool
+	solutions []solution
+}
+
+type solution struct {
+	packs    []shipping.PackConfig
+	overhead int64
+}
+
+func newSolutionTables(bound uint64) *solutionTables {
+	return &solutionTables{
+		bound:     bound,
+		maxTables: max(1, maxTableEntries/int(bound+1)),
+		entries:   make(map[string]*list.Element),
+		lru:       list.New(),
+	}
+}
+
+// calculatePacks returns the packing of qty items with the sizes, looking it up when the sizes have a table.
+// The table of sizes turning hot is built in the background, the packing being calculated meanwhile.
+func (st *solutionTables) calculatePacks(qty uint64, sizes []uint64) (packs []shipping.PackConfig, overhead int64, lookedUp bool) {
+	if qty > st.bound {
+		packs, overhead = calculatePacks(int64(qty), sizes)
+		return packs, overhead, false
+	}
+	key := fmt.Sprint(sizes)
+	st.mtx.Lock()
+	table := st.get(key)
+	if table.solutions != nil {
+		s := table.solutions[qty]
+		st.mtx.Unlock()
+		packs := make([]shipping.PackConfig, len(s.packs))
+		copy(packs, s.packs)
+		return packs, s.overhead, true
+	}
+	table.calls++
+	build := table.calls >= hotThreshold && !table.building
+	if build {
+		table.building = true
+	}
+	st.mtx.Unlock()
+
+	if build {
+		go st.build(table, append([]uint64(nil), sizes...))
+	}
+	packs, overhead = calculatePacks(int64(qty), sizes)
+	return packs, overhead, false
+}
+
+// get returns the entry of the key, adding it when missing, and marks it as the most recently used
+func (st *solutionTables) get(key string) *solutionTable {
+	if el, ok := st.entries[key]; ok {
+		st.lru.MoveToFront(el)
+		return el.Value.(*solutionTable)
+	}
+	table := &solutionTable{key: key}
+	st.entries[key] = st.lru.PushFront(table)
+	if st.lru.Len() > maxCounted {
+		st.remove(st.lru.Back())
+	}
+	return table
+}
+
+// build precomputes the solutions of the table, which is only stored if the entry was not dropped meanwhile.
+// The least recently used table is dropped when there are too many.
+func (st *solutionTables) build(table *solutionTable, sizes []uint64) {
+	solutions := make([]solution, st.bound+1)
+	for qty := range solutions {
+		packs, overhead := calculatePacks(int64(qty), sizes)
+		solutions[qty] = solution{
+			packs:    packs,
+			overhead: overhead,
+		}
+	}
+	st.mtx.Lock()
+	defer st.mtx.Unlock()
+	el, ok := st.entries[table.key]
+	if !ok || el.Value != table {
+		return
+	}
+	table.solutions = solutions
+	st.built++
+	for e := st.lru.Back(); st.built > st.maxTables && e != nil; e = e.Prev() {
+		if t := e.Value.(*solutionTable); t.solutions != nil && t != table {
+			// the entry is kept, so the sizes get a new table once they turn hot again
+			t.solutions, t.calls, t.building = nil, 0, false
+			st.built--
+		}
+	}
+}
+
+func (st *solutionTables) remove(el *list.Element) {
+	table := st.lru.Remove(el).(*solutionTable)
+	delete(st.entries, table.key)
+	if table.solutions != nil {
+		st.built--
+	}
+}
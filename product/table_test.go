@@ -0,0 +1,90 @@
+package product_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/silvan-talos/shipping/mock"
+	"github.com/silvan-talos/shipping/product"
+)
+
+// lookedUp tells whether the last solver run recorded was served by a precomputed table
+func lookedUp(t *testing.T, recorder *tracetest.SpanRecorder) bool {
+	spans := recorder.Ended()
+	require.NotEmpty(t, spans)
+	for _, kv := range spans[len(spans)-1].Attributes() {
+		if kv.Key == "shipping.table_lookup" {
+			return kv.Value.AsBool()
+		}
+	}
+	t.Fatal("solver span without the table_lookup attribute")
+	return false
+}
+
+func TestService_CalculatePackingWithTables(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	// the solver spans are recorded by the global provider, restored for the other tests
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = tp.Shutdown(context.Background())
+	})
+
+	var mtx sync.Mutex
+	sizes := []uint64{23, 31, 53}
+	packs := &mock.PackRepository{
+		GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			return sizes, nil
+		},
+		UpdateConfigFn: func(ctx context.Context, productID uint64, config []uint64) ([]uint64, error) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			sizes = config
+			return nil, nil
+		},
+	}
+	s := product.NewService(product.ServiceArgs{
+		Packs:      packs,
+		TableBound: 1000,
+	})
+	calculate := func(qty uint64, sizes []uint64) {
+		packing, err := s.CalculatePacking(tenantCtx, 1, qty, "")
+		require.NoError(t, err)
+		expectedPacks, expectedOverhead, err := product.CalculatePacks(qty, sizes)
+		require.NoError(t, err)
+		require.Equal(t, expectedPacks, packing.Packs, "qty %d", qty)
+		require.Equal(t, expectedOverhead, packing.Overhead, "qty %d", qty)
+	}
+
+	// the calculations turning the sizes hot are solved while their table is built in the background
+	for qty := uint64(0); qty < 100; qty++ {
+		calculate(qty, []uint64{23, 31, 53})
+		require.False(t, lookedUp(t, recorder), "qty %d", qty)
+	}
+	require.Eventually(t, func() bool {
+		calculate(500, []uint64{23, 31, 53})
+		return lookedUp(t, recorder)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the quantities up to the bound are looked up, the ones above are solved
+	for qty := uint64(0); qty <= 1200; qty++ {
+		calculate(qty, []uint64{23, 31, 53})
+		require.Equal(t, qty <= 1000, lookedUp(t, recorder), "qty %d", qty)
+	}
+
+	// the new sizes are solved until they turn hot in turn
+	require.NoError(t, s.UpdatePacksConfiguration(tenantCtx, 1, []uint64{250, 500}))
+	calculate(251, []uint64{250, 500})
+	require.False(t, lookedUp(t, recorder))
+}
@@ -0,0 +1,49 @@
+package shipping
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// MaxQuantity and MaxPackSize are the largest ordered quantity and pack size the calculations support.
+// Their sum fits in an int64, so the capacity of a packing never overflows.
+const (
+	MaxQuantity uint64 = math.MaxInt64 / 2
+	MaxPackSize uint64 = math.MaxInt64 / 2
+)
+
+// ErrOutOfRange is matched by every RangeError.
+var ErrOutOfRange = errors.New("out of range")
+
+// RangeError reports an input, or a result calculated from it, above the largest value supported.
+type RangeError struct {
+	Field string
+	Max   uint64
+}
+
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("invalid request: %s exceeds the maximum of %d", e.Field, e.Max)
+}
+
+func (e *RangeError) Is(target error) bool {
+	return target == ErrOutOfRange
+}
+
+// CheckQuantity returns a RangeError when qty is above MaxQuantity.
+func CheckQuantity(qty uint64) error {
+	if qty > MaxQuantity {
+		return &RangeError{Field: "quantity", Max: MaxQuantity}
+	}
+	return nil
+}
+
+// CheckPackSizes returns a RangeError when one of the sizes is above MaxPackSize.
+func CheckPackSizes(sizes []uint64) error {
+	for _, size := range sizes {
+		if size > MaxPackSize {
+			return &RangeError{Field: "pack size", Max: MaxPackSize}
+		}
+	}
+	return nil
+}
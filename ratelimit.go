@@ -0,0 +1,28 @@
+package shipping
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimit is a token bucket refilled with Rate tokens per second up to Burst tokens, every request taking one.
+// Quota optionally bounds the requests of a day, in UTC, 0 meaning unbounded.
+type RateLimit struct {
+	Rate  float64 `json:"rate" validate:"gt=0"`
+	Burst int     `json:"burst" validate:"gte=1"`
+	Quota int64   `json:"daily_quota" validate:"gte=0"`
+}
+
+// RateDecision tells whether a request was allowed, and otherwise how long the client should wait.
+type RateDecision struct {
+	Allowed    bool
+	RetryAfter time.Duration
+	// Remaining is the number of requests left in the daily quota, -1 without quota
+	Remaining int64
+}
+
+// RateLimitStore keeps the buckets of every client. Take must be atomic, so instances sharing a store
+// enforce the limits together.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit, now time.Time) (RateDecision, error)
+}
@@ -0,0 +1,12 @@
+package shipping
+
+// Recommendation is a set of pack sizes recommended for a demand distribution, along with the expected
+// overhead and pack count per order it results in.
+type Recommendation struct {
+	PackSizes        []uint64 `json:"pack_sizes"`
+	ExpectedOverhead float64  `json:"expected_overhead"`
+	ExpectedPacks    float64  `json:"expected_packs"`
+	Orders           int      `json:"orders"`
+	// Seed of the search, the same demand and seed always result in the same recommendation
+	Seed int64 `json:"seed"`
+}
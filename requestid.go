@@ -0,0 +1,45 @@
+package shipping
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID correlating the logs of a request.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the ID of the request ctx belongs to.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// maxRequestIDLength bounds the IDs taken from callers, longer ones are replaced
+const maxRequestIDLength = 128
+
+// ValidRequestID tells whether an ID given by a caller can be used to correlate its logs, it must be made of at
+// most 128 printable ASCII characters.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewRequestID returns a random ID for the requests made without a valid one.
+func NewRequestID() string {
+	id := make([]byte, 16)
+	// crypto/rand does not fail on the supported platforms
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
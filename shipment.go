@@ -0,0 +1,79 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidTransition = errors.New("invalid shipment status transition")
+	// ErrStatusChanged is returned when the status of a shipment changed since it was read
+	ErrStatusChanged = errors.New("shipment status changed concurrently")
+)
+
+type ShipmentStatus string
+
+const (
+	StatusPlanned    ShipmentStatus = "planned"
+	StatusPacked     ShipmentStatus = "packed"
+	StatusDispatched ShipmentStatus = "dispatched"
+	StatusDelivered  ShipmentStatus = "delivered"
+	StatusCancelled  ShipmentStatus = "cancelled"
+)
+
+// transitions lists the statuses reachable from every status
+var transitions = map[ShipmentStatus][]ShipmentStatus{
+	StatusPlanned:    {StatusPacked, StatusCancelled},
+	StatusPacked:     {StatusDispatched, StatusCancelled},
+	StatusDispatched: {StatusDelivered},
+}
+
+// Shipment records the packing computed for an order. Packing is a snapshot taken when
+// the shipment was planned and doesn't change with the product configuration.
+// Replanned shipments use fewer pack sizes than configured, because of the warehouse stock.
+type Shipment struct {
+	ID        uint64         `json:"id"`
+	Tenant    string         `json:"-"`
+	OrderID   string         `json:"order_id"`
+	Warehouse string         `json:"warehouse"`
+	ProductID uint64         `json:"product_id"`
+	Quantity  uint64         `json:"quantity"`
+	Packing   Packing        `json:"packing"`
+	Replanned bool           `json:"replanned"`
+	Status    ShipmentStatus `json:"status"`
+	History   []StatusChange `json:"history"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type StatusChange struct {
+	Status ShipmentStatus `json:"status"`
+	At     time.Time      `json:"at"`
+}
+
+// Transition moves the shipment to the provided status if allowed by its lifecycle.
+func (s *Shipment) Transition(status ShipmentStatus, at time.Time) error {
+	for _, next := range transitions[s.Status] {
+		if next == status {
+			s.Status = status
+			s.UpdatedAt = at
+			s.History = append(s.History, StatusChange{
+				Status: status,
+				At:     at,
+			})
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, s.Status, status)
+}
+
+type ShipmentRepository interface {
+	Store(ctx context.Context, shipment Shipment) (uint64, error)
+	GetByID(ctx context.Context, id uint64) (Shipment, error)
+	FindByOrderID(ctx context.Context, orderID string) ([]Shipment, error)
+	// Update replaces the shipment if its status is still expected, returning ErrStatusChanged otherwise,
+	// so concurrent transitions of the same shipment cannot both succeed.
+	Update(ctx context.Context, shipment Shipment, expected ShipmentStatus) error
+}
@@ -0,0 +1,247 @@
+package shipment
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/product"
+)
+
+var (
+	ErrMissingOrderID   = errors.New("invalid request: order id cannot be empty")
+	ErrMissingWarehouse = errors.New("invalid request: warehouse cannot be empty")
+	ErrZeroQuantity     = errors.New("invalid request: quantity must be positive")
+)
+
+// maxUpdateAttempts bounds the attempts to update a status changed concurrently by other requests
+const maxUpdateAttempts = 3
+
+type Service interface {
+	PlanShipment(ctx context.Context, orderID, warehouse string, productID, qty uint64) (shipping.Shipment, error)
+	GetShipment(ctx context.Context, id uint64) (shipping.Shipment, error)
+	FindShipments(ctx context.Context, orderID string) ([]shipping.Shipment, error)
+	UpdateStatus(ctx context.Context, id uint64, status shipping.ShipmentStatus) (shipping.Shipment, error)
+}
+
+type service struct {
+	shipments shipping.ShipmentRepository
+	inventory shipping.InventoryRepository
+	products  product.Service
+}
+
+func NewService(args ServiceArgs) Service {
+	err := shipping.Validate.Struct(args)
+	if err != nil {
+		log.Fatal("failed to create shipment service, err:", err)
+	}
+
+	return &service{
+		shipments: args.Shipments,
+		inventory: args.Inventory,
+		products:  args.Products,
+	}
+}
+
+type ServiceArgs struct {
+	Shipments shipping.ShipmentRepository  `validate:"required"`
+	Inventory shipping.InventoryRepository `validate:"required"`
+	Products  product.Service              `validate:"required"`
+}
+
+func (s *service) PlanShipment(ctx context.Context, orderID, warehouse string, productID, qty uint64) (shipping.Shipment, error) {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return shipping.Shipment{}, shipping.ErrMissingTenant
+	}
+	if orderID == "" {
+		return shipping.Shipment{}, ErrMissingOrderID
+	}
+	if warehouse == "" {
+		return shipping.Shipment{}, ErrMissingWarehouse
+	}
+	if qty == 0 {
+		return shipping.Shipment{}, ErrZeroQuantity
+	}
+	packing, err := s.products.CalculatePacking(ctx, productID, qty, warehouse)
+	if err != nil {
+		return shipping.Shipment{}, err
+	}
+	replanned, err := s.reservePacks(ctx, warehouse, qty, &packing)
+	if err != nil {
+		return shipping.Shipment{}, err
+	}
+	now := time.Now().UTC()
+	shipment := shipping.Shipment{
+		Tenant:    tenant,
+		OrderID:   orderID,
+		Warehouse: warehouse,
+		ProductID: productID,
+		Quantity:  qty,
+		Packing:   packing,
+		Replanned: replanned,
+		Status:    shipping.StatusPlanned,
+		History: []shipping.StatusChange{
+			{
+				Status: shipping.StatusPlanned,
+				At:     now,
+			},
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	shipment.ID, err = s.shipments.Store(ctx, shipment)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to store shipment", "err", err)
+		s.releasePacks(ctx, warehouse, packing.Packs)
+		return shipping.Shipment{}, shipping.InternalServerErr
+	}
+	return shipment, nil
+}
+
+func (s *service) GetShipment(ctx context.Context, id uint64) (shipping.Shipment, error) {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return shipping.Shipment{}, shipping.ErrMissingTenant
+	}
+	shipment, err := s.shipments.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, shipping.ErrNotFound) {
+			return shipping.Shipment{}, err
+		}
+		slog.ErrorContext(ctx, "failed to get shipment", "shipment_id", id, "err", err)
+		return shipping.Shipment{}, shipping.InternalServerErr
+	}
+	// shipments of other tenants are reported as missing, so their IDs are not disclosed
+	if shipment.Tenant != tenant {
+		return shipping.Shipment{}, shipping.ErrNotFound
+	}
+	return shipment, nil
+}
+
+func (s *service) FindShipments(ctx context.Context, orderID string) ([]shipping.Shipment, error) {
+	tenant, ok := shipping.TenantFromContext(ctx)
+	if !ok {
+		return nil, shipping.ErrMissingTenant
+	}
+	if orderID == "" {
+		return nil, ErrMissingOrderID
+	}
+	shipments, err := s.shipments.FindByOrderID(ctx, orderID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find shipments", "order_id", orderID, "err", err)
+		return nil, shipping.InternalServerErr
+	}
+	res := make([]shipping.Shipment, 0, len(shipments))
+	for _, shipment := range shipments {
+		if shipment.Tenant == tenant {
+			res = append(res, shipment)
+		}
+	}
+	return res, nil
+}
+
+func (s *service) UpdateStatus(ctx context.Context, id uint64, status shipping.ShipmentStatus) (shipping.Shipment, error) {
+	for attempt := 1; ; attempt++ {
+		shipment, err := s.GetShipment(ctx, id)
+		if err != nil {
+			return shipping.Shipment{}, err
+		}
+		previous := shipment.Status
+		err = shipment.Transition(status, time.Now().UTC())
+		if err != nil {
+			return shipping.Shipment{}, err
+		}
+		err = s.shipments.Update(ctx, shipment, previous)
+		if errors.Is(err, shipping.ErrStatusChanged) && attempt < maxUpdateAttempts {
+			// the transition is checked again against the status the shipment moved to
+			continue
+		}
+		if err != nil {
+			if errors.Is(err, shipping.ErrNotFound) || errors.Is(err, shipping.ErrStatusChanged) {
+				return shipping.Shipment{}, err
+			}
+			slog.ErrorContext(ctx, "failed to update shipment", "shipment_id", id, "err", err)
+			return shipping.Shipment{}, shipping.InternalServerErr
+		}
+		// the packs of packed shipments were used, only the ones of planned shipments go back to the stock
+		if status == shipping.StatusCancelled && previous == shipping.StatusPlanned {
+			s.releasePacks(ctx, shipment.Warehouse, shipment.Packing.Packs)
+		}
+		return shipment, nil
+	}
+}
+
+// reservePacks reserves the recommended packs from the warehouse stock. When they are not in stock,
+// the packing is replanned without the pack sizes the warehouse is short of.
+func (s *service) reservePacks(ctx context.Context, warehouse string, qty uint64, packing *shipping.Packing) (bool, error) {
+	err := s.inventory.Reserve(ctx, warehouse, packing.Packs)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, shipping.ErrInsufficientStock) {
+		return false, inventoryError(ctx, err)
+	}
+	stock, err := s.inventory.GetStock(ctx, warehouse)
+	if err != nil {
+		return false, inventoryError(ctx, err)
+	}
+	sizes := make([]uint64, 0, len(packing.PackSizes))
+	for _, size := range packing.PackSizes {
+		if stock[size] > 0 {
+			sizes = append(sizes, size)
+		}
+	}
+	for len(sizes) > 0 {
+		packs, overhead, err := product.CalculatePacks(qty, sizes)
+		if err != nil {
+			return false, err
+		}
+		available := sizes[:0]
+		for _, size := range sizes {
+			if countOf(packs, size) <= stock[size] {
+				available = append(available, size)
+			}
+		}
+		if len(available) < len(sizes) {
+			sizes = available
+			continue
+		}
+		err = s.inventory.Reserve(ctx, warehouse, packs)
+		if err != nil {
+			return false, inventoryError(ctx, err)
+		}
+		slog.InfoContext(ctx, "shipment replanned with the pack sizes in stock", "warehouse", warehouse, "sizes", sizes)
+		packing.Packs = packs
+		packing.Overhead = overhead
+		return true, nil
+	}
+	return false, shipping.ErrInsufficientStock
+}
+
+func (s *service) releasePacks(ctx context.Context, warehouse string, packs []shipping.PackConfig) {
+	err := s.inventory.Release(ctx, warehouse, packs)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to release packs", "warehouse", warehouse, "err", err)
+	}
+}
+
+func inventoryError(ctx context.Context, err error) error {
+	if errors.Is(err, shipping.ErrNotFound) || errors.Is(err, shipping.ErrInsufficientStock) {
+		return err
+	}
+	slog.ErrorContext(ctx, "failed to reserve packs", "err", err)
+	return shipping.InternalServerErr
+}
+
+func countOf(packs []shipping.PackConfig, size uint64) int64 {
+	for _, p := range packs {
+		if p.Size == size {
+			return p.Count
+		}
+	}
+	return 0
+}
@@ -0,0 +1,344 @@
+package shipment_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/inmem"
+	"github.com/silvan-talos/shipping/mock"
+	"github.com/silvan-talos/shipping/product"
+	"github.com/silvan-talos/shipping/shipment"
+)
+
+// tenantCtx is the context of a caller authenticated as tenant-1
+var tenantCtx = shipping.WithTenant(context.Background(), "tenant-1")
+
+func TestService_PlanShipment(t *testing.T) {
+	var released []shipping.PackConfig
+	tests := map[string]struct {
+		orderID           string
+		warehouse         string
+		qty               uint64
+		packs             shipping.PackRepository
+		inventory         shipping.InventoryRepository
+		shipments         shipping.ShipmentRepository
+		expectedPacking   shipping.Packing
+		expectedReplanned bool
+		expectedErr       error
+	}{
+		"planShipment_successful": {
+			orderID:   "order-1",
+			warehouse: "w1",
+			qty:       501,
+			packs: &mock.PackRepository{
+				GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+					return []uint64{500, 250}, nil
+				},
+			},
+			shipments: &mock.ShipmentRepository{},
+			expectedPacking: shipping.Packing{
+				PackSizes: []uint64{250, 500},
+				Source:    shipping.LevelProduct,
+				Packs:     []shipping.PackConfig{{Count: 1, Size: 500}, {Count: 1, Size: 250}},
+				Overhead:  249,
+			},
+		},
+		"stockShort_replannedWithSizesInStock": {
+			orderID:   "order-1",
+			warehouse: "w1",
+			qty:       501,
+			packs: &mock.PackRepository{
+				GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+					return []uint64{500, 250}, nil
+				},
+			},
+			inventory: &mock.InventoryRepository{
+				ReserveFn: func(ctx context.Context, warehouse string, packs []shipping.PackConfig) error {
+					if len(packs) == 1 && packs[0] == (shipping.PackConfig{Count: 3, Size: 250}) {
+						return nil
+					}
+					return shipping.ErrInsufficientStock
+				},
+				GetStockFn: func(ctx context.Context, warehouse string) (map[uint64]int64, error) {
+					return map[uint64]int64{250: 3}, nil
+				},
+			},
+			shipments: &mock.ShipmentRepository{},
+			expectedPacking: shipping.Packing{
+				PackSizes: []uint64{250, 500},
+				Source:    shipping.LevelProduct,
+				Packs:     []shipping.PackConfig{{Count: 3, Size: 250}},
+				Overhead:  249,
+			},
+			expectedReplanned: true,
+		},
+		"stockShortForAllSizes_returnErrInsufficientStock": {
+			orderID:   "order-1",
+			warehouse: "w1",
+			qty:       501,
+			packs:     &mock.PackRepository{},
+			inventory: &mock.InventoryRepository{
+				ReserveFn: func(ctx context.Context, warehouse string, packs []shipping.PackConfig) error {
+					return shipping.ErrInsufficientStock
+				},
+				GetStockFn: func(ctx context.Context, warehouse string) (map[uint64]int64, error) {
+					return map[uint64]int64{250: 2, 500: 0}, nil
+				},
+			},
+			shipments:   &mock.ShipmentRepository{},
+			expectedErr: shipping.ErrInsufficientStock,
+		},
+		"warehouseWithoutStock_returnErrNotFound": {
+			orderID:   "order-1",
+			warehouse: "w1",
+			qty:       1,
+			packs:     &mock.PackRepository{},
+			inventory: &mock.InventoryRepository{
+				ReserveFn: func(ctx context.Context, warehouse string, packs []shipping.PackConfig) error {
+					return shipping.ErrNotFound
+				},
+			},
+			shipments:   &mock.ShipmentRepository{},
+			expectedErr: shipping.ErrNotFound,
+		},
+		"warehouseMissing_invalidRequest": {
+			orderID:     "order-1",
+			qty:         1,
+			packs:       &mock.PackRepository{},
+			shipments:   &mock.ShipmentRepository{},
+			expectedErr: shipment.ErrMissingWarehouse,
+		},
+		"orderIdMissing_invalidRequest": {
+			warehouse:   "w1",
+			qty:         1,
+			packs:       &mock.PackRepository{},
+			shipments:   &mock.ShipmentRepository{},
+			expectedErr: shipment.ErrMissingOrderID,
+		},
+		"zeroQuantity_invalidRequest": {
+			orderID:     "order-1",
+			warehouse:   "w1",
+			packs:       &mock.PackRepository{},
+			shipments:   &mock.ShipmentRepository{},
+			expectedErr: shipment.ErrZeroQuantity,
+		},
+		"configurationNotFound_returnErrNotFound": {
+			orderID:   "order-1",
+			warehouse: "w1",
+			qty:       1,
+			packs: &mock.PackRepository{
+				GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+					return nil, shipping.ErrNotFound
+				},
+				GetDefaultFn: func(ctx context.Context) ([]uint64, error) {
+					return nil, shipping.ErrNotFound
+				},
+			},
+			shipments:   &mock.ShipmentRepository{},
+			expectedErr: shipping.ErrNotFound,
+		},
+		"failedToStoreShipment_packsReleasedAndInternalError": {
+			orderID:   "order-1",
+			warehouse: "w1",
+			qty:       1,
+			packs:     &mock.PackRepository{},
+			inventory: &mock.InventoryRepository{
+				ReleaseFn: func(ctx context.Context, warehouse string, packs []shipping.PackConfig) error {
+					released = packs
+					return nil
+				},
+			},
+			shipments: &mock.ShipmentRepository{
+				StoreFn: func(ctx context.Context, shipment shipping.Shipment) (uint64, error) {
+					return 0, errors.New("failed to store shipment")
+				},
+			},
+			expectedErr: shipping.InternalServerErr,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			inventory := tc.inventory
+			if inventory == nil {
+				inventory = &mock.InventoryRepository{}
+			}
+			s := shipment.NewService(shipment.ServiceArgs{
+				Shipments: tc.shipments,
+				Inventory: inventory,
+				Products: product.NewService(product.ServiceArgs{
+					Packs: tc.packs,
+				}),
+			})
+			res, err := s.PlanShipment(tenantCtx, tc.orderID, tc.warehouse, 1, tc.qty)
+			require.Equal(t, tc.expectedErr, err, "errors must match")
+			if err == nil {
+				require.Equal(t, uint64(1), res.ID)
+				require.Equal(t, tc.warehouse, res.Warehouse)
+				require.Equal(t, shipping.StatusPlanned, res.Status)
+				require.Equal(t, tc.expectedPacking, res.Packing, "packing must match")
+				require.Equal(t, tc.expectedReplanned, res.Replanned)
+				require.Len(t, res.History, 1)
+			}
+		})
+	}
+	require.Equal(t, []shipping.PackConfig{{Count: 1, Size: 250}}, released, "packs must be released when the shipment is not stored")
+}
+
+func TestService_UpdateStatus(t *testing.T) {
+	tests := map[string]struct {
+		status           shipping.ShipmentStatus
+		shipments        shipping.ShipmentRepository
+		expectedReleased []shipping.PackConfig
+		expectedErr      error
+	}{
+		"packPlannedShipment_successful": {
+			status:    shipping.StatusPacked,
+			shipments: &mock.ShipmentRepository{},
+		},
+		"cancelPlannedShipment_packsReleased": {
+			status:           shipping.StatusCancelled,
+			shipments:        &mock.ShipmentRepository{},
+			expectedReleased: []shipping.PackConfig{{Count: 1, Size: 500}},
+		},
+		"deliverPlannedShipment_invalidTransition": {
+			status:      shipping.StatusDelivered,
+			shipments:   &mock.ShipmentRepository{},
+			expectedErr: shipping.ErrInvalidTransition,
+		},
+		"cancelDeliveredShipment_invalidTransition": {
+			status: shipping.StatusCancelled,
+			shipments: &mock.ShipmentRepository{
+				GetByIDFn: func(ctx context.Context, id uint64) (shipping.Shipment, error) {
+					return shipping.Shipment{ID: id, Tenant: "tenant-1", Status: shipping.StatusDelivered}, nil
+				},
+			},
+			expectedErr: shipping.ErrInvalidTransition,
+		},
+		"shipmentOfAnotherTenant_returnErrNotFound": {
+			status: shipping.StatusPacked,
+			shipments: &mock.ShipmentRepository{
+				GetByIDFn: func(ctx context.Context, id uint64) (shipping.Shipment, error) {
+					return shipping.Shipment{ID: id, Tenant: "tenant-2", Status: shipping.StatusPlanned}, nil
+				},
+			},
+			expectedErr: shipping.ErrNotFound,
+		},
+		"shipmentNotFound_returnErrNotFound": {
+			status: shipping.StatusPacked,
+			shipments: &mock.ShipmentRepository{
+				GetByIDFn: func(ctx context.Context, id uint64) (shipping.Shipment, error) {
+					return shipping.Shipment{}, shipping.ErrNotFound
+				},
+			},
+			expectedErr: shipping.ErrNotFound,
+		},
+		"failedToUpdateShipment_internalError": {
+			status: shipping.StatusPacked,
+			shipments: &mock.ShipmentRepository{
+				UpdateFn: func(ctx context.Context, shipment shipping.Shipment, expected shipping.ShipmentStatus) error {
+					return errors.New("failed to update shipment")
+				},
+			},
+			expectedErr: shipping.InternalServerErr,
+		},
+		"cancelPackedShipment_packsNotReleased": {
+			status: shipping.StatusCancelled,
+			shipments: &mock.ShipmentRepository{
+				GetByIDFn: func(ctx context.Context, id uint64) (shipping.Shipment, error) {
+					return shipping.Shipment{
+						ID:      id,
+						Tenant:  "tenant-1",
+						Packing: shipping.Packing{Packs: []shipping.PackConfig{{Count: 1, Size: 500}}},
+						Status:  shipping.StatusPacked,
+					}, nil
+				},
+			},
+		},
+		"statusKeepsChanging_returnErrStatusChanged": {
+			status: shipping.StatusCancelled,
+			shipments: &mock.ShipmentRepository{
+				UpdateFn: func(ctx context.Context, shipment shipping.Shipment, expected shipping.ShipmentStatus) error {
+					return shipping.ErrStatusChanged
+				},
+			},
+			expectedErr: shipping.ErrStatusChanged,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var released []shipping.PackConfig
+			s := shipment.NewService(shipment.ServiceArgs{
+				Shipments: tc.shipments,
+				Inventory: &mock.InventoryRepository{
+					ReleaseFn: func(ctx context.Context, warehouse string, packs []shipping.PackConfig) error {
+						released = packs
+						return nil
+					},
+				},
+				Products: product.NewService(product.ServiceArgs{
+					Packs: &mock.PackRepository{},
+				}),
+			})
+			res, err := s.UpdateStatus(tenantCtx, 1, tc.status)
+			require.Equal(t, tc.expectedReleased, released, "released packs must match")
+			require.ErrorIs(t, err, tc.expectedErr, "errors must match")
+			if tc.expectedErr == nil {
+				require.NoError(t, err)
+				require.Equal(t, tc.status, res.Status)
+				require.Equal(t, tc.status, res.History[len(res.History)-1].Status)
+			}
+		})
+	}
+}
+
+func TestService_UpdateStatusConcurrently(t *testing.T) {
+	inventory := inmem.NewInventoryRepository()
+	require.NoError(t, inventory.UpdateStock(tenantCtx, "w1", map[uint64]int64{250: 10, 500: 10}))
+	s := shipment.NewService(shipment.ServiceArgs{
+		Shipments: inmem.NewShipmentRepository(),
+		Inventory: inventory,
+		Products: product.NewService(product.ServiceArgs{
+			Packs: &mock.PackRepository{
+				GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+					return []uint64{250, 500}, nil
+				},
+			},
+		}),
+	})
+	planned, err := s.PlanShipment(tenantCtx, "order-1", "w1", 1, 750)
+	require.NoError(t, err)
+
+	var cancelled, rejected int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.UpdateStatus(tenantCtx, planned.ID, shipping.StatusCancelled)
+			switch {
+			case err == nil:
+				atomic.AddInt64(&cancelled, 1)
+			case errors.Is(err, shipping.ErrInvalidTransition), errors.Is(err, shipping.ErrStatusChanged):
+				atomic.AddInt64(&rejected, 1)
+			default:
+				t.Error("unexpected error:", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int64(1), cancelled, "the shipment must be cancelled once")
+	require.Equal(t, int64(49), rejected)
+	stock, err := inventory.GetStock(tenantCtx, "w1")
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]int64{250: 10, 500: 10}, stock, "packs must be released once")
+	res, err := s.GetShipment(tenantCtx, planned.ID)
+	require.NoError(t, err)
+	require.Len(t, res.History, 2, "no status change may be lost")
+}
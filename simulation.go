@@ -0,0 +1,22 @@
+package shipping
+
+// Simulation compares the packing of historical orders with the current and with a candidate set of pack sizes.
+type Simulation struct {
+	Orders    int              `json:"orders"`
+	Currency  string           `json:"currency,omitempty"`
+	Current   SimulationResult `json:"current"`
+	Candidate SimulationResult `json:"candidate"`
+	// Delta is the candidate result minus the current one, negative values are savings
+	Delta SimulationResult `json:"delta"`
+}
+
+// SimulationResult aggregates the packing of all simulated orders with a set of pack sizes. Cost is only
+// calculated when a carrier is given and only covers the orders the carrier can ship with both sets,
+// the orders it cannot ship with this set are counted as unpriced.
+type SimulationResult struct {
+	PackSizes []uint64 `json:"pack_sizes,omitempty"`
+	Overhead  int64    `json:"overhead"`
+	Packs     int64    `json:"packs"`
+	Cost      int64    `json:"cost"`
+	Unpriced  int      `json:"unpriced"`
+}
@@ -0,0 +1,21 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrMissingTenant = errors.New("missing tenant")
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx scoped to the tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant ctx is scoped to.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok && tenant != ""
+}
@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"log"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/silvan-talos/shipping"
+)
+
+type PackRepositoryArgs struct {
+	Next shipping.PackRepository `validate:"required"`
+	// Name tells the repositories apart in the spans, e.g. inmem or cache
+	Name string `validate:"required"`
+	// TracerProvider defaults to the global one
+	TracerProvider trace.TracerProvider
+}
+
+// PackRepository starts a span for every call to the next repository.
+type PackRepository struct {
+	next   shipping.PackRepository
+	name   string
+	tracer trace.Tracer
+}
+
+func NewPackRepository(args PackRepositoryArgs) *PackRepository {
+	err := shipping.Validate.Struct(args)
+	if err != nil {
+		log.Fatal("failed to create traced pack repository, err:", err)
+	}
+
+	return &PackRepository{
+		next:   args.Next,
+		name:   args.Name,
+		tracer: tracer(args.TracerProvider),
+	}
+}
+
+func (pr *PackRepository) GetByProductID(ctx context.Context, productID uint64) ([]uint64, error) {
+	ctx, span := pr.start(ctx, "GetByProductID", productIDKey.Int64(int64(productID)))
+	config, err := pr.next.GetByProductID(ctx, productID)
+	end(span, err)
+	return config, err
+}
+
+func (pr *PackRepository) GetByWarehouse(ctx context.Context, warehouse string, productID uint64) ([]uint64, error) {
+	ctx, span := pr.start(ctx, "GetByWarehouse", warehouseKey.String(warehouse), productIDKey.Int64(int64(productID)))
+	config, err := pr.next.GetByWarehouse(ctx, warehouse, productID)
+	end(span, err)
+	return config, err
+}
+
+func (pr *PackRepository) GetDefault(ctx context.Context) ([]uint64, error) {
+	ctx, span := pr.start(ctx, "GetDefault")
+	config, err := pr.next.GetDefault(ctx)
+	end(span, err)
+	return config, err
+}
+
+func (pr *PackRepository) UpdateConfig(ctx context.Context, productID uint64, config []uint64) ([]uint64, error) {
+	ctx, span := pr.start(ctx, "UpdateConfig", productIDKey.Int64(int64(productID)), packSizesKey.Int(len(config)))
+	previous, err := pr.next.UpdateConfig(ctx, productID, config)
+	end(span, err)
+	return previous, err
+}
+
+func (pr *PackRepository) UpdateWarehouseConfig(ctx context.Context, warehouse string, productID uint64, config []uint64) ([]uint64, error) {
+	ctx, span := pr.start(ctx, "UpdateWarehouseConfig",
+		warehouseKey.String(warehouse), productIDKey.Int64(int64(productID)), packSizesKey.Int(len(config)))
+	previous, err := pr.next.UpdateWarehouseConfig(ctx, warehouse, productID, config)
+	end(span, err)
+	return previous, err
+}
+
+func (pr *PackRepository) UpdateDefault(ctx context.Context, config []uint64) ([]uint64, error) {
+	ctx, span := pr.start(ctx, "UpdateDefault", packSizesKey.Int(len(config)))
+	previous, err := pr.next.UpdateDefault(ctx, config)
+	end(span, err)
+	return previous, err
+}
+
+func (pr *PackRepository) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return pr.tracer.Start(ctx, "PackRepository."+method,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(append(attrs, repositoryKey.String(pr.name))...),
+	)
+}
@@ -0,0 +1,141 @@
+package tracing
+
+import (
+	"context"
+	"log"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/product"
+)
+
+type ProductServiceArgs struct {
+	Next product.Service `validate:"required"`
+	// TracerProvider defaults to the global one
+	TracerProvider trace.TracerProvider
+}
+
+// ProductService starts a span for every call to the next service, annotated with the product, the quantity
+// and the packing it results in.
+type ProductService struct {
+	next   product.Service
+	tracer trace.Tracer
+}
+
+func NewProductService(args ProductServiceArgs) *ProductService {
+	err := shipping.Validate.Struct(args)
+	if err != nil {
+		log.Fatal("failed to create traced product service, err:", err)
+	}
+
+	return &ProductService{
+		next:   args.Next,
+		tracer: tracer(args.TracerProvider),
+	}
+}
+
+func (ps *ProductService) CalculatePacksConfiguration(ctx context.Context, id, qty uint64) ([]shipping.PackConfig, error) {
+	ctx, span := ps.start(ctx, "CalculatePacksConfiguration", productIDKey.Int64(int64(id)), quantityKey.Int64(int64(qty)))
+	packs, err := ps.next.CalculatePacksConfiguration(ctx, id, qty)
+	if err == nil {
+		span.SetAttributes(packCountKey.Int64(countPacks(packs)))
+	}
+	end(span, err)
+	return packs, err
+}
+
+func (ps *ProductService) CalculatePacking(ctx context.Context, id, qty uint64, warehouse string) (shipping.Packing, error) {
+	ctx, span := ps.start(ctx, "CalculatePacking",
+		productIDKey.Int64(int64(id)), quantityKey.Int64(int64(qty)), warehouseKey.String(warehouse))
+	packing, err := ps.next.CalculatePacking(ctx, id, qty, warehouse)
+	if err == nil {
+		span.SetAttributes(
+			sourceKey.String(string(packing.Source)),
+			packCountKey.Int64(countPacks(packing.Packs)),
+			overheadKey.Int64(packing.Overhead),
+		)
+	}
+	end(span, err)
+	return packing, err
+}
+
+func (ps *ProductService) CalculateCostOptimalPacks(ctx context.Context, id, qty uint64, warehouse, carrier string) (shipping.CostOptimalPacking, error) {
+	ctx, span := ps.start(ctx, "CalculateCostOptimalPacks",
+		productIDKey.Int64(int64(id)), quantityKey.Int64(int64(qty)), warehouseKey.String(warehouse), carrierKey.String(carrier))
+	packing, err := ps.next.CalculateCostOptimalPacks(ctx, id, qty, warehouse, carrier)
+	if err == nil {
+		span.SetAttributes(
+			sourceKey.String(string(packing.Source)),
+			packCountKey.Int64(countPacks(packing.Packs)),
+			overheadKey.Int64(packing.Overhead),
+		)
+	}
+	end(span, err)
+	return packing, err
+}
+
+func (ps *ProductService) SimulatePackSizes(ctx context.Context, id uint64, warehouse string, candidate, quantities []uint64, carrier string) (shipping.Simulation, error) {
+	ctx, span := ps.start(ctx, "SimulatePackSizes",
+		productIDKey.Int64(int64(id)), warehouseKey.String(warehouse), carrierKey.String(carrier), simulatedKey.Int(len(quantities)))
+	simulation, err := ps.next.SimulatePackSizes(ctx, id, warehouse, candidate, quantities, carrier)
+	end(span, err)
+	return simulation, err
+}
+
+func (ps *ProductService) RecommendPackSizes(ctx context.Context, req product.RecommendRequest) (shipping.Recommendation, error) {
+	ctx, span := ps.start(ctx, "RecommendPackSizes", simulatedKey.Int(len(req.Quantities)))
+	recommendation, err := ps.next.RecommendPackSizes(ctx, req)
+	if err == nil {
+		span.SetAttributes(recommendedKey.Int(len(recommendation.PackSizes)))
+	}
+	end(span, err)
+	return recommendation, err
+}
+
+func (ps *ProductService) GetPacksConfiguration(ctx context.Context, id uint64, warehouse string) ([]uint64, shipping.ConfigLevel, error) {
+	ctx, span := ps.start(ctx, "GetPacksConfiguration", productIDKey.Int64(int64(id)), warehouseKey.String(warehouse))
+	sizes, source, err := ps.next.GetPacksConfiguration(ctx, id, warehouse)
+	if err == nil {
+		span.SetAttributes(sourceKey.String(string(source)))
+	}
+	end(span, err)
+	return sizes, source, err
+}
+
+func (ps *ProductService) UpdatePacksConfiguration(ctx context.Context, id uint64, config []uint64) error {
+	ctx, span := ps.start(ctx, "UpdatePacksConfiguration", productIDKey.Int64(int64(id)), packSizesKey.Int(len(config)))
+	err := ps.next.UpdatePacksConfiguration(ctx, id, config)
+	end(span, err)
+	return err
+}
+
+func (ps *ProductService) UpdateWarehousePacksConfiguration(ctx context.Context, warehouse string, id uint64, config []uint64) error {
+	ctx, span := ps.start(ctx, "UpdateWarehousePacksConfiguration",
+		warehouseKey.String(warehouse), productIDKey.Int64(int64(id)), packSizesKey.Int(len(config)))
+	err := ps.next.UpdateWarehousePacksConfiguration(ctx, warehouse, id, config)
+	end(span, err)
+	return err
+}
+
+func (ps *ProductService) GetDefaultPacksConfiguration(ctx context.Context) ([]uint64, error) {
+	ctx, span := ps.start(ctx, "GetDefaultPacksConfiguration")
+	sizes, err := ps.next.GetDefaultPacksConfiguration(ctx)
+	end(span, err)
+	return sizes, err
+}
+
+func (ps *ProductService) UpdateDefaultPacksConfiguration(ctx context.Context, config []uint64) error {
+	ctx, span := ps.start(ctx, "UpdateDefaultPacksConfiguration", packSizesKey.Int(len(config)))
+	err := ps.next.UpdateDefaultPacksConfiguration(ctx, config)
+	end(span, err)
+	return err
+}
+
+func (ps *ProductService) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return ps.tracer.Start(ctx, "ProductService."+method,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attrs...),
+	)
+}
@@ -0,0 +1,81 @@
+// Package tracing provides OpenTelemetry decorators for the services and repositories of the shipping package,
+// and the tracer provider exporting their spans.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/silvan-talos/shipping"
+)
+
+const instrumentationName = "github.com/silvan-talos/shipping/tracing"
+
+// attributes of the spans
+const (
+	productIDKey   = attribute.Key("shipping.product_id")
+	quantityKey    = attribute.Key("shipping.quantity")
+	warehouseKey   = attribute.Key("shipping.warehouse")
+	carrierKey     = attribute.Key("shipping.carrier")
+	sourceKey      = attribute.Key("shipping.config_level")
+	packCountKey   = attribute.Key("shipping.pack_count")
+	overheadKey    = attribute.Key("shipping.overhead")
+	repositoryKey  = attribute.Key("shipping.repository")
+	packSizesKey   = attribute.Key("shipping.pack_sizes")
+	simulatedKey   = attribute.Key("shipping.simulated_orders")
+	recommendedKey = attribute.Key("shipping.recommended_sizes")
+)
+
+// NewTracerProvider creates a provider exporting the spans with OTLP over gRPC. The exporter is configured
+// through the standard OTEL_EXPORTER_OTLP_* environment variables, e.g. OTEL_EXPORTER_OTLP_ENDPOINT.
+// The provider must be shut down to flush the spans not exported yet.
+func NewTracerProvider(ctx context.Context, serviceName string) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create otlp exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("create resource: %w", err)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	), nil
+}
+
+func tracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(instrumentationName)
+}
+
+// end ends the span, marking it as failed on error. Missing resources are an expected outcome, not a failure.
+func end(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, shipping.ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func countPacks(packs []shipping.PackConfig) int64 {
+	var count int64
+	for _, p := range packs {
+		count += p.Count
+	}
+	return count
+}
@@ -0,0 +1,116 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/silvan-talos/shipping"
+	"github.com/silvan-talos/shipping/mock"
+	"github.com/silvan-talos/shipping/product"
+	"github.com/silvan-talos/shipping/tracing"
+)
+
+var tenantCtx = shipping.WithTenant(context.Background(), "tenant-1")
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestProductService(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	// the solver spans are recorded by the global provider, restored for the other tests. The tracers
+	// obtained before keep delegating to tp, shutting it down stops them recording.
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = tp.Shutdown(context.Background())
+	})
+	ps := tracing.NewProductService(tracing.ProductServiceArgs{
+		Next: product.NewService(product.ServiceArgs{
+			Packs: tracing.NewPackRepository(tracing.PackRepositoryArgs{
+				Next: &mock.PackRepository{
+					GetByWarehouseFn: func(ctx context.Context, warehouse string, productID uint64) ([]uint64, error) {
+						return nil, shipping.ErrNotFound
+					},
+					GetByProductIDFn: func(ctx context.Context, productID uint64) ([]uint64, error) {
+						return []uint64{250, 500}, nil
+					},
+				},
+				Name:           "inmem",
+				TracerProvider: tp,
+			}),
+		}),
+		TracerProvider: tp,
+	})
+
+	_, err := ps.CalculatePacking(tenantCtx, 7, 501, "ams")
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+	names := make([]string, 0, len(spans))
+	for _, s := range spans {
+		names = append(names, s.Name())
+	}
+	require.Equal(t, []string{
+		"PackRepository.GetByWarehouse",
+		"PackRepository.GetByProductID",
+		"solver.min_overhead",
+		"ProductService.CalculatePacking",
+	}, names)
+
+	root := spans[3]
+	for _, s := range spans[:3] {
+		require.Equal(t, root.SpanContext().TraceID(), s.SpanContext().TraceID())
+		require.Equal(t, root.SpanContext().SpanID(), s.Parent().SpanID())
+	}
+	// a missing warehouse configuration is not a failure
+	require.Equal(t, codes.Unset, spans[0].Status().Code)
+	require.Equal(t, map[attribute.Key]attribute.Value{
+		"shipping.product_id":   attribute.Int64Value(7),
+		"shipping.quantity":     attribute.Int64Value(501),
+		"shipping.warehouse":    attribute.StringValue("ams"),
+		"shipping.config_level": attribute.StringValue("product"),
+		"shipping.pack_count":   attribute.Int64Value(2),
+		"shipping.overhead":     attribute.Int64Value(249),
+	}, attributes(root))
+}
+
+func TestPackRepository_Error(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	pr := tracing.NewPackRepository(tracing.PackRepositoryArgs{
+		Next: &mock.PackRepository{
+			UpdateDefaultFn: func(ctx context.Context, config []uint64) ([]uint64, error) {
+				return nil, errors.New("disk full")
+			},
+		},
+		Name:           "file",
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+	})
+
+	_, err := pr.UpdateDefault(tenantCtx, []uint64{250, 500})
+	require.EqualError(t, err, "disk full")
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "PackRepository.UpdateDefault", spans[0].Name())
+	require.Equal(t, sdktrace.Status{Code: codes.Error, Description: "disk full"}, spans[0].Status())
+	require.Equal(t, map[attribute.Key]attribute.Value{
+		"shipping.pack_sizes": attribute.IntValue(2),
+		"shipping.repository": attribute.StringValue("file"),
+	}, attributes(spans[0]))
+}